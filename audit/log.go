@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/orbs-network/boyarin/utils"
+)
+
+const (
+	ACTION_PULL           = "pull"
+	ACTION_CREATE         = "create"
+	ACTION_REMOVE         = "remove"
+	ACTION_PURGE          = "purge"
+	ACTION_ROTATE_SECRETS = "rotate-secrets"
+	ACTION_UPDATE_CONFIG  = "update-config"
+	ACTION_SELF_UPDATE    = "self-update"
+	ACTION_SNAPSHOT       = "snapshot"
+	ACTION_RESTORE        = "restore"
+	ACTION_BOOTSTRAP      = "bootstrap"
+)
+
+const (
+	RESULT_SUCCESS = "success"
+	RESULT_FAILURE = "failure"
+)
+
+const DEFAULT_LOG_MAX_SIZE = 10 * 1024 * 1024
+const SECRETS_HASH_SUFFIX = ".secrets"
+
+// hashes of every target are kept under separate keys, container names never contain a slash
+const (
+	SECRETS_HASH_KEY_SUFFIX = "/secrets"
+	CONFIG_HASH_KEY_SUFFIX  = "/config"
+)
+
+// single action, stored as a json line
+type Entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	ConfigHash string    `json:"configHash,omitempty"`
+	Details    string    `json:"details,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Append only log rotated to a single backup file, nil if the audit log is disabled
+type Log struct {
+	lines *utils.JsonLinesLog
+
+	mutex           sync.Mutex
+	secretsHashPath string
+	secretsHash     map[string]string
+}
+
+// Returns nil if the path is empty
+func NewLog(path string, maxSize int64) *Log {
+	if path == "" {
+		return nil
+	}
+
+	return &Log{
+		lines:           utils.NewJsonLinesLog(path, maxSize),
+		secretsHashPath: path + SECRETS_HASH_SUFFIX,
+	}
+}
+
+func (l *Log) Record(action string, target string, configHash string, details string, start time.Time, err error) error {
+	if l == nil {
+		return nil
+	}
+
+	entry := &Entry{
+		Timestamp:  start,
+		Action:     action,
+		Target:     target,
+		ConfigHash: configHash,
+		Details:    details,
+		DurationMs: time.Since(start).Nanoseconds() / int64(time.Millisecond),
+		Result:     RESULT_SUCCESS,
+	}
+
+	if err != nil {
+		entry.Result = RESULT_FAILURE
+		entry.Error = err.Error()
+	}
+
+	return l.lines.Append(entry)
+}
+
+// Reports whether the secrets or the config (depending on the key) are different from the ones the target was started with before.
+// Only hashes are kept next to the log, so that a change is still noticed after a restart.
+func (l *Log) hashChanged(key string, hash string) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.secretsHash == nil {
+		secretsHash, err := readSecretsHash(l.secretsHashPath)
+		if err != nil {
+			return false, err
+		}
+		l.secretsHash = secretsHash
+	}
+
+	previous, found := l.secretsHash[key]
+	if found && previous == hash {
+		return false, nil
+	}
+
+	l.secretsHash[key] = hash
+	return found, writeSecretsHash(l.secretsHashPath, l.secretsHash)
+}
+
+// Missing file means nothing was started yet
+func readSecretsHash(path string) (map[string]string, error) {
+	secretsHash := make(map[string]string)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return secretsHash, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &secretsHash); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+
+	return secretsHash, nil
+}
+
+func writeSecretsHash(path string, secretsHash map[string]string) error {
+	data, err := json.Marshal(secretsHash)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+type Filter struct {
+	Target string // either exact container name or its suffix, so that chain-42 matches namespaced containers too
+	Action string
+	Since  time.Time
+	Limit  int // newest entries are kept, unlimited if zero
+}
+
+func (f Filter) matches(entry *Entry) bool {
+	if f.Target != "" && entry.Target != f.Target && !strings.HasSuffix(entry.Target, "-"+f.Target) {
+		return false
+	}
+
+	if f.Action != "" && entry.Action != f.Action {
+		return false
+	}
+
+	return !entry.Timestamp.Before(f.Since)
+}
+
+// Oldest entries first, including the rotated file
+func Query(path string, filter Filter) (entries []*Entry, err error) {
+	err = utils.NewJsonLinesLog(path, DEFAULT_LOG_MAX_SIZE).Scan(func(line []byte) {
+		entry := &Entry{}
+		// skip partially written lines
+		if json.Unmarshal(line, entry) == nil && filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+
+	return entries, nil
+}
+
+// One line per entry: time, action, target, result, duration and the rest only if present
+func FormatEntry(entry *Entry) string {
+	line := fmt.Sprintf("%s %-14s %-30s %-7s %6dms", entry.Timestamp.Format(time.RFC3339), entry.Action, entry.Target, entry.Result, entry.DurationMs)
+
+	if entry.ConfigHash != "" {
+		line += " config " + entry.ConfigHash
+	}
+
+	if entry.Details != "" {
+		line += " " + entry.Details
+	}
+
+	if entry.Error != "" {
+		line += ": " + entry.Error
+	}
+
+	return line
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func withAuditLog(t *testing.T, maxSize int64, f func(path string, log *Log)) {
+	dir, err := ioutil.TempDir("", "boyar-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	f(path, NewLog(path, maxSize))
+}
+
+func TestLog_QueryFiltersEntries(t *testing.T) {
+	withAuditLog(t, DEFAULT_LOG_MAX_SIZE, func(path string, log *Log) {
+		start := time.Now()
+		require.NoError(t, log.Record(ACTION_PULL, "orbsnetwork/node:v2.0.0", "5f1d", "", start.Add(-2*time.Hour), nil))
+		require.NoError(t, log.Record(ACTION_CREATE, "a328846c-chain-42", "5f1d", "orbsnetwork/node:v2.0.0", start.Add(-time.Hour), nil))
+		require.NoError(t, log.Record(ACTION_CREATE, "a328846c-chain-1991", "5f1d", "orbsnetwork/node:v2.0.0", start, fmt.Errorf("no such image")))
+		require.NoError(t, log.Record(ACTION_REMOVE, "a328846c-chain-42", "9a0c", "", start, nil))
+
+		all, err := Query(path, Filter{})
+		require.NoError(t, err)
+		require.Len(t, all, 4)
+		require.Equal(t, RESULT_FAILURE, all[2].Result)
+		require.Equal(t, "no such image", all[2].Error)
+
+		chain, err := Query(path, Filter{Target: "chain-42"})
+		require.NoError(t, err)
+		require.Len(t, chain, 2)
+		require.Equal(t, "9a0c", chain[1].ConfigHash)
+
+		created, err := Query(path, Filter{Action: ACTION_CREATE, Since: start.Add(-time.Minute)})
+		require.NoError(t, err)
+		require.Len(t, created, 1)
+		require.Equal(t, "a328846c-chain-1991", created[0].Target)
+
+		latest, err := Query(path, Filter{Limit: 1})
+		require.NoError(t, err)
+		require.Equal(t, ACTION_REMOVE, latest[0].Action)
+	})
+}
+
+func TestLog_RotatesBySize(t *testing.T) {
+	withAuditLog(t, 300, func(path string, log *Log) {
+		for i := 0; i < 5; i++ {
+			require.NoError(t, log.Record(ACTION_CREATE, fmt.Sprintf("chain-%d", i), "5f1d", "", time.Now(), nil))
+		}
+
+		_, err := os.Stat(path + utils.ROTATED_SUFFIX)
+		require.NoError(t, err)
+
+		entries, err := Query(path, Filter{})
+		require.NoError(t, err)
+		require.Equal(t, "chain-4", entries[len(entries)-1].Target)
+		require.True(t, len(entries) < 5, "entries older than the backup file should be gone")
+	})
+}
+
+func TestAuditingOrchestrator(t *testing.T) {
+	withAuditLog(t, DEFAULT_LOG_MAX_SIZE, func(path string, log *Log) {
+		ctx := context.Background()
+		serviceConfig := &adapter.ServiceConfig{ContainerName: "chain-42", ImageName: "orbsnetwork/node:v2.0.0"}
+
+		first := NewAuditingOrchestrator(adapter.NewRecordingOrchestrator(nil), log, "5f1d")
+		require.NoError(t, first.RunVirtualChain(ctx, serviceConfig, &adapter.AppConfig{KeyPair: []byte("old"), Config: []byte("{}")}))
+
+		// rotations are noticed across restarts
+		second := NewAuditingOrchestrator(adapter.NewRecordingOrchestrator(nil), NewLog(path, DEFAULT_LOG_MAX_SIZE), "9a0c")
+		require.NoError(t, second.RunVirtualChain(ctx, serviceConfig, &adapter.AppConfig{KeyPair: []byte("new"), Config: []byte("{}")}))
+		require.NoError(t, second.RunVirtualChain(ctx, serviceConfig, &adapter.AppConfig{KeyPair: []byte("new"), Config: []byte("{}")}))
+
+		// config changes are not secret rotations
+		third := NewAuditingOrchestrator(adapter.NewRecordingOrchestrator(nil), NewLog(path, DEFAULT_LOG_MAX_SIZE), "e7b2")
+		require.NoError(t, third.RunVirtualChain(ctx, serviceConfig, &adapter.AppConfig{KeyPair: []byte("new"), Config: []byte(`{"logger-http-endpoint": "http://logs"}`)}))
+		require.NoError(t, third.RemoveService(ctx, "chain-1991"))
+
+		entries, err := Query(path, Filter{})
+		require.NoError(t, err)
+
+		var actions []string
+		for _, entry := range entries {
+			actions = append(actions, entry.Action+" "+entry.Target+" "+entry.ConfigHash)
+		}
+
+		require.Equal(t, []string{
+			"create chain-42 5f1d",
+			"create chain-42 9a0c",
+			"rotate-secrets chain-42 9a0c",
+			"create chain-42 9a0c",
+			"create chain-42 e7b2",
+			"update-config chain-42 e7b2",
+			"remove chain-1991 e7b2",
+		}, actions)
+		require.Equal(t, "orbsnetwork/node:v2.0.0", entries[0].Details)
+	})
+
+	orchestrator := adapter.NewRecordingOrchestrator(nil)
+	require.Equal(t, orchestrator, NewAuditingOrchestrator(orchestrator, NewLog("", DEFAULT_LOG_MAX_SIZE), "5f1d"))
+}
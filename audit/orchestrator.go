@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/orbs-network/boyarin/snapshot"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+)
+
+// Records every call that changes running services, read-only calls are passed through
+type auditingOrchestrator struct {
+	adapter.Orchestrator
+	log        *Log
+	configHash string
+}
+
+// Returns the orchestrator as is if the audit log is disabled
+func NewAuditingOrchestrator(orchestrator adapter.Orchestrator, log *Log, configHash string) adapter.Orchestrator {
+	if log == nil {
+		return orchestrator
+	}
+
+	return &auditingOrchestrator{
+		Orchestrator: orchestrator,
+		log:          log,
+		configHash:   configHash,
+	}
+}
+
+func (o *auditingOrchestrator) record(action string, target string, details string, start time.Time, err error) {
+	// failing to audit should never fail provisioning
+	o.log.Record(action, target, o.configHash, details, start, err)
+}
+
+func (o *auditingOrchestrator) PullImage(ctx context.Context, imageName string) error {
+	start := time.Now()
+	err := o.Orchestrator.PullImage(ctx, imageName)
+	o.record(ACTION_PULL, imageName, "", start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) RunVirtualChain(ctx context.Context, serviceConfig *adapter.ServiceConfig, appConfig *adapter.AppConfig) error {
+	start := time.Now()
+	err := o.Orchestrator.RunVirtualChain(ctx, serviceConfig, appConfig)
+	o.recordRun(serviceConfig, appConfig, start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) RunService(ctx context.Context, serviceConfig *adapter.ServiceConfig, appConfig *adapter.AppConfig) error {
+	start := time.Now()
+	err := o.Orchestrator.RunService(ctx, serviceConfig, appConfig)
+	o.recordRun(serviceConfig, appConfig, start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) recordRun(serviceConfig *adapter.ServiceConfig, appConfig *adapter.AppConfig, start time.Time, err error) {
+	o.record(ACTION_CREATE, serviceConfig.ContainerName, serviceConfig.ImageName, start, err)
+
+	if err != nil {
+		return
+	}
+
+	if changed, err := o.log.hashChanged(serviceConfig.ContainerName+SECRETS_HASH_KEY_SUFFIX, hashSecrets(appConfig)); changed || err != nil {
+		o.record(ACTION_ROTATE_SECRETS, serviceConfig.ContainerName, "", start, err)
+	}
+
+	if changed, err := o.log.hashChanged(serviceConfig.ContainerName+CONFIG_HASH_KEY_SUFFIX, hashConfig(appConfig)); changed || err != nil {
+		o.record(ACTION_UPDATE_CONFIG, serviceConfig.ContainerName, "", start, err)
+	}
+}
+
+func (o *auditingOrchestrator) RunReverseProxy(ctx context.Context, config *adapter.ReverseProxyConfig) error {
+	start := time.Now()
+	err := o.Orchestrator.RunReverseProxy(ctx, config)
+	o.record(ACTION_CREATE, config.ContainerName, "", start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) RemoveService(ctx context.Context, containerName string) error {
+	start := time.Now()
+	err := o.Orchestrator.RemoveService(ctx, containerName)
+	o.record(ACTION_REMOVE, containerName, "", start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) PurgeServiceData(ctx context.Context, containerName string) error {
+	start := time.Now()
+	err := o.Orchestrator.PurgeServiceData(ctx, containerName)
+	o.record(ACTION_PURGE, containerName, "", start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) PurgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error {
+	start := time.Now()
+	err := o.Orchestrator.PurgeVirtualChainData(ctx, nodeAddress, vcId, containerName)
+	o.record(ACTION_PURGE, containerName, "", start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) SnapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	start := time.Now()
+	err := o.Orchestrator.SnapshotVirtualChainData(ctx, nodeAddress, vcId, containerName, archivePath)
+	o.record(ACTION_SNAPSHOT, containerName, "to "+archivePath, start, err)
+	return err
+}
+
+func (o *auditingOrchestrator) RestoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	start := time.Now()
+	err := o.Orchestrator.RestoreVirtualChainData(ctx, nodeAddress, vcId, containerName, archivePath)
+	o.record(ACTION_RESTORE, containerName, "from "+archivePath, start, err)
+	return err
+}
+
+// Skipped bootstraps did not change anything, the outcome of started ones is in status
+func (o *auditingOrchestrator) BootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, source *snapshot.Source, progress *snapshot.Progress) (bool, error) {
+	start := time.Now()
+	started, err := o.Orchestrator.BootstrapVirtualChainData(ctx, nodeAddress, vcId, containerName, source, progress)
+	if started || err != nil {
+		o.record(ACTION_BOOTSTRAP, containerName, "started from "+source.Location, start, err)
+	}
+	return started, err
+}
+
+// Only the key pair is secret, the rest of the app config changes with every configuration
+func hashSecrets(appConfig *adapter.AppConfig) string {
+	if appConfig == nil {
+		return ""
+	}
+
+	return hashParts(appConfig.KeyPair)
+}
+
+func hashConfig(appConfig *adapter.AppConfig) string {
+	if appConfig == nil {
+		return ""
+	}
+
+	return hashParts(appConfig.Network, appConfig.Config)
+}
+
+func hashParts(parts ...[]byte) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
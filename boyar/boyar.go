@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/snapshot"
 	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/utils"
 	"github.com/orbs-network/scribe/log"
@@ -14,6 +15,8 @@ type Cache struct {
 	vChains  *utils.CacheMap
 	nginx    *utils.CacheFilter
 	services *utils.CacheMap
+
+	bootstraps *snapshot.Tracker
 }
 
 func NewCache() *Cache {
@@ -21,13 +24,21 @@ func NewCache() *Cache {
 		vChains:  utils.NewCacheMap(),
 		nginx:    utils.NewCacheFilter(),
 		services: utils.NewCacheMap(),
+
+		bootstraps: snapshot.NewTracker(),
 	}
 }
 
+// Progress of vchain bootstraps by container name, for status reporting
+func (c *Cache) Bootstraps() *snapshot.Tracker {
+	return c.bootstraps
+}
+
 type Boyar interface {
 	ProvisionVirtualChains(ctx context.Context) error
 	ProvisionHttpAPIEndpoint(ctx context.Context) error
 	ProvisionServices(ctx context.Context) error
+	Reconcile(ctx context.Context) error
 }
 
 type boyar struct {
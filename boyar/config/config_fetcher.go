@@ -0,0 +1,243 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/orbs-network/boyarin/utils"
+)
+
+const CONFIG_FETCH_TIMEOUT = 30 * time.Second
+const CONFIG_FETCH_TRIES = 3
+const CONFIG_FETCH_RETRY_INTERVAL = 1 * time.Second
+
+const (
+	CONFIG_ORIGIN_NETWORK      = "network"
+	CONFIG_ORIGIN_NOT_MODIFIED = "not-modified"
+	CONFIG_ORIGIN_CACHE        = "last-known-good"
+)
+
+type configFetcherEntry struct {
+	Body      []byte
+	ETag      string
+	FetchedAt time.Time // last time the source confirmed the content
+
+	origin string
+}
+
+type ConfigSourceStatus struct {
+	Url       string
+	Origin    string
+	ETag      string `json:",omitempty"`
+	FetchedAt time.Time
+	Age       string
+}
+
+// Downloads configuration with retries and conditional requests.
+// Content of the last configuration that was successfully loaded is kept on disk (if the path is provided),
+// and used on startup if the sources are unreachable.
+type ConfigFetcher struct {
+	client        *http.Client
+	cachePath     string
+	tries         int
+	retryInterval time.Duration
+
+	mutex       sync.Mutex
+	entries     map[string]*configFetcherEntry
+	lastKnown   map[string]*configFetcherEntry
+	used        map[string]bool
+	initialized bool
+}
+
+func NewConfigFetcher(cachePath string) *ConfigFetcher {
+	f := &ConfigFetcher{
+		client:        &http.Client{Timeout: CONFIG_FETCH_TIMEOUT},
+		cachePath:     cachePath,
+		tries:         CONFIG_FETCH_TRIES,
+		retryInterval: CONFIG_FETCH_RETRY_INTERVAL,
+		entries:       make(map[string]*configFetcherEntry),
+		lastKnown:     make(map[string]*configFetcherEntry),
+		used:          make(map[string]bool),
+	}
+
+	if cachePath != "" {
+		if data, err := ioutil.ReadFile(cachePath); err == nil {
+			json.Unmarshal(data, &f.lastKnown)
+		}
+	}
+
+	return f
+}
+
+type httpStatusError struct {
+	code   int
+	status string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("management config url returned with status %s", e.status)
+}
+
+// Client errors are not going to be fixed by retrying
+func isRetryable(err error) bool {
+	statusError, ok := err.(*httpStatusError)
+	return !ok || statusError.code >= http.StatusInternalServerError
+}
+
+// The mutex is only held around the maps, status is never blocked by retries
+func (f *ConfigFetcher) Fetch(url string) ([]byte, error) {
+	f.mutex.Lock()
+	previous := f.entries[url]
+	f.mutex.Unlock()
+
+	var entry *configFetcherEntry
+	var fetchErr error
+	err := utils.Try(context.Background(), f.tries, time.Duration(f.tries)*f.client.Timeout, f.retryInterval, func(ctx context.Context) error {
+		entry, fetchErr = f.get(ctx, url, previous)
+		if isRetryable(fetchErr) {
+			return fetchErr
+		}
+		return nil
+	})
+	if err == nil {
+		err = fetchErr
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err != nil {
+		// only on startup, otherwise the daemon keeps running with what it has
+		if cached, found := f.lastKnown[url]; found && !f.initialized {
+			f.entries[url] = &configFetcherEntry{Body: cached.Body, ETag: cached.ETag, FetchedAt: cached.FetchedAt, origin: CONFIG_ORIGIN_CACHE}
+			f.used[url] = true
+			return cached.Body, nil
+		}
+
+		return nil, err
+	}
+
+	f.entries[url] = entry
+	f.used[url] = true
+	return entry.Body, nil
+}
+
+func (f *ConfigFetcher) get(ctx context.Context, url string, previous *configFetcherEntry) (*configFetcherEntry, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not download configuration from source: %s", err)
+	}
+	req = req.WithContext(ctx)
+
+	if previous != nil && previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not download configuration from source: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && previous != nil {
+		return &configFetcherEntry{Body: previous.Body, ETag: previous.ETag, FetchedAt: time.Now(), origin: CONFIG_ORIGIN_NOT_MODIFIED}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read configuration from source: %s", err)
+	}
+
+	return &configFetcherEntry{Body: body, ETag: resp.Header.Get("ETag"), FetchedAt: time.Now(), origin: CONFIG_ORIGIN_NETWORK}, nil
+}
+
+// Called once the configuration passed verification, stores everything that was used to assemble it
+func (f *ConfigFetcher) Commit() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.initialized = true
+
+	lastKnown := make(map[string]*configFetcherEntry)
+	for url := range f.used {
+		lastKnown[url] = f.entries[url]
+	}
+	f.lastKnown = lastKnown
+	f.used = make(map[string]bool)
+
+	if f.cachePath == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(lastKnown)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.cachePath), 0755); err != nil {
+		return fmt.Errorf("could not save last known good configuration: %s", err)
+	}
+
+	// written separately and moved, so that a crash does not leave a broken cache
+	tmpPath := f.cachePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("could not save last known good configuration: %s", err)
+	}
+
+	return os.Rename(tmpPath, f.cachePath)
+}
+
+// Forgets sources used by a configuration that failed verification
+func (f *ConfigFetcher) Discard() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.used = make(map[string]bool)
+}
+
+// Sources of the last configuration that was successfully loaded
+func (f *ConfigFetcher) Status() []*ConfigSourceStatus {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var urls []string
+	for url := range f.lastKnown {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	var status []*ConfigSourceStatus
+	for _, url := range urls {
+		entry := f.lastKnown[url]
+		if current, found := f.entries[url]; found {
+			entry = current
+		}
+
+		origin := entry.origin
+		if origin == "" {
+			origin = CONFIG_ORIGIN_CACHE
+		}
+
+		status = append(status, &ConfigSourceStatus{
+			Url:       url,
+			Origin:    origin,
+			ETag:      entry.ETag,
+			FetchedAt: entry.FetchedAt,
+			Age:       time.Since(entry.FetchedAt).Round(time.Second).String(),
+		})
+	}
+
+	return status
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfigFetcher(cachePath string) *ConfigFetcher {
+	fetcher := NewConfigFetcher(cachePath)
+	fetcher.retryInterval = 10 * time.Millisecond
+	return fetcher
+}
+
+func TestConfigFetcher_NotModified(t *testing.T) {
+	var conditionalRequests int
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("If-None-Match") == `"v1"` {
+			conditionalRequests++
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		writer.Header().Set("ETag", `"v1"`)
+		writer.Write([]byte("{}"))
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	fetcher := newTestConfigFetcher("")
+
+	body, err := fetcher.Fetch(server.Url())
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(body))
+	require.NoError(t, fetcher.Commit())
+
+	body, err = fetcher.Fetch(server.Url())
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(body))
+	require.Equal(t, 1, conditionalRequests)
+
+	status := fetcher.Status()
+	require.Len(t, status, 1)
+	require.Equal(t, CONFIG_ORIGIN_NOT_MODIFIED, status[0].Origin)
+	require.Equal(t, `"v1"`, status[0].ETag)
+}
+
+func TestConfigFetcher_FallbackToLastKnownGoodOnStartup(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "config-fetcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	cachePath := filepath.Join(tmp, "cache", "config.json")
+
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(`{"chains":[]}`))
+	})
+	server.Start()
+	url := server.Url()
+
+	fetcher := newTestConfigFetcher(cachePath)
+	_, err = fetcher.Fetch(url)
+	require.NoError(t, err)
+	require.NoError(t, fetcher.Commit())
+
+	server.Shutdown()
+
+	_, err = fetcher.Fetch(url)
+	require.Error(t, err, "should not fall back after the configuration was loaded")
+
+	restarted := newTestConfigFetcher(cachePath)
+	body, err := restarted.Fetch(url)
+	require.NoError(t, err)
+	require.Equal(t, `{"chains":[]}`, string(body))
+	require.NoError(t, restarted.Commit())
+
+	status := restarted.Status()
+	require.Len(t, status, 1)
+	require.Equal(t, url, status[0].Url)
+	require.Equal(t, CONFIG_ORIGIN_CACHE, status[0].Origin)
+}
+
+func TestConfigFetcher_DiscardDoesNotUpdateLastKnownGood(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "config-fetcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	cachePath := filepath.Join(tmp, "config.json")
+
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("{}"))
+	})
+	server.Start()
+	url := server.Url()
+
+	fetcher := newTestConfigFetcher(cachePath)
+	_, err = fetcher.Fetch(url)
+	require.NoError(t, err)
+	fetcher.Discard()
+
+	require.Empty(t, fetcher.Status())
+	require.NoError(t, fetcher.Commit())
+
+	server.Shutdown()
+
+	_, err = newTestConfigFetcher(cachePath).Fetch(url)
+	require.Error(t, err)
+}
+
+func TestConfigFetcher_RetriesOnlyServerErrors(t *testing.T) {
+	requests := make(map[string]int)
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		requests[request.URL.Path]++
+		if request.URL.Path == "/not-found" {
+			writer.WriteHeader(http.StatusNotFound)
+		} else {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	fetcher := newTestConfigFetcher("")
+
+	_, err := fetcher.Fetch(server.Url() + "not-found")
+	require.EqualError(t, err, "management config url returned with status 404 Not Found")
+	require.Equal(t, 1, requests["/not-found"])
+
+	_, err = fetcher.Fetch(server.Url() + "broken")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "management config url returned with status 500 Internal Server Error")
+	require.Equal(t, CONFIG_FETCH_TRIES, requests["/broken"])
+}
+
+func TestConfigFetcher_StatusDoesNotWaitForFetch(t *testing.T) {
+	release := make(chan struct{})
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+		writer.Write([]byte("{}"))
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	fetcher := newTestConfigFetcher("")
+	fetched := make(chan error)
+	go func() {
+		_, err := fetcher.Fetch(server.Url())
+		fetched <- err
+	}()
+
+	status := make(chan []*ConfigSourceStatus)
+	go func() {
+		status <- fetcher.Status()
+	}()
+
+	select {
+	case <-status:
+	case <-time.After(time.Second):
+		t.Fatal("status is blocked by the fetch")
+	}
+
+	close(release)
+	require.NoError(t, <-fetched)
+}
@@ -6,6 +6,16 @@ type Flags struct {
 	ConfigUrl         string
 	KeyPairConfigPath string
 
+	// Signature verification is only enforced if trusted keys are provided
+	ConfigTrustedKeys  []string
+	ConfigSignatureUrl string
+
+	// Merged on top of ConfigUrl in order
+	ConfigSources []string
+
+	// Last known good configuration, used on startup if the sources are unreachable
+	ConfigCachePath string
+
 	SSLCertificatePath string
 	SSLPrivateKeyPath  string
 
@@ -14,6 +24,9 @@ type Flags struct {
 	MaxReloadTimeDelay    time.Duration
 	BootstrapResetTimeout time.Duration
 
+	// New configuration is reverted if the services it touched fail within this period, disabled if zero
+	ConfigSettlePeriod time.Duration
+
 	EthereumEndpoint string
 
 	LoggerHttpEndpoint string
@@ -22,6 +35,29 @@ type Flags struct {
 	StatusFilePath  string
 	MetricsFilePath string
 
+	AdminListen string
+
+	// Every action that changes running services is appended there, disabled if empty
+	AuditLogPath string
+
+	// Virtual chains and services only run from images signed by one of these keys (paths to PEM files), disabled if empty
+	ImageTrustedKeys []string
+
+	// Health is reported as a warning above these thresholds (in percent), defaults are used if zero
+	HealthMaxCPULoad    float64
+	HealthMaxMemoryUsed float64
+	HealthMaxDiskUsed   float64
+
+	// Configuration that was not received for longer is stale, disabled if zero
+	HealthMaxConfigAge time.Duration
+
+	// Notifications about state transitions, disabled if no sinks are provided
+	NotificationWebhooks      []string
+	NotificationSlackWebhooks []string
+	NotificationScript        string
+	NotificationDedupPeriod   time.Duration
+	NotificationRateLimit     int // per hour
+
 	OrchestratorOptions string
 
 	ManagementConfig string
@@ -31,6 +67,12 @@ type Flags struct {
 	ShutdownAfterUpdate bool
 	BoyarBinaryPath     string
 
+	// Update manifests must be signed with one of these keys, updates without a manifest are refused if not empty
+	UpdateTrustedKeys []string
+
+	// Updated binary is replaced with the previous one if it does not apply the configuration within this period, disabled if zero
+	UpdateRollbackTimeout time.Duration
+
 	// Testing only
 	WithNamespace bool
 }
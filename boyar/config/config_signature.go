@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/orbs-network/boyarin/crypto"
+)
+
+const CONFIG_SIGNATURE_SUFFIX = ".sig"
+
+// Returned when the configuration was downloaded but could not be trusted
+type ConfigSignatureError struct {
+	Reason string
+}
+
+func (e *ConfigSignatureError) Error() string {
+	return fmt.Sprintf("config signature verification failed: %s", e.Reason)
+}
+
+func IsConfigSignatureError(err error) bool {
+	_, ok := err.(*ConfigSignatureError)
+	return ok
+}
+
+func getConfigSignatureUrl(flags *Flags) string {
+	if flags.ConfigSignatureUrl != "" {
+		return flags.ConfigSignatureUrl
+	}
+
+	return flags.ConfigUrl + CONFIG_SIGNATURE_SUFFIX
+}
+
+func VerifyConfigSignature(trustedKeys []string, input []byte, signatures []byte) (*crypto.PublicKey, error) {
+	keys, err := crypto.ParsePublicKeys(trustedKeys)
+	if err != nil {
+		return nil, &ConfigSignatureError{Reason: err.Error()}
+	}
+
+	key, err := crypto.VerifyDetachedSignature(keys, input, signatures)
+	if err != nil {
+		return nil, &ConfigSignatureError{Reason: err.Error()}
+	}
+
+	return key, nil
+}
+
+func downloadConfigSignature(fetcher *ConfigFetcher, url string) ([]byte, error) {
+	signatures, err := fetcher.Fetch(url)
+	if statusError, ok := err.(*httpStatusError); ok {
+		return nil, &ConfigSignatureError{Reason: fmt.Sprintf("signature url returned with status %s", statusError.status)}
+	} else if err != nil {
+		return nil, &ConfigSignatureError{Reason: fmt.Sprintf("could not download signature: %s", err)}
+	}
+
+	return signatures, nil
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Arrays of objects that are merged element by element, matched by the key
+var mergeKeys = map[string]string{
+	"chains":  "Id",
+	"network": "address",
+}
+
+type configurationSource struct {
+	Name  string
+	Input []byte
+}
+
+// Where the configuration came from: sources in order of precedence and values set by sources other than the first one
+type ConfigProvenance struct {
+	Sources   []string
+	Overrides map[string]string // json path -> source
+}
+
+func isHttpSource(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// Downloads the configuration and verifies its signature if trusted keys are provided
+func readRemoteConfigurationSource(flags *Flags, fetcher *ConfigFetcher, url string, signatureUrl string) (*configurationSource, error) {
+	input, err := fetcher.Fetch(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(flags.ConfigTrustedKeys) > 0 {
+		signatures, err := downloadConfigSignature(fetcher, signatureUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := VerifyConfigSignature(flags.ConfigTrustedKeys, input, signatures); err != nil {
+			return nil, err
+		}
+	}
+
+	return &configurationSource{Name: url, Input: input}, nil
+}
+
+// Location could be an http url, a file or a directory with json files that are applied in lexical order
+func readConfigurationSources(flags *Flags, fetcher *ConfigFetcher, location string) ([]*configurationSource, error) {
+	if isHttpSource(location) {
+		source, err := readRemoteConfigurationSource(flags, fetcher, location, location+CONFIG_SIGNATURE_SUFFIX)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*configurationSource{source}, nil
+	}
+
+	path := strings.TrimPrefix(location, "file://")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read configuration source %s: %s", location, err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	var sources []*configurationSource
+	for _, file := range files {
+		input, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read configuration source %s: %s", file, err)
+		}
+
+		sources = append(sources, &configurationSource{Name: file, Input: input})
+	}
+
+	return sources, nil
+}
+
+// Later sources take precedence: objects are merged recursively, keys are matched case insensitively like in encoding/json,
+// chains are matched by Id and peers by address, other values are replaced, null removes the value
+func mergeConfigurationSources(sources []*configurationSource) ([]byte, *ConfigProvenance, error) {
+	provenance := &ConfigProvenance{
+		Overrides: make(map[string]string),
+	}
+
+	var merged interface{}
+	for i, source := range sources {
+		provenance.Sources = append(provenance.Sources, source.Name)
+
+		decoder := json.NewDecoder(bytes.NewReader(source.Input))
+		decoder.UseNumber()
+
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("could not parse configuration source %s: %s", source.Name, err)
+		}
+
+		if i == 0 {
+			merged = value
+			continue
+		}
+
+		merged = mergeValue(merged, value, "", source.Name, provenance)
+	}
+
+	data, err := json.Marshal(merged)
+	return data, provenance, err
+}
+
+func mergeValue(base interface{}, override interface{}, path string, source string, provenance *ConfigProvenance) interface{} {
+	baseMap, baseIsMap := base.(map[string]interface{})
+	overrideMap, overrideIsMap := override.(map[string]interface{})
+	if !baseIsMap || !overrideIsMap {
+		recordOverride(override, path, source, provenance)
+		return override
+	}
+
+	for key, value := range overrideMap {
+		baseKey := findKey(baseMap, key)
+		keyPath := joinPath(path, baseKey)
+
+		if value == nil {
+			delete(baseMap, baseKey)
+			provenance.Overrides[keyPath] = source
+			continue
+		}
+
+		if mergeKey, found := mergeKeys[strings.ToLower(baseKey)]; found && path == "" {
+			baseMap[baseKey] = mergeArray(baseMap[baseKey], value, mergeKey, keyPath, source, provenance)
+		} else {
+			baseMap[baseKey] = mergeValue(baseMap[baseKey], value, keyPath, source, provenance)
+		}
+	}
+
+	return baseMap
+}
+
+func mergeArray(base interface{}, override interface{}, mergeKey string, path string, source string, provenance *ConfigProvenance) interface{} {
+	baseArray, baseIsArray := base.([]interface{})
+	overrideArray, overrideIsArray := override.([]interface{})
+	if !baseIsArray || !overrideIsArray {
+		recordOverride(override, path, source, provenance)
+		return override
+	}
+
+	for _, element := range overrideArray {
+		index := findElement(baseArray, element, mergeKey)
+		if index < 0 {
+			baseArray = append(baseArray, element)
+			recordOverride(element, fmt.Sprintf("%s[%d]", path, len(baseArray)-1), source, provenance)
+		} else {
+			baseArray[index] = mergeValue(baseArray[index], element, fmt.Sprintf("%s[%d]", path, index), source, provenance)
+		}
+	}
+
+	return baseArray
+}
+
+func findKey(value map[string]interface{}, key string) string {
+	if _, found := value[key]; found {
+		return key
+	}
+
+	for existingKey := range value {
+		if strings.EqualFold(existingKey, key) {
+			return existingKey
+		}
+	}
+
+	return key
+}
+
+func findElement(array []interface{}, element interface{}, mergeKey string) int {
+	elementMap, ok := element.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+
+	id, found := elementMap[findKey(elementMap, mergeKey)]
+	if !found {
+		return -1
+	}
+
+	for i, existing := range array {
+		if existingMap, ok := existing.(map[string]interface{}); ok {
+			if existingId, found := existingMap[findKey(existingMap, mergeKey)]; found && fmt.Sprint(existingId) == fmt.Sprint(id) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// Records every leaf of the value
+func recordOverride(value interface{}, path string, source string, provenance *ConfigProvenance) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			recordOverride(child, joinPath(path, key), source, provenance)
+		}
+	default:
+		provenance.Overrides[path] = source
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
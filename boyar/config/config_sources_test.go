@@ -0,0 +1,93 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConfigurationSources(t *testing.T) {
+	base, err := ioutil.ReadFile("./test/config.json")
+	require.NoError(t, err)
+
+	merged, provenance, err := mergeConfigurationSources([]*configurationSource{
+		{Name: "management", Input: base},
+		{Name: "pin-tag.json", Input: []byte(`{"chains": [{"Id": 42, "DockerConfig": {"tag": "v1.3.16"}}]}`)},
+		{Name: "emergency.json", Input: []byte(`{"services": {"service-name": {"Disabled": true}}, "chains": [{"Id": 2020, "DockerConfig": {"Image": "orbsnetwork/node", "Tag": "v2"}}], "orchestrator": {"max-reload-time-delay": null}}`)},
+	})
+	require.NoError(t, err)
+
+	cfg, err := parseStringConfig(string(merged), "", fakeKeyPair, false)
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Chains(), 4)
+	require.EqualValues(t, 42, cfg.Chains()[0].Id)
+	require.Equal(t, "v1.3.16", cfg.Chains()[0].DockerConfig.Tag)
+	require.Equal(t, "orbsnetwork/node", cfg.Chains()[0].DockerConfig.Image, "should keep values that were not overridden")
+	require.Equal(t, "experimental", cfg.Chains()[1].DockerConfig.Tag)
+	require.EqualValues(t, 2020, cfg.Chains()[3].Id)
+
+	require.True(t, cfg.Services()["service-name"].Disabled)
+	require.False(t, cfg.Services().Signer().Disabled)
+	require.Empty(t, cfg.OrchestratorOptions().MaxReloadTimedDelayStr)
+	require.Equal(t, "ebs", cfg.OrchestratorOptions().StorageDriver)
+
+	require.Equal(t, []string{"management", "pin-tag.json", "emergency.json"}, provenance.Sources)
+	require.Equal(t, "pin-tag.json", provenance.Overrides["chains[0].DockerConfig.Tag"])
+	require.Equal(t, "emergency.json", provenance.Overrides["services.service-name.Disabled"])
+	require.Equal(t, "emergency.json", provenance.Overrides["chains[3].DockerConfig.Tag"])
+	require.Equal(t, "emergency.json", provenance.Overrides["orchestrator.max-reload-time-delay"])
+	require.NotContains(t, provenance.Overrides, "chains[0].DockerConfig.Image")
+}
+
+func TestMergeConfigurationSources_InvalidSource(t *testing.T) {
+	_, _, err := mergeConfigurationSources([]*configurationSource{
+		{Name: "management", Input: []byte(`{}`)},
+		{Name: "broken.json", Input: []byte(`{`)},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "broken.json")
+}
+
+func TestGetConfigurationWithLocalOverrides(t *testing.T) {
+	input, err := ioutil.ReadFile("./test/config.json")
+	require.NoError(t, err)
+
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(input)
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	overrides, err := ioutil.TempDir("", "boyar-overrides")
+	require.NoError(t, err)
+	defer os.RemoveAll(overrides)
+
+	// applied in lexical order
+	require.NoError(t, ioutil.WriteFile(filepath.Join(overrides, "20-tag.json"), []byte(`{"chains": [{"Id": 42, "DockerConfig": {"Tag": "v2"}}]}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(overrides, "10-tag.json"), []byte(`{"chains": [{"Id": 42, "DockerConfig": {"Tag": "v1"}}]}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(overrides, "README.md"), []byte(`not a config`), 0600))
+
+	cfg, err := GetConfiguration(&Flags{
+		ConfigUrl:         server.Url(),
+		KeyPairConfigPath: fakeKeyPair,
+		ConfigSources:     []string{overrides},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "v2", cfg.Chains()[0].DockerConfig.Tag)
+	require.Equal(t, []string{server.Url(), filepath.Join(overrides, "10-tag.json"), filepath.Join(overrides, "20-tag.json")}, cfg.Provenance().Sources)
+	require.Equal(t, filepath.Join(overrides, "20-tag.json"), cfg.Provenance().Overrides["chains[0].DockerConfig.Tag"])
+
+	_, err = GetConfiguration(&Flags{
+		ConfigUrl:         server.Url(),
+		KeyPairConfigPath: fakeKeyPair,
+		ConfigSources:     []string{"file:///does/not/exist.json"},
+	})
+	require.Error(t, err)
+}
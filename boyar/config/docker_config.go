@@ -19,6 +19,7 @@ type DockerResources struct {
 type DockerConfig struct {
 	Image               string
 	Tag                 string
+	Digest              string // sha256:<hex>, pins the image regardless of what the tag points to
 	Pull                bool
 	ContainerNamePrefix string
 	Resources           DockerResources
@@ -26,5 +27,9 @@ type DockerConfig struct {
 }
 
 func (c *DockerConfig) FullImageName() string {
+	if c.Digest != "" {
+		return c.Image + ":" + c.Tag + "@" + c.Digest
+	}
+
 	return c.Image + ":" + c.Tag
 }
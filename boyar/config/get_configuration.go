@@ -7,12 +7,50 @@ import (
 )
 
 func GetConfiguration(flags *Flags) (NodeConfiguration, error) {
-	config, err := NewUrlConfigurationSource(flags.ConfigUrl, flags.EthereumEndpoint, flags.KeyPairConfigPath, flags.WithNamespace)
+	return GetConfigurationWithFetcher(flags, NewConfigFetcher(""))
+}
+
+// Fetcher should be committed by the caller once the configuration is accepted
+func GetConfigurationWithFetcher(flags *Flags, fetcher *ConfigFetcher) (NodeConfiguration, error) {
+	config, err := getConfiguration(flags, fetcher)
+	if err != nil {
+		fetcher.Discard()
+	}
+
+	return config, err
+}
+
+func getConfiguration(flags *Flags, fetcher *ConfigFetcher) (NodeConfiguration, error) {
+	source, err := readRemoteConfigurationSource(flags, fetcher, flags.ConfigUrl, getConfigSignatureUrl(flags))
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []*configurationSource{source}
+	for _, location := range flags.ConfigSources {
+		additionalSources, err := readConfigurationSources(flags, fetcher, location)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, additionalSources...)
+	}
+
+	input := source.Input
+	var provenance *ConfigProvenance
+	if len(sources) > 1 {
+		if input, provenance, err = mergeConfigurationSources(sources); err != nil {
+			return nil, err
+		}
+	}
 
+	config, err := parseStringConfig(string(input), flags.EthereumEndpoint, flags.KeyPairConfigPath, flags.WithNamespace)
 	if err != nil {
 		return nil, err
 	}
 
+	config.provenance = provenance
+
 	config.SetSSLOptions(getSSLOptions(flags))
 
 	if flags.OrchestratorOptions != "" {
@@ -31,6 +69,17 @@ func GetConfiguration(flags *Flags) (NodeConfiguration, error) {
 	return config, err
 }
 
+// Reads configuration written by MarshalNodeConfiguration, it was verified before it was saved
+func ParseSavedConfiguration(flags *Flags, data []byte) (NodeConfiguration, error) {
+	config, err := parseStringConfig(string(data), flags.EthereumEndpoint, flags.KeyPairConfigPath, flags.WithNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse saved configuration: %s", err)
+	}
+
+	config.SetSSLOptions(getSSLOptions(flags))
+	return config, nil
+}
+
 func getOrchestratorOptions(options string) (adapter.OrchestratorOptions, error) {
 	orchestratorOptions := adapter.OrchestratorOptions{}
 	err := json.Unmarshal([]byte(options), &orchestratorOptions)
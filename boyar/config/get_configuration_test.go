@@ -1,8 +1,12 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/orbs-network/boyarin/test/helpers"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
 	"net/http"
 	"testing"
 )
@@ -36,3 +40,109 @@ func Test_VerifyConfigWithCorruptConfig(t *testing.T) {
 	require.EqualError(t, err, "config verification failed: config is missing orchestrator options")
 	require.Nil(t, source)
 }
+
+func createSignedConfigServer(input []byte, signature string) helpers.HttpServer {
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/config.json":
+			writer.Write(input)
+		case "/config.json.sig":
+			if signature == "" {
+				writer.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writer.Write([]byte(signature))
+		}
+	})
+	server.Start()
+
+	return server
+}
+
+func Test_GetConfigurationWithSignature(t *testing.T) {
+	input, err := ioutil.ReadFile("./test/config.json")
+	require.NoError(t, err)
+
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	trustedKeys := []string{"ed25519:" + hex.EncodeToString(publicKey)}
+
+	t.Run("valid signature", func(t *testing.T) {
+		server := createSignedConfigServer(input, hex.EncodeToString(ed25519.Sign(privateKey, input)))
+		defer server.Shutdown()
+
+		cfg, err := GetConfiguration(&Flags{
+			ConfigUrl:         server.Url() + "config.json",
+			KeyPairConfigPath: fakeKeyPair,
+			ConfigTrustedKeys: trustedKeys,
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		_, otherPrivateKey, _ := ed25519.GenerateKey(rand.Reader)
+		server := createSignedConfigServer(input, hex.EncodeToString(ed25519.Sign(otherPrivateKey, input)))
+		defer server.Shutdown()
+
+		cfg, err := GetConfiguration(&Flags{
+			ConfigUrl:         server.Url() + "config.json",
+			KeyPairConfigPath: fakeKeyPair,
+			ConfigTrustedKeys: trustedKeys,
+		})
+
+		require.EqualError(t, err, "config signature verification failed: signature does not match any of the trusted keys")
+		require.True(t, IsConfigSignatureError(err))
+		require.Nil(t, cfg)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		server := createSignedConfigServer(input, "")
+		defer server.Shutdown()
+
+		_, err := GetConfiguration(&Flags{
+			ConfigUrl:         server.Url() + "config.json",
+			KeyPairConfigPath: fakeKeyPair,
+			ConfigTrustedKeys: trustedKeys,
+		})
+
+		require.EqualError(t, err, "config signature verification failed: signature url returned with status 404 Not Found")
+	})
+
+	t.Run("signature is checked before config verification", func(t *testing.T) {
+		server := createSignedConfigServer([]byte("{}"), hex.EncodeToString(ed25519.Sign(privateKey, input)))
+		defer server.Shutdown()
+
+		_, err := GetConfiguration(&Flags{
+			ConfigUrl:         server.Url() + "config.json",
+			KeyPairConfigPath: fakeKeyPair,
+			ConfigTrustedKeys: trustedKeys,
+		})
+
+		require.True(t, IsConfigSignatureError(err))
+	})
+}
+
+func Test_ParseSavedConfiguration(t *testing.T) {
+	input, err := ioutil.ReadFile("./test/configWithSigner.json")
+	require.NoError(t, err)
+
+	flags := &Flags{
+		KeyPairConfigPath:  fakeKeyPair,
+		EthereumEndpoint:   "http://ganache:7545",
+		SSLCertificatePath: "/etc/ssl/cert.pem",
+	}
+
+	cfg, err := parseStringConfig(string(input), flags.EthereumEndpoint, flags.KeyPairConfigPath, flags.WithNamespace)
+	require.NoError(t, err)
+	cfg.SetSSLOptions(getSSLOptions(flags))
+
+	data, err := MarshalNodeConfiguration(cfg)
+	require.NoError(t, err)
+
+	saved, err := ParseSavedConfiguration(flags, data)
+	require.NoError(t, err)
+	require.Equal(t, cfg.Hash(), saved.Hash())
+	require.Equal(t, "/etc/ssl/cert.pem", saved.SSLOptions().SSLCertificatePath)
+	require.NoError(t, saved.VerifyConfig())
+}
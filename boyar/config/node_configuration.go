@@ -24,6 +24,9 @@ type NodeConfiguration interface {
 
 	VerifyConfig() error
 	Hash() string
+
+	// Nil if the configuration was not assembled from multiple sources
+	Provenance() *ConfigProvenance
 }
 
 type MutableNodeConfiguration interface {
@@ -48,6 +51,7 @@ type nodeConfigurationContainer struct {
 	ethereumEndpoint string
 	sslOptions       adapter.SSLOptions
 	withNamespace    bool
+	provenance       *ConfigProvenance
 }
 
 func (c *nodeConfigurationContainer) Chains() []*VirtualChain {
@@ -67,6 +71,20 @@ func (c *nodeConfigurationContainer) Hash() string {
 	return crypto.CalculateHash(data)
 }
 
+// Only the value, the options that come from the flags are applied again by ParseSavedConfiguration
+func MarshalNodeConfiguration(cfg NodeConfiguration) ([]byte, error) {
+	c, ok := cfg.(*nodeConfigurationContainer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported configuration type %T", cfg)
+	}
+
+	return json.Marshal(c.value)
+}
+
+func (c *nodeConfigurationContainer) Provenance() *ConfigProvenance {
+	return c.provenance
+}
+
 func (c *nodeConfigurationContainer) KeyConfigPath() string {
 	return c.keyConfigPath
 }
@@ -79,7 +97,7 @@ func (c *nodeConfigurationContainer) SSLOptions() adapter.SSLOptions {
 	return c.sslOptions
 }
 
-// FIXME should add more checks
+// Returns ValidationErrors with every problem found if the keys and orchestrator options are in place
 func (c *nodeConfigurationContainer) VerifyConfig() error {
 	_, err := c.readKeysConfig()
 	if err != nil {
@@ -90,12 +108,20 @@ func (c *nodeConfigurationContainer) VerifyConfig() error {
 		return fmt.Errorf("config is missing orchestrator options")
 	}
 
+	if errors := validateNodeConfiguration(&c.value); len(errors) > 0 {
+		return errors
+	}
+
 	return nil
 }
 
 func (n *nodeConfiguration) overrideValues(key string, value string) {
 	if value != "" {
 		for _, chain := range n.Chains {
+			// chains added by local overrides do not necessarily come with the config
+			if chain.Config == nil {
+				chain.Config = make(map[string]interface{})
+			}
 			chain.Config[key] = value
 		}
 	}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/orbs-network/boyarin/crypto"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+)
+
+const MAX_PORT = 65535
+
+var validImageDigest = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+var validHostname = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-.]*[a-zA-Z0-9])?$`)
+var validStorageMountTypes = []string{"", "bind", "volume", "tmpfs"}
+var validBackends = []string{"", adapter.SWARM_BACKEND, adapter.DOCKER_BACKEND, adapter.KUBERNETES_BACKEND}
+
+// Single problem with the configuration, path follows the JSON structure (for example, chains[0].DockerConfig.Tag)
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	var messages []string
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+type validator struct {
+	errors ValidationErrors
+
+	// external port -> path of its first user
+	ports map[int]string
+}
+
+func (v *validator) fail(path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, &ValidationError{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) checkPort(path string, port int) {
+	if port < 0 || port > MAX_PORT {
+		v.fail(path, "port %d is out of range", port)
+	}
+}
+
+func (v *validator) checkExternalPort(path string, port int) {
+	v.checkPort(path, port)
+
+	if port == 0 {
+		return
+	}
+
+	if other, found := v.ports[port]; found {
+		v.fail(path, "port %d is already used by %s", port, other)
+	} else {
+		v.ports[port] = path
+	}
+}
+
+// Validates configuration as is, without reading the keys or talking to Docker
+func ValidateConfigString(input string) error {
+	var value nodeConfiguration
+	if err := json.Unmarshal([]byte(input), &value); err != nil {
+		return fmt.Errorf("could not parse configuration: %s", err)
+	}
+
+	if errors := validateNodeConfiguration(&value); len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func validateNodeConfiguration(value *nodeConfiguration) ValidationErrors {
+	v := &validator{
+		ports: make(map[int]string),
+	}
+
+	if value.OrchestratorOptions == nil {
+		v.fail("orchestrator", "is missing")
+	} else {
+		v.validateOrchestratorOptions("orchestrator", value.OrchestratorOptions)
+	}
+
+	chainIds := make(map[VirtualChainId]string)
+	for i, chain := range value.Chains {
+		path := fmt.Sprintf("chains[%d]", i)
+		if chain == nil {
+			v.fail(path, "is empty")
+			continue
+		}
+
+		if other, found := chainIds[chain.Id]; found {
+			v.fail(path+".Id", "virtual chain id %d is already used by %s", chain.Id, other)
+		} else {
+			chainIds[chain.Id] = path
+		}
+
+		v.validateService(path, &chain.Service)
+
+		if chain.Snapshot != nil && !filepath.IsAbs(chain.Snapshot.Path) {
+			v.fail(path+".Snapshot.Path", "%q is not an absolute path", chain.Snapshot.Path)
+		}
+
+		if bootstrap := chain.BootstrapFrom; bootstrap != nil {
+			if bootstrap.Source == "" {
+				v.fail(path+".BootstrapFrom.Source", "is empty")
+			} else if !bootstrap.GetSource().IsUrl() && !filepath.IsAbs(bootstrap.Source) {
+				v.fail(path+".BootstrapFrom.Source", "%q is neither an http(s) url nor an absolute path", bootstrap.Source)
+			}
+			v.checkSha256(path+".BootstrapFrom", bootstrap.Source, bootstrap.Sha256)
+		}
+
+		// volumes of kubernetes are not reachable from the host
+		if (chain.Snapshot != nil || chain.BootstrapFrom != nil) && value.OrchestratorOptions != nil && value.OrchestratorOptions.Backend == adapter.KUBERNETES_BACKEND {
+			v.fail(path, "snapshots are not supported by %s backend", adapter.KUBERNETES_BACKEND)
+		}
+	}
+
+	// map iteration order is random, errors should not be
+	var serviceNames []string
+	for name := range value.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	for _, name := range serviceNames {
+		path := "services." + name
+		if service := value.Services[name]; service != nil {
+			v.validateService(path, service)
+		}
+	}
+
+	addresses := make(map[string]string)
+	for i, node := range value.FederationNodes {
+		path := fmt.Sprintf("network[%d]", i)
+		if node == nil {
+			v.fail(path, "is empty")
+			continue
+		}
+
+		address := strings.ToLower(strings.TrimPrefix(node.Address, "0x"))
+		if address == "" {
+			v.fail(path+".address", "is empty")
+		} else if _, err := hex.DecodeString(address); err != nil {
+			v.fail(path+".address", "%s is not a valid hex address", node.Address)
+		} else if other, found := addresses[address]; found {
+			v.fail(path+".address", "address %s is already used by %s", node.Address, other)
+		} else {
+			addresses[address] = path
+		}
+
+		if node.IP == "" {
+			v.fail(path+".ip", "is empty")
+		} else if net.ParseIP(node.IP) == nil && !validHostname.MatchString(node.IP) {
+			v.fail(path+".ip", "%s is neither an ip nor a hostname", node.IP)
+		}
+
+		v.checkPort(path+".port", node.Port)
+	}
+
+	return v.errors
+}
+
+// Disabled services are about to be removed, so only their ports are allowed to collide
+func (v *validator) validateService(path string, service *Service) {
+	v.checkPort(path+".InternalPort", service.InternalPort)
+
+	if service.Disabled {
+		v.checkPort(path+".ExternalPort", service.ExternalPort)
+		return
+	}
+
+	v.checkExternalPort(path+".ExternalPort", service.ExternalPort)
+
+	dockerConfig := service.DockerConfig
+	if dockerConfig.Image == "" {
+		v.fail(path+".DockerConfig.Image", "is empty")
+	}
+
+	if dockerConfig.Tag == "" {
+		v.fail(path+".DockerConfig.Tag", "is empty")
+	}
+
+	if dockerConfig.Digest != "" && !validImageDigest.MatchString(dockerConfig.Digest) {
+		v.fail(path+".DockerConfig.Digest", "%s is not a valid sha256:<hex> digest", dockerConfig.Digest)
+	}
+
+	v.validateResource(path+".DockerConfig.Resources.Limits", dockerConfig.Resources.Limits)
+	v.validateResource(path+".DockerConfig.Resources.Reservations", dockerConfig.Resources.Reservations)
+
+	limits, reservations := dockerConfig.Resources.Limits, dockerConfig.Resources.Reservations
+	if limits.Memory > 0 && reservations.Memory > limits.Memory {
+		v.fail(path+".DockerConfig.Resources.Reservations.Memory", "%d is more than the limit of %d", reservations.Memory, limits.Memory)
+	}
+
+	if limits.CPUs > 0 && reservations.CPUs > limits.CPUs {
+		v.fail(path+".DockerConfig.Resources.Reservations.CPUs", "%v is more than the limit of %v", reservations.CPUs, limits.CPUs)
+	}
+
+	if dockerConfig.Volumes.Blocks < 0 {
+		v.fail(path+".DockerConfig.Volumes.Blocks", "%d is negative", dockerConfig.Volumes.Blocks)
+	}
+
+	if dockerConfig.Volumes.Logs < 0 {
+		v.fail(path+".DockerConfig.Volumes.Logs", "%d is negative", dockerConfig.Volumes.Logs)
+	}
+}
+
+func (v *validator) validateResource(path string, resource Resource) {
+	if resource.Memory < 0 {
+		v.fail(path+".Memory", "%d is negative", resource.Memory)
+	}
+
+	if resource.CPUs < 0 {
+		v.fail(path+".CPUs", "%v is negative", resource.CPUs)
+	}
+}
+
+func (v *validator) validateOrchestratorOptions(path string, options *adapter.OrchestratorOptions) {
+	if !contains(validBackends, options.Backend) {
+		v.fail(path+".backend", "unknown backend %s", options.Backend)
+	}
+
+	if !contains(validStorageMountTypes, options.StorageMountType) {
+		v.fail(path+".storage-mount-type", "unknown mount type %s", options.StorageMountType)
+	}
+
+	if options.MaxReloadTimedDelayStr != "" {
+		if _, err := time.ParseDuration(options.MaxReloadTimedDelayStr); err != nil {
+			v.fail(path+".max-reload-time-delay", "%s is not a valid duration", options.MaxReloadTimedDelayStr)
+		}
+	}
+
+	if options.UpdateMonitorWindowStr != "" {
+		if d, err := time.ParseDuration(options.UpdateMonitorWindowStr); err != nil || d <= 0 {
+			v.fail(path+".update-monitor-window", "%s is not a valid positive duration", options.UpdateMonitorWindowStr)
+		}
+	}
+
+	// the proxy listens on the default ports if they are not set
+	if options.HTTPPort > MAX_PORT {
+		v.fail(path+".http-port", "port %d is out of range", options.HTTPPort)
+	} else if options.HTTPPort == 0 {
+		v.checkExternalPort(path+".http-port (default)", int(adapter.DEFAULT_HTTP_PORT))
+	} else {
+		v.checkExternalPort(path+".http-port", int(options.HTTPPort))
+	}
+
+	if options.SSLPort > MAX_PORT {
+		v.fail(path+".ssl-port", "port %d is out of range", options.SSLPort)
+	} else if options.SSLPort == 0 {
+		v.checkExternalPort(path+".ssl-port (default)", int(adapter.DEFAULT_SSL_PORT))
+	} else {
+		v.checkExternalPort(path+".ssl-port", int(options.SSLPort))
+	}
+
+	v.checkSha256(path+".ExecutableImage", options.ExecutableImage.Url, options.ExecutableImage.Sha256)
+
+	var platforms []string
+	for platform := range options.ExecutableImage.Binaries {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	for _, platform := range platforms {
+		binaryPath := fmt.Sprintf("%s.ExecutableImage.Binaries[%s]", path, platform)
+		if parts := strings.Split(platform, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			v.fail(binaryPath, "platform should be in GOOS/GOARCH format, for example linux/amd64")
+		}
+
+		binary := options.ExecutableImage.Binaries[platform]
+		if binary.Url == "" {
+			v.fail(binaryPath+".Url", "is empty")
+		}
+		v.checkSha256(binaryPath, binary.Url, binary.Sha256)
+	}
+
+	if percentage := options.ExecutableImage.RolloutPercentage; percentage < 0 || percentage > 100 {
+		v.fail(path+".ExecutableImage.RolloutPercentage", "%d is out of range, should be between 0 and 100", percentage)
+	}
+
+	if _, err := options.ExecutableImage.MaintenanceHours(); err != nil {
+		v.fail(path+".ExecutableImage.MaintenanceWindow", "%s", err)
+	}
+
+	for i, key := range options.DynamicManagementConfig.TrustedKeys {
+		if _, err := crypto.ParsePublicKey(key); err != nil {
+			v.fail(fmt.Sprintf("%s.DynamicManagementConfig.TrustedKeys[%d]", path, i), "%s", err)
+		}
+	}
+
+	if options.Backend == adapter.KUBERNETES_BACKEND && options.Kubernetes.RenderOnly && options.Kubernetes.RenderPath == "" {
+		v.fail(path+".kubernetes.render-path", "is required to render Kubernetes manifests")
+	}
+}
+
+func (v *validator) checkSha256(path string, source string, sha256 string) {
+	if sha256 != "" {
+		if decoded, err := hex.DecodeString(sha256); err != nil || len(decoded) != 32 {
+			v.fail(path+".Sha256", "%s is not a valid sha256 checksum", sha256)
+		}
+	} else if source != "" {
+		v.fail(path+".Sha256", "is required to verify %s", source)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigString_ValidConfigs(t *testing.T) {
+	for _, file := range []string{"config.json", "configWithActiveVchains.json", "configWithSingleChain.json", "configWithSigner.json", "configWithOverrides.json"} {
+		input, err := ioutil.ReadFile("./test/" + file)
+		require.NoError(t, err)
+
+		require.NoError(t, ValidateConfigString(string(input)), file)
+	}
+}
+
+func TestValidateConfigString_ReportsEveryProblem(t *testing.T) {
+	err := ValidateConfigString(`{
+		"orchestrator": {
+			"storage-mount-type": "nfs",
+			"backend": "nomad",
+			"max-reload-time-delay": "soon",
+			"ExecutableImage": {"Url": "http://boyar.bin", "RolloutPercentage": 150, "MaintenanceWindow": "2-5,night",
+				"Binaries": {"linux/arm64": {"Url": "http://boyar-arm64.bin", "Sha256": "abcd"}, "darwin": {}}},
+			"DynamicManagementConfig": {"TrustedKeys": ["rsa:abcd"]}
+		},
+		"network": [
+			{"address": "a328846cd5b4979d68a8c58a9bdfeee657b34de7", "ip": "192.168.1.14"},
+			{"address": "0xA328846CD5B4979D68A8C58A9BDFEEE657B34DE7", "ip": "not an ip", "port": 70000}
+		],
+		"chains": [
+			{"Id": 42, "ExternalPort": 4400, "DockerConfig": {"Image": "orbsnetwork/node", "Tag": "v1"}, "Snapshot": {"Path": "backups/chain-42.tar.zst"},
+				"BootstrapFrom": {"Source": "peer/chain-42.tar.zst"}},
+			{"Id": 42, "ExternalPort": 4400, "DockerConfig": {"Image": "orbsnetwork/node", "Tag": "", "Digest": "sha256:abc"}},
+			{"Id": 1991, "ExternalPort": 4400, "Disabled": true, "DockerConfig": {}}
+		],
+		"services": {
+			"signer": {"InternalPort": 7777, "ExternalPort": 443, "DockerConfig": {"Image": "orbsnetwork/signer", "Tag": "v1", "Resources": {"Limits": {"Memory": -1}, "Reservations": {"CPUs": 2}}}},
+			"management-service": {"ExternalPort": 4400, "DockerConfig": {"Tag": "v1", "Resources": {"Limits": {"CPUs": 1}}}}
+		}
+	}`)
+
+	require.IsType(t, ValidationErrors{}, err)
+
+	var messages []string
+	for _, e := range err.(ValidationErrors) {
+		messages = append(messages, e.Error())
+	}
+
+	require.Equal(t, []string{
+		"orchestrator.backend: unknown backend nomad",
+		"orchestrator.storage-mount-type: unknown mount type nfs",
+		"orchestrator.max-reload-time-delay: soon is not a valid duration",
+		"orchestrator.ExecutableImage.Sha256: is required to verify http://boyar.bin",
+		"orchestrator.ExecutableImage.Binaries[darwin]: platform should be in GOOS/GOARCH format, for example linux/amd64",
+		"orchestrator.ExecutableImage.Binaries[darwin].Url: is empty",
+		"orchestrator.ExecutableImage.Binaries[linux/arm64].Sha256: abcd is not a valid sha256 checksum",
+		"orchestrator.ExecutableImage.RolloutPercentage: 150 is out of range, should be between 0 and 100",
+		`orchestrator.ExecutableImage.MaintenanceWindow: invalid hour "night", should be between 0 and 23`,
+		"orchestrator.DynamicManagementConfig.TrustedKeys[0]: unsupported signature scheme rsa",
+		`chains[0].Snapshot.Path: "backups/chain-42.tar.zst" is not an absolute path`,
+		`chains[0].BootstrapFrom.Source: "peer/chain-42.tar.zst" is neither an http(s) url nor an absolute path`,
+		"chains[0].BootstrapFrom.Sha256: is required to verify peer/chain-42.tar.zst",
+		"chains[1].Id: virtual chain id 42 is already used by chains[0]",
+		"chains[1].ExternalPort: port 4400 is already used by chains[0].ExternalPort",
+		"chains[1].DockerConfig.Tag: is empty",
+		"chains[1].DockerConfig.Digest: sha256:abc is not a valid sha256:<hex> digest",
+		"services.management-service.ExternalPort: port 4400 is already used by chains[0].ExternalPort",
+		"services.management-service.DockerConfig.Image: is empty",
+		"services.signer.ExternalPort: port 443 is already used by orchestrator.ssl-port (default)",
+		"services.signer.DockerConfig.Resources.Limits.Memory: -1 is negative",
+		"network[1].address: address 0xA328846CD5B4979D68A8C58A9BDFEEE657B34DE7 is already used by network[0]",
+		"network[1].ip: not an ip is neither an ip nor a hostname",
+		"network[1].port: port 70000 is out of range",
+	}, messages)
+}
+
+func TestValidateConfigString_MissingOrchestrator(t *testing.T) {
+	require.EqualError(t, ValidateConfigString(`{}`), "orchestrator: is missing")
+	require.Error(t, ValidateConfigString(`{`))
+}
+
+func TestVerifyConfig_ReturnsValidationErrors(t *testing.T) {
+	cfg, err := NewStringConfigurationSource(`{"orchestrator": {}, "chains": [{"Id": 42, "DockerConfig": {"Image": "orbsnetwork/node"}}]}`, "", fakeKeyPair, false)
+	require.NoError(t, err)
+
+	require.EqualError(t, cfg.VerifyConfig(), "chains[0].DockerConfig.Tag: is empty")
+}
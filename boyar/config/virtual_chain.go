@@ -3,8 +3,11 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/orbs-network/boyarin/snapshot"
 )
 
 type VirtualChainId uint32
@@ -13,6 +16,25 @@ type VirtualChain struct {
 	Service
 	Id               VirtualChainId
 	InternalHttpPort int // FIXME should be deprecated as vchain specific
+
+	Snapshot      *VirtualChainSnapshot  `json:",omitempty"`
+	BootstrapFrom *VirtualChainBootstrap `json:",omitempty"`
+}
+
+// Archives the blocks volume once per path, the chain is stopped while the archive is written
+type VirtualChainSnapshot struct {
+	Path string
+}
+
+// Archive made by a snapshot, unpacked before the chain is started for the first time if its blocks volume is empty
+type VirtualChainBootstrap struct {
+	Source            string // http(s) url or absolute path
+	Sha256            string // of the whole archive
+	FallbackToGenesis bool   `json:",omitempty"` // starts the chain anyway once the bootstrap failed
+}
+
+func (b *VirtualChainBootstrap) GetSource() *snapshot.Source {
+	return &snapshot.Source{Location: b.Source, Sha256: b.Sha256}
 }
 
 type VirtualChainConfig struct {
@@ -46,6 +68,17 @@ func (v *VirtualChain) GetContainerName() string {
 	return fmt.Sprintf("chain-%d", v.Id)
 }
 
+var virtualChainContainerName = regexp.MustCompile(`(^|-)chain-(\d+)$`)
+
+// Id of the virtual chain from a container name made by GetContainerName, namespaced or not; false for other containers
+func ParseVirtualChainContainerName(name string) (string, bool) {
+	if match := virtualChainContainerName.FindStringSubmatch(name); match != nil {
+		return match[2], true
+	}
+
+	return "", false
+}
+
 func (c *VirtualChain) GetSerializedConfig() []byte {
 	m := make(map[string]interface{})
 	for k, v := range c.Config {
@@ -3,6 +3,7 @@ package boyar
 import (
 	"fmt"
 	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
@@ -51,7 +52,7 @@ func assertAllChainedCached(t *testing.T, cfg config.MutableNodeConfiguration, c
 		if chain.Disabled {
 			assert.False(t, cache.vChains.CheckNewJsonValue(chainId, removed), "cache should remember chain was removed")
 		} else {
-			assert.False(t, cache.vChains.CheckNewJsonValue(chainId, getVirtualChainConfig(cfg, chain)), "cache should remember chain deployed with configuration")
+			assert.False(t, cache.vChains.CheckNewValue(chainId, &utils.HashedValue{Value: getVirtualChainConfigHash(cfg, chain)}), "cache should remember chain deployed with configuration")
 		}
 	}
 }
@@ -1,7 +1,9 @@
 package boyar
 
 import (
+	"encoding/json"
 	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/crypto"
 )
 
 func getKeyConfigJson(config config.NodeConfiguration, addressOnly bool) []byte {
@@ -11,3 +13,8 @@ func getKeyConfigJson(config config.NodeConfiguration, addressOnly bool) []byte
 	}
 	return keyConfig.JSON(addressOnly)
 }
+
+func getConfigHash(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return crypto.CalculateHash(data)
+}
@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/orbs-network/boyarin/audit"
 	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/boyarin/recovery"
 	"github.com/orbs-network/boyarin/services"
@@ -18,18 +22,39 @@ import (
 	"github.com/orbs-network/scribe/log"
 )
 
+const RECOVERY_NODE_ADDRESS_PLACEHOLDER = "{node-address}"
+const DEFAULT_AUDIT_LOG_PATH = "./boyar_audit/audit.log"
+const DEFAULT_RECOVERY_URL = "https://deployment.orbs.network/boyar_recovery/node/0x" + RECOVERY_NODE_ADDRESS_PLACEHOLDER + "/main.json"
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validate(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		os.Exit(history(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && (os.Args[1] == "snapshot" || os.Args[1] == "restore") {
+		os.Exit(chainData(os.Args[1], os.Args[2:]))
+	}
+
 	basicLogger := log.GetLogger()
 	basicLogger.Info("Boyar main version: " + version.GetVersion().Semantic)
 
 	configUrlPtr := flag.String("config-url", "", "http://my-config/config.json")
 	keyPairConfigPathPtr := flag.String("keys", "", "path to public/private key pair in json format")
+	configTrustedKeys := flag.String("config-trusted-keys", "", "comma separated list of public keys (ed25519:<hex> or secp256k1:<hex>, secp256k1 also accepts node address) trusted to sign the configuration; signature verification is disabled if empty")
+	configSources := flag.String("config-sources", "", "comma separated list of additional configuration sources (http url, json file or directory of json files) merged on top of --config-url in order, later sources take precedence")
+	configSignatureUrl := flag.String("config-signature-url", "", "url of the detached configuration signature (default is --config-url with .sig suffix)")
+	configCachePath := flag.String("config-cache", "", "path to last known good configuration, used on startup if configuration sources are unreachable; disabled if empty")
 
 	_ = flag.Bool("daemonize", true, "DEPRECATED (always true)")
 	pollingIntervalPtr := flag.Duration("polling-interval", 1*time.Minute, "how often to poll for configuration in daemon mode (duration: 1s, 1m, 1h, etc)")
 	maxReloadTimePtr := flag.Duration("max-reload-time-delay", 15*time.Minute, "introduces jitter to reloading configuration to make network more stable, only works in daemon mode (duration: 1s, 1m, 1h, etc)")
 
 	timeoutPtr := flag.Duration("timeout", 10*time.Minute, "timeout for provisioning all virtual chains (duration: 1s, 1m, 1h, etc)")
+	configSettlePeriod := flag.Duration("config-settle-period", 2*time.Minute, "how long to watch services touched by a configuration change; the change is reverted to the last good configuration and quarantined if they fail (duration: 1s, 1m, 1h, etc, 0 disables)")
 
 	ethereumEndpointPtr := flag.String("ethereum-endpoint", "", "Ethereum endpoint")
 	flag.String("topology-contract-address", "", "legacy parameter, will be removed in future versions")
@@ -39,6 +64,20 @@ func main() {
 
 	statusFilePath := flag.String("status", "", "path to status file")
 	metricsFilePath := flag.String("metrics", "", "path to metrics file")
+	auditLogPath := flag.String("audit-log", DEFAULT_AUDIT_LOG_PATH, "path to the audit log of every action that changes running services (json lines), see `boyar history`; disabled if empty")
+	imageTrustedKeys := flag.String("image-trusted-keys", "", "comma separated list of paths to public keys (PEM, ECDSA) that virtual chain and service images must be signed with by cosign; images are not verified if empty")
+	adminListen := flag.String("admin-listen", "", "address for the admin http api serving status, metrics and config (for example, 127.0.0.1:8090), disabled if empty")
+
+	healthMaxCPULoad := flag.Float64("health-max-cpu", services.DEFAULT_MAX_CPU_LOAD, "CPU load (percent) above which health is reported as a warning")
+	healthMaxMemoryUsed := flag.Float64("health-max-memory", services.DEFAULT_MAX_MEMORY_USED, "memory usage (percent) above which health is reported as a warning")
+	healthMaxDiskUsed := flag.Float64("health-max-disk", services.DEFAULT_MAX_DISK_USED, "disk usage (percent) above which health is reported as a warning")
+	healthMaxConfigAge := flag.Duration("health-max-config-age", 30*time.Minute, "how long since the configuration was last received before health is reported as a warning (duration: 1s, 1m, 1h, etc, 0 disables)")
+
+	notifyWebhooks := flag.String("notify-webhook", "", "comma separated list of urls to post notifications to in json format")
+	notifySlackWebhooks := flag.String("notify-slack", "", "comma separated list of Slack-compatible incoming webhook urls to post notifications to")
+	notifyScript := flag.String("notify-script", "", "path to an executable that receives every notification in json format on stdin")
+	notifyDedupPeriod := flag.Duration("notify-dedup-period", time.Hour, "how long to suppress repeated notifications about the same problem (duration: 1s, 1m, 1h, etc, 0 disables)")
+	notifyRateLimit := flag.Int("notify-rate-limit", 30, "maximum number of notifications per hour, the rest are dropped (0 disables)")
 
 	orchestratorOptionsPtr := flag.String("orchestrator-options", "", "allows to override `orchestrator` section of boyar config, takes JSON object as a parameter")
 
@@ -51,11 +90,23 @@ func main() {
 	help := flag.Bool("help", false, "show usage")
 	showVersion := flag.Bool("version", false, "show version")
 
+	plan := flag.Bool("plan", false, "print the changes the configuration would make to running services and exit")
+	planFormat := flag.String("plan-format", "text", "plan output format (text or json)")
+	planBaseConfigUrl := flag.String("plan-base-config-url", "", "compare with another configuration instead of running services")
+
 	showStatus := flag.Bool("show-status", false, "print status in json format and exit")
 
 	autoUpdate := flag.Bool("auto-update", false, "enables boyar binary auto update")
+	updateTrustedKeys := flag.String("update-trusted-keys", "", "comma separated list of public keys (ed25519:<hex> or secp256k1:<hex>) trusted to sign update manifests; updates without a signed manifest are refused if not empty")
+	updateRollbackTimeout := flag.Duration("update-rollback-timeout", 10*time.Minute, "how long the updated binary has to apply the configuration after restart before it is replaced with the previous one (duration: 1s, 1m, 1h, etc, 0 disables)")
 	shutdownAfterUpdate := flag.Bool("shutdown-after-update", false, "the process shuts down after automatic update is performed and **DOES NOT** restart; recommended to be used with an external process manager")
 
+	disableRecovery := flag.Bool("disable-recovery", false, "disables periodical execution of recovery instructions")
+	recoveryUrl := flag.String("recovery-url", DEFAULT_RECOVERY_URL, "url of recovery instructions, "+RECOVERY_NODE_ADDRESS_PLACEHOLDER+" is replaced with the node address")
+	recoveryTrustedKeys := flag.String("recovery-trusted-keys", "", "comma separated list of public keys (ed25519:<hex> or secp256k1:<hex>) trusted to sign recovery instructions and scripts; nothing is executed if empty")
+	recoveryJournal := flag.String("recovery-journal", "", "path to the journal of recovery runs (default ./boyar_recovery/journal.log)")
+	recoveryAllowedBins := flag.String("recovery-allowed-bins", strings.Join(recovery.DEFAULT_ALLOWED_BINS, ","), "comma separated list of executables recovery instructions are allowed to run")
+
 	bootstrapResetTimeout := flag.Duration("bootstrap-reset-timeout", 0, "if the process is unable to receive valid configuration within a limited timeframe (duration: 1s, 1m, 1h, etc), it will exit with an error; recommended to be used with an external process manager, (default 0s, off)")
 
 	flag.Parse()
@@ -72,9 +123,20 @@ func main() {
 	flags := &config.Flags{
 		ConfigUrl:             *configUrlPtr,
 		KeyPairConfigPath:     *keyPairConfigPathPtr,
+		ConfigTrustedKeys:     splitList(*configTrustedKeys),
+		ConfigSignatureUrl:    *configSignatureUrl,
+		ConfigSources:         splitList(*configSources),
+		ConfigCachePath:       *configCachePath,
 		LogFilePath:           *logFilePath,
 		StatusFilePath:        *statusFilePath,
 		MetricsFilePath:       *metricsFilePath,
+		AdminListen:           *adminListen,
+		AuditLogPath:          *auditLogPath,
+		ImageTrustedKeys:      splitList(*imageTrustedKeys),
+		HealthMaxCPULoad:      *healthMaxCPULoad,
+		HealthMaxMemoryUsed:   *healthMaxMemoryUsed,
+		HealthMaxDiskUsed:     *healthMaxDiskUsed,
+		HealthMaxConfigAge:    *healthMaxConfigAge,
 		PollingInterval:       *pollingIntervalPtr,
 		Timeout:               *timeoutPtr,
 		MaxReloadTimeDelay:    *maxReloadTimePtr,
@@ -86,15 +148,24 @@ func main() {
 		ManagementConfig:      *managementConfig,
 		AutoUpdate:            *autoUpdate,
 		ShutdownAfterUpdate:   *shutdownAfterUpdate,
+		UpdateTrustedKeys:     splitList(*updateTrustedKeys),
+		UpdateRollbackTimeout: *updateRollbackTimeout,
 		BoyarBinaryPath:       executableWithoutSymlink,
 		BootstrapResetTimeout: *bootstrapResetTimeout,
+		ConfigSettlePeriod:    *configSettlePeriod,
+
+		NotificationWebhooks:      splitList(*notifyWebhooks),
+		NotificationSlackWebhooks: splitList(*notifySlackWebhooks),
+		NotificationScript:        *notifyScript,
+		NotificationDedupPeriod:   *notifyDedupPeriod,
+		NotificationRateLimit:     *notifyRateLimit,
 	}
 
 	if *showStatus {
 		ctx, cancel := context.WithTimeout(context.Background(), services.SERVICE_STATUS_REPORT_TIMEOUT)
 		defer cancel()
 
-		status, _ := services.GetStatusAndMetrics(ctx, basicLogger, flags, time.Now(), services.SERVICE_STATUS_REPORT_TIMEOUT)
+		status, _ := services.GetStatusAndMetrics(ctx, basicLogger, flags, nil, time.Now(), services.SERVICE_STATUS_REPORT_TIMEOUT)
 		rawJSON, _ := json.MarshalIndent(status, "  ", "  ")
 		fmt.Println(string(rawJSON))
 
@@ -116,6 +187,14 @@ func main() {
 		return
 	}
 
+	if *plan {
+		if err := printPlan(flags, *planBaseConfigUrl, *planFormat); err != nil {
+			logger.Error("could not plan configuration changes", log.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	if flags.ManagementConfig != "" {
 		flags, err = services.Bootstrap(context.Background(), flags, logger)
 		if err != nil {
@@ -125,6 +204,31 @@ func main() {
 	}
 
 	// start recovery //////////////////////////////
+	if *disableRecovery {
+		logger.Info("recovery is disabled")
+	} else {
+		startRecovery(flags, logger, recovery.Config{
+			IntervalMinute: 60 * 6,
+			TimeoutMinute:  30,
+			Url:            *recoveryUrl,
+			TrustedKeys:    splitList(*recoveryTrustedKeys),
+			AllowedBins:    splitList(*recoveryAllowedBins),
+			JournalPath:    *recoveryJournal,
+		})
+	}
+
+	// start services
+	waiter, err := services.Execute(context.Background(), flags, logger)
+	if err != nil {
+		logger.Error("Startup failure", log.Error(err))
+		os.Exit(1)
+	}
+
+	// should block forever
+	waiter.WaitUntilShutdown(context.Background())
+}
+
+func startRecovery(flags *config.Flags, logger log.Logger, recovConfig recovery.Config) {
 	logger.Info("============================================")
 	keys, err := config.NewKeysConfig(flags.KeyPairConfigPath)
 	nodeAddress := ""
@@ -139,29 +243,187 @@ func main() {
 	}
 	// go on to init recovery anyways
 	logger.Info("recovery node address is: " + nodeAddress)
-	url := fmt.Sprintf("https://deployment.orbs.network/boyar_recovery/node/0x%s/main.json", nodeAddress)
-	// for testing
-	//url := fmt.Sprintf("https://raw.githubusercontent.com/amihaz/staging-deployment/main/boyar_recovery/node/0x%s/main.json", keys.Address())
-	recovConfig := recovery.Config{
-		IntervalMinute: 60 * 6,
-		TimeoutMinute:  30,
-		Url:            url,
+	recovConfig.Url = strings.Replace(recovConfig.Url, RECOVERY_NODE_ADDRESS_PLACEHOLDER, nodeAddress, -1)
+	recovConfig.NodeAddress = nodeAddress
+	if len(recovConfig.TrustedKeys) == 0 {
+		logger.Error("no recovery trusted keys provided, recovery instructions will not be executed")
 	}
 	recovery.Init(recovConfig, logger)
 
 	// start
 	recovery.GetInstance().Start(true)
 	logger.Info("============================================")
+}
 
-	// start services
-	waiter, err := services.Execute(context.Background(), flags, logger)
+// Usage: boyar validate <file>, exits with 1 if the configuration has problems
+func validate(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: boyar validate <file>")
+		return 2
+	}
+
+	data, err := ioutil.ReadFile(args[0])
 	if err != nil {
-		logger.Error("Startup failure", log.Error(err))
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
-	// should block forever
-	waiter.WaitUntilShutdown(context.Background())
+	if err := config.ValidateConfigString(string(data)); err != nil {
+		if errors, ok := err.(config.ValidationErrors); ok {
+			for _, e := range errors {
+				fmt.Println(e.Error())
+			}
+		} else {
+			fmt.Println(err)
+		}
+		return 1
+	}
+
+	fmt.Println("configuration is valid")
+	return 0
+}
+
+// Usage: boyar history [--target chain-42] [--action create] [--since 24h] [--limit 50] [--format text|json]
+func history(args []string) int {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	path := flags.String("audit-log", DEFAULT_AUDIT_LOG_PATH, "path to the audit log")
+	target := flags.String("target", "", "only show actions on this container (for example, chain-42 or signer)")
+	action := flags.String("action", "", "only show actions of this type ("+strings.Join([]string{audit.ACTION_PULL, audit.ACTION_CREATE, audit.ACTION_REMOVE, audit.ACTION_PURGE, audit.ACTION_ROTATE_SECRETS, audit.ACTION_SELF_UPDATE, audit.ACTION_SNAPSHOT, audit.ACTION_RESTORE, audit.ACTION_BOOTSTRAP}, ", ")+")")
+	since := flags.Duration("since", 0, "only show actions within this period (duration: 1s, 1m, 1h, etc, 0 shows everything)")
+	limit := flags.Int("limit", 50, "show at most this number of the latest actions (0 shows everything)")
+	format := flags.String("format", "text", "output format (text or json)")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown history format %s\n", *format)
+		return 2
+	}
+
+	filter := audit.Filter{
+		Target: *target,
+		Action: *action,
+		Limit:  *limit,
+	}
+
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	entries, err := audit.Query(*path, filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		rawJSON, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(rawJSON))
+		return 0
+	}
+
+	for _, entry := range entries {
+		fmt.Println(audit.FormatEntry(entry))
+	}
+
+	return 0
+}
+
+// Usage: boyar snapshot --vchain 42 --output chain-42.tar.zst, boyar restore --vchain 42 --input chain-42.tar.zst
+// Both take the configuration flags to find the chain volume and stop the chain
+func chainData(command string, args []string) int {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	configUrl := flags.String("config-url", "", "http://my-config/config.json")
+	keyPairConfigPath := flags.String("keys", "", "path to public/private key pair in json format")
+	configTrustedKeys := flags.String("config-trusted-keys", "", "comma separated list of public keys trusted to sign the configuration")
+	configSignatureUrl := flags.String("config-signature-url", "", "url of the detached configuration signature")
+	configSources := flags.String("config-sources", "", "comma separated list of additional configuration sources")
+	orchestratorOptions := flags.String("orchestrator-options", "", "allows to override `orchestrator` section of boyar config")
+	auditLogPath := flags.String("audit-log", DEFAULT_AUDIT_LOG_PATH, "path to the audit log, disabled if empty")
+	timeout := flags.Duration("timeout", time.Hour, "timeout for stopping the chain and writing the archive (duration: 1s, 1m, 1h, etc)")
+	vcId := flags.Uint("vchain", 0, "virtual chain id")
+
+	archiveFlag, archiveUsage := "output", "path to write the archive (tar.zst) to, the checksum is written next to it with .sha256 suffix"
+	if command == "restore" {
+		archiveFlag, archiveUsage = "input", "path of the archive to restore, verified against the checksum next to it if there is one"
+	}
+	archivePath := flags.String(archiveFlag, "", archiveUsage)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *vcId == 0 || *archivePath == "" {
+		fmt.Fprintf(os.Stderr, "usage: boyar %s --vchain <id> --%s <path> --config-url <url> --keys <path>\n", command, archiveFlag)
+		return 2
+	}
+
+	path, err := filepath.Abs(*archivePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	logger := log.GetLogger().WithOutput(log.NewFormattingOutput(os.Stderr, log.NewHumanReadableFormatter()))
+	configFlags := &config.Flags{
+		ConfigUrl:           *configUrl,
+		KeyPairConfigPath:   *keyPairConfigPath,
+		ConfigTrustedKeys:   splitList(*configTrustedKeys),
+		ConfigSignatureUrl:  *configSignatureUrl,
+		ConfigSources:       splitList(*configSources),
+		OrchestratorOptions: *orchestratorOptions,
+		AuditLogPath:        *auditLogPath,
+	}
+
+	if command == "restore" {
+		err = services.RestoreVirtualChain(ctx, configFlags, config.VirtualChainId(*vcId), path, logger)
+	} else {
+		err = services.SnapshotVirtualChain(ctx, configFlags, config.VirtualChainId(*vcId), path, logger)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if command == "restore" {
+		fmt.Printf("restored virtual chain %d from %s\n", *vcId, path)
+	} else {
+		fmt.Printf("saved virtual chain %d to %s\n", *vcId, path)
+	}
+	return 0
+}
+
+func printPlan(flags *config.Flags, baseConfigUrl string, format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown plan format %s", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flags.Timeout)
+	defer cancel()
+
+	// keeps stdout clean for the plan itself
+	logger := log.GetLogger().WithOutput(log.NewFormattingOutput(os.Stderr, log.NewHumanReadableFormatter()))
+
+	plan, err := services.GetPlan(ctx, flags, baseConfigUrl, logger)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		rawJSON, _ := json.MarshalIndent(plan, "", "  ")
+		fmt.Println(string(rawJSON))
+	default:
+		fmt.Print(plan.String())
+	}
+
+	return nil
 }
 
 func printConfiguration(flags *config.Flags, logger log.Logger) {
@@ -182,4 +444,36 @@ func printConfiguration(flags *config.Flags, logger log.Logger) {
 	fmt.Println("# Chains:\n# ============================")
 	chains, _ := json.MarshalIndent(cfg.Chains(), "", "  ")
 	fmt.Println(string(chains))
+
+	fmt.Println("# Services:\n# ============================")
+	services, _ := json.MarshalIndent(cfg.Services(), "", "  ")
+	fmt.Println(string(services))
+
+	if provenance := cfg.Provenance(); provenance != nil {
+		fmt.Println("# Sources (later take precedence):\n# ============================")
+		for _, source := range provenance.Sources {
+			fmt.Println(source)
+		}
+
+		fmt.Println("# Overrides:\n# ============================")
+		var paths []string
+		for path := range provenance.Overrides {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			fmt.Printf("%s <- %s\n", path, provenance.Overrides[path])
+		}
+	}
+}
+
+func splitList(value string) (list []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return
 }
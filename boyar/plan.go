@@ -0,0 +1,114 @@
+package boyar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/orbs-network/scribe/log"
+)
+
+type Plan struct {
+	Changes         []*adapter.PlannedChange `json:"changes"`
+	NginxConfigDiff string                   `json:"nginxConfigDiff,omitempty"`
+}
+
+var planActionSymbols = map[string]string{
+	adapter.PLAN_PULL:   ">",
+	adapter.PLAN_CREATE: "+",
+	adapter.PLAN_UPDATE: "~",
+	adapter.PLAN_REMOVE: "-",
+	adapter.PLAN_PURGE:  "!",
+}
+
+// Runs the same provisioning flow as the daemon against a recording orchestrator, running services define the starting point.
+// Nginx config is not compared, the deployed one is kept in secrets that could not be read back.
+func GetPlan(ctx context.Context, cfg config.NodeConfiguration, running []*adapter.RunningService, logger log.Logger) (*Plan, error) {
+	plan, _, err := getPlan(ctx, cfg, running, logger)
+	return plan, err
+}
+
+func getPlan(ctx context.Context, cfg config.NodeConfiguration, running []*adapter.RunningService, logger log.Logger) (*Plan, *adapter.ReverseProxyConfig, error) {
+	orchestrator := adapter.NewRecordingOrchestrator(running)
+	b := NewBoyar(orchestrator, cfg, NewCache(), logger)
+
+	var errors []error
+	for _, step := range []func(ctx context.Context) error{
+		b.Reconcile,
+		b.ProvisionVirtualChains,
+		b.ProvisionServices,
+		b.ProvisionHttpAPIEndpoint,
+	} {
+		if err := step(ctx); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if err := utils.AggregateErrors(errors); err != nil {
+		return nil, nil, fmt.Errorf("could not plan configuration changes: %s", err)
+	}
+
+	return &Plan{Changes: orchestrator.Changes()}, orchestrator.ReverseProxyConfig(), nil
+}
+
+// Compares two configurations without looking at what is actually running, nginx config diff included
+func GetPlanBetween(ctx context.Context, previous config.NodeConfiguration, cfg config.NodeConfiguration, logger log.Logger) (*Plan, error) {
+	desired, err := GetPlan(ctx, previous, nil, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var running []*adapter.RunningService
+	for _, change := range desired.Changes {
+		if change.Action == adapter.PLAN_CREATE {
+			running = append(running, &adapter.RunningService{
+				Name:       change.Name,
+				ConfigHash: change.ConfigHash,
+			})
+		}
+	}
+
+	plan, proxyConfig, err := getPlan(ctx, cfg, running, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if proxyConfig != nil {
+		plan.NginxConfigDiff = utils.DiffLines(getNginxConfig(previous), proxyConfig.NginxConfig)
+	}
+
+	return plan, nil
+}
+
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) > 0 || p.NginxConfigDiff != ""
+}
+
+func (p *Plan) String() string {
+	if !p.HasChanges() {
+		return "No changes.\n"
+	}
+
+	var lines []string
+	for _, change := range p.Changes {
+		line := fmt.Sprintf("%s %s", planActionSymbols[change.Action], change.Action)
+		if change.Kind != "" {
+			line += " " + change.Kind
+		}
+		line += " " + change.Name
+		if change.Image != "" {
+			line += " (" + change.Image + ")"
+		}
+
+		lines = append(lines, line)
+	}
+
+	if p.NginxConfigDiff != "" {
+		lines = append(lines, "", "nginx config:", strings.TrimSuffix(p.NginxConfigDiff, "\n"))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,59 @@
+package boyar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPlan_FromScratch(t *testing.T) {
+	cfg := getJSONConfig(t, ConfigWithActiveVchains)
+
+	plan, err := GetPlan(context.Background(), cfg, nil, helpers.DefaultTestLogger())
+	require.NoError(t, err)
+
+	require.Equal(t, []*adapter.PlannedChange{
+		{Action: adapter.PLAN_CREATE, Kind: adapter.PLAN_KIND_VIRTUAL_CHAIN, Name: "chain-42", Image: "orbsnetwork/node:experimental", ConfigHash: getVirtualChainConfigHash(cfg, cfg.Chains()[0])},
+		{Action: adapter.PLAN_CREATE, Kind: adapter.PLAN_KIND_VIRTUAL_CHAIN, Name: "chain-1991", Image: "orbsnetwork/node:experimental", ConfigHash: getVirtualChainConfigHash(cfg, cfg.Chains()[1])},
+		{Action: adapter.PLAN_CREATE, Kind: adapter.PLAN_KIND_PROXY, Name: adapter.PROXY_CONTAINER_NAME, ConfigHash: getNginxConfigHash(cfg)},
+	}, plan.Changes)
+
+	require.Empty(t, plan.NginxConfigDiff, "deployed nginx config is not known")
+	require.Contains(t, plan.String(), "+ create vchain chain-42 (orbsnetwork/node:experimental)")
+}
+
+func TestGetPlan_WithoutChanges(t *testing.T) {
+	cfg := getJSONConfig(t, ConfigWithActiveVchains)
+	b := NewBoyar(nil, cfg, NewCache(), helpers.DefaultTestLogger()).(*boyar)
+
+	plan, err := GetPlan(context.Background(), cfg, getRunningServices(b), helpers.DefaultTestLogger())
+	require.NoError(t, err)
+
+	require.False(t, plan.HasChanges())
+	require.Equal(t, "No changes.\n", plan.String())
+}
+
+func TestGetPlanBetween(t *testing.T) {
+	previous := getJSONConfig(t, Config)
+	cfg := getJSONConfig(t, ConfigWithSingleChain)
+
+	plan, err := GetPlanBetween(context.Background(), previous, cfg, helpers.DefaultTestLogger())
+	require.NoError(t, err)
+
+	actions := make(map[string]string)
+	for _, change := range plan.Changes {
+		actions[change.Name] = change.Action
+	}
+
+	require.Equal(t, adapter.PLAN_REMOVE, actions["chain-1991"])
+	require.Equal(t, adapter.PLAN_REMOVE, actions["service-name"])
+	require.Equal(t, adapter.PLAN_CREATE, actions["management-service"])
+	require.Equal(t, adapter.PLAN_UPDATE, actions[adapter.PROXY_CONTAINER_NAME])
+	require.NotContains(t, actions, "chain-1976", "disabled chain was not running in the first place")
+
+	require.Contains(t, plan.NginxConfigDiff, "- ")
+	require.Contains(t, plan.NginxConfigDiff, "+ ")
+}
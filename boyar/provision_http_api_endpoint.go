@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/utils"
 	"github.com/orbs-network/scribe/log"
 	"io/ioutil"
 )
@@ -13,7 +14,8 @@ func (b *boyar) ProvisionHttpAPIEndpoint(ctx context.Context) error {
 	b.nginxLock.Lock()
 	defer b.nginxLock.Unlock()
 
-	if b.cache.nginx.CheckNewJsonValue(getNginxConfig(b.config)) {
+	configHash := getNginxConfigHash(b.config)
+	if b.cache.nginx.CheckNewValue(&utils.HashedValue{Value: configHash}) {
 		sslOptions := b.config.SSLOptions()
 		sslEnabled := sslOptions.SSLCertificatePath != "" && sslOptions.SSLPrivateKeyPath != ""
 
@@ -24,6 +26,7 @@ func (b *boyar) ProvisionHttpAPIEndpoint(ctx context.Context) error {
 			HTTPPort:      b.config.OrchestratorOptions().HTTPPort,
 			SSLPort:       b.config.OrchestratorOptions().SSLPort,
 			Services:      getReverseProxyServices(b.config),
+			ConfigHash:    configHash,
 		}
 
 		if sslEnabled {
@@ -51,6 +54,10 @@ func (b *boyar) ProvisionHttpAPIEndpoint(ctx context.Context) error {
 	return nil
 }
 
+func getNginxConfigHash(cfg config.NodeConfiguration) string {
+	return getConfigHash(getNginxConfig(cfg))
+}
+
 func getReverseProxyServices(cfg config.NodeConfiguration) (services []adapter.ReverseProxyConfigService) {
 	for serviceName, _ := range cfg.Services() {
 		services = append(services, adapter.ReverseProxyConfigService{
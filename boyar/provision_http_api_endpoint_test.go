@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/orbs-network/boyarin/utils"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -22,7 +23,7 @@ func TestBoyar_ProvisionHttpAPIEndpointWithNoConfigChanges(t *testing.T) {
 	require.NoError(t, err)
 	orchestrator.AssertExpectations(t)
 
-	require.False(t, cache.nginx.CheckNewJsonValue(getNginxConfig(cfg)))
+	require.False(t, cache.nginx.CheckNewValue(&utils.HashedValue{Value: getNginxConfigHash(cfg)}))
 
 	err = b.ProvisionHttpAPIEndpoint(context.Background())
 	require.NoError(t, err)
@@ -42,7 +43,7 @@ func TestBoyar_ProvisionHttpAPIEndpointReprovisionsIfConfigChanges(t *testing.T)
 	require.NoError(t, err)
 	orchestrator.AssertExpectations(t)
 
-	require.False(t, cache.nginx.CheckNewJsonValue(getNginxConfig(cfg)))
+	require.False(t, cache.nginx.CheckNewValue(&utils.HashedValue{Value: getNginxConfigHash(cfg)}))
 
 	orchestrator.On("RunReverseProxy", mock.Anything, mock.Anything).Return(nil).Once()
 	cfg.Chains()[0].Id = 9125
@@ -38,7 +38,7 @@ func (b *boyar) provisionService(ctx context.Context, serviceName string, servic
 
 	if service.Disabled {
 		if key := serviceName; b.cache.services.CheckNewJsonValue(key, removed) {
-			if err := b.orchestrator.RemoveService(ctx, serviceName); err != nil {
+			if err := b.orchestrator.RemoveService(ctx, fullServiceName); err != nil {
 				b.cache.services.Clear(key)
 				logger.Error("failed to remove service", log.Error(err))
 				return err
@@ -49,7 +49,7 @@ func (b *boyar) provisionService(ctx context.Context, serviceName string, servic
 
 		if key := serviceName + "-data"; service.PurgeData && b.cache.services.CheckNewJsonValue(key, removed) {
 			if err := b.orchestrator.PurgeServiceData(ctx, fullServiceName); err != nil {
-				b.cache.vChains.Clear(key)
+				b.cache.services.Clear(key)
 				logger.Error("failed to purge service data", log.Error(err))
 				return err
 			} else {
@@ -60,17 +60,41 @@ func (b *boyar) provisionService(ctx context.Context, serviceName string, servic
 		return nil
 	}
 
+	serviceConfig, appConfig := getServiceConfig(b.config, serviceName, service)
+
+	if key := serviceName; b.cache.services.CheckNewValue(key, &utils.HashedValue{Value: serviceConfig.ConfigHash}) {
+		if service.DockerConfig.Pull {
+			if err := b.orchestrator.PullImage(ctx, imageName); err != nil {
+				return fmt.Errorf("could not pull docker image: %s", err)
+			}
+		}
+
+		if err := b.orchestrator.RunService(ctx, serviceConfig, appConfig); err == nil {
+			data, _ := json.Marshal(serviceConfig)
+			logger.Info("updated service configuration", log.String("configuration", string(data)))
+		} else {
+			logger.Error("failed to update service configuration", log.Error(err))
+			b.cache.services.Clear(key)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Config hash covers both the container and the application config, so that changing either one restarts the service
+func getServiceConfig(cfg config.NodeConfiguration, serviceName string, service *config.Service) (*adapter.ServiceConfig, *adapter.AppConfig) {
 	var logsMountPointNames map[string]string
 	if service.MountNodeLogs {
-		logsMountPointNames = getLogsMountPointNames(b.config)
+		logsMountPointNames = getLogsMountPointNames(cfg)
 	}
 
 	serviceConfig := &adapter.ServiceConfig{
-		NodeAddress: string(b.config.NodeAddress()),
+		NodeAddress: string(cfg.NodeAddress()),
 
-		ImageName:      imageName,
+		ImageName:      service.DockerConfig.FullImageName(),
 		Name:           serviceName,
-		ContainerName:  fullServiceName,
+		ContainerName:  cfg.NamespacedContainerName(serviceName),
 		ExecutablePath: service.ExecutablePath,
 		InternalPort:   service.InternalPort,
 		ExternalPort:   service.ExternalPort,
@@ -88,30 +112,15 @@ func (b *boyar) provisionService(ctx context.Context, serviceName string, servic
 
 	jsonConfig, _ := json.Marshal(service.Config)
 
-	var keyPairConfigJSON = getKeyConfigJson(b.config, !service.InjectNodePrivateKey)
+	var keyPairConfigJSON = getKeyConfigJson(cfg, !service.InjectNodePrivateKey)
 	appConfig := &adapter.AppConfig{
 		KeyPair: keyPairConfigJSON,
 		Config:  jsonConfig,
 	}
 
-	if key := serviceName; b.cache.services.CheckNewJsonValue(key, serviceConfig) {
-		if service.DockerConfig.Pull {
-			if err := b.orchestrator.PullImage(ctx, imageName); err != nil {
-				return fmt.Errorf("could not pull docker image: %s", err)
-			}
-		}
-
-		if err := b.orchestrator.RunService(ctx, serviceConfig, appConfig); err == nil {
-			data, _ := json.Marshal(serviceConfig)
-			logger.Info("updated service configuration", log.String("configuration", string(data)))
-		} else {
-			logger.Error("failed to update service configuration", log.Error(err))
-			b.cache.services.Clear(key)
-			return err
-		}
-	}
+	serviceConfig.ConfigHash = getConfigHash([]interface{}{serviceConfig, appConfig})
 
-	return nil
+	return serviceConfig, appConfig
 }
 
 func getLogsMountPointNames(cfg config.NodeConfiguration) map[string]string {
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/boyarin/log_types"
+	"github.com/orbs-network/boyarin/snapshot"
 	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/utils"
 	"github.com/orbs-network/scribe/log"
+	"os"
 	"sort"
 )
 
@@ -22,6 +24,13 @@ func (b *boyar) ProvisionVirtualChains(ctx context.Context) error {
 		containerName := b.config.NamespacedContainerName(chain.GetContainerName())
 		logger := b.logger.WithTags(log_types.VirtualChainId(int64(chain.Id)))
 
+		// comes first so that the data could be saved before it is purged
+		if chain.Snapshot != nil {
+			if err := b.snapshotVirtualChain(ctx, chain, containerName, logger); err != nil {
+				errors = append(errors, err)
+			}
+		}
+
 		if chain.Disabled {
 			if key := containerName; b.cache.vChains.CheckNewJsonValue(key, removed) {
 				if err := b.orchestrator.RemoveService(ctx, containerName); err != nil {
@@ -48,7 +57,17 @@ func (b *boyar) ProvisionVirtualChains(ctx context.Context) error {
 		}
 
 		input := getVirtualChainConfig(b.config, chain)
-		if key := containerName; b.cache.vChains.CheckNewJsonValue(key, input) {
+		configHash := getConfigHash(input)
+		if key := containerName; b.cache.vChains.CheckNewValue(key, &utils.HashedValue{Value: configHash}) {
+			// otherwise the chain would start syncing from genesis and the volume would no longer be empty
+			if ready, err := b.bootstrapVirtualChain(ctx, chain, containerName, logger); !ready {
+				b.cache.vChains.Clear(key)
+				if err != nil {
+					errors = append(errors, err)
+				}
+				continue
+			}
+
 			imageName := chain.DockerConfig.FullImageName()
 
 			if chain.DockerConfig.Pull {
@@ -73,6 +92,8 @@ func (b *boyar) ProvisionVirtualChains(ctx context.Context) error {
 				LimitedCPU:     chain.DockerConfig.Resources.Limits.CPUs,
 				ReservedMemory: chain.DockerConfig.Resources.Reservations.Memory,
 				ReservedCPU:    chain.DockerConfig.Resources.Reservations.CPUs,
+
+				ConfigHash: configHash,
 			}
 
 			appConfig := &adapter.AppConfig{
@@ -95,6 +116,70 @@ func (b *boyar) ProvisionVirtualChains(ctx context.Context) error {
 	return utils.AggregateErrors(errors)
 }
 
+// Taken once per path, an existing archive is never overwritten
+func (b *boyar) snapshotVirtualChain(ctx context.Context, chain *config.VirtualChain, containerName string, logger log.Logger) error {
+	key := containerName + "-snapshot"
+	if !b.cache.vChains.CheckNewJsonValue(key, chain.Snapshot) {
+		return nil
+	}
+
+	if _, err := os.Stat(chain.Snapshot.Path); err == nil {
+		logger.Info("vchain snapshot already exists", log.String("path", chain.Snapshot.Path))
+		return nil
+	}
+
+	// the chain is stopped by the snapshot and has to be started again
+	defer b.cache.vChains.Clear(containerName)
+
+	if err := b.orchestrator.SnapshotVirtualChainData(ctx, string(b.config.NodeAddress()), uint32(chain.Id), containerName, chain.Snapshot.Path); err != nil {
+		b.cache.vChains.Clear(key)
+		logger.Error("failed to snapshot vchain data", log.Error(err))
+		return err
+	}
+
+	logger.Info("successfully saved vchain snapshot", log.String("path", chain.Snapshot.Path))
+	return nil
+}
+
+// The chain is ready to start once the bootstrap is done or skipped; failed bootstraps are retried
+// on every poll and resume their download, unless the chain is allowed to sync from genesis instead
+func (b *boyar) bootstrapVirtualChain(ctx context.Context, chain *config.VirtualChain, containerName string, logger log.Logger) (bool, error) {
+	if chain.BootstrapFrom == nil {
+		return true, nil
+	}
+
+	progress := b.cache.bootstraps.Get(containerName, chain.BootstrapFrom.Source)
+	switch status := progress.Status(); status.State {
+	case snapshot.PROGRESS_DONE, snapshot.PROGRESS_SKIPPED:
+		return true, nil
+	case snapshot.PROGRESS_FAILED:
+		if chain.BootstrapFrom.FallbackToGenesis {
+			logger.Info("failed to bootstrap vchain data, syncing from genesis", log.String("source", status.Source), log.String("error", status.Error))
+			return true, nil
+		}
+
+		logger.Error("failed to bootstrap vchain data, retrying", log.String("source", status.Source), log.String("error", status.Error))
+	case snapshot.PROGRESS_PENDING:
+	default:
+		return false, nil
+	}
+
+	started, err := b.orchestrator.BootstrapVirtualChainData(ctx, string(b.config.NodeAddress()), uint32(chain.Id), containerName, chain.BootstrapFrom.GetSource(), progress)
+	if err != nil {
+		progress.Finish(err)
+		logger.Error("failed to bootstrap vchain data", log.Error(err))
+		return false, err
+	}
+
+	if !started {
+		logger.Info("vchain already has data, skipping bootstrap", log.String("source", chain.BootstrapFrom.Source))
+		return true, nil
+	}
+
+	logger.Info("started bootstrapping vchain data", log.String("source", chain.BootstrapFrom.Source))
+	return progress.Status().State == snapshot.PROGRESS_DONE, nil
+}
+
 func getNetworkConfigJSON(nodes []*config.FederationNode) []byte {
 	jsonMap := make(map[string]interface{})
 
@@ -129,9 +214,18 @@ func overrideTopologyPort(nodes []*config.FederationNode, gossipPort int) []*con
 	return newTopology
 }
 
+func getVirtualChainConfigHash(cfg config.NodeConfiguration, chain *config.VirtualChain) string {
+	return getConfigHash(getVirtualChainConfig(cfg, chain))
+}
+
+// Snapshots and bootstraps should not restart the chain by themselves, so they are not part of the hash
 func getVirtualChainConfig(cfg config.NodeConfiguration, chain *config.VirtualChain) *config.VirtualChainConfig {
+	chainWithoutActions := *chain
+	chainWithoutActions.Snapshot = nil
+	chainWithoutActions.BootstrapFrom = nil
+
 	return &config.VirtualChainConfig{
-		VirtualChain:  chain,
+		VirtualChain:  &chainWithoutActions,
 		Topology:      overrideTopologyPort(cfg.FederationNodes(), chain.ExternalPort),
 		NodeAddress:   cfg.NodeAddress(),
 		KeyPairConfig: getKeyConfigJson(cfg, true),
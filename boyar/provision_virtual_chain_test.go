@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/snapshot"
 	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/test/helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -202,3 +206,99 @@ func Test_BoyarProvisionVirtualChainsOnOffAndOnAgain(t *testing.T) {
 
 	orchestrator.AssertExpectations(t)
 }
+
+func Test_BoyarProvisionVirtualChainsTakesSnapshotOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boyar-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	orchestrator := &adapter.OrchestratorMock{}
+	cfg := getJSONConfig(t, ConfigWithSingleChain)
+	b := NewBoyar(orchestrator, cfg, NewCache(), helpers.DefaultTestLogger())
+
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.Anything, mock.Anything).Once().Return(nil)
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+	orchestrator.AssertExpectations(t)
+
+	// the chain is started again after the snapshot stopped it
+	path := filepath.Join(dir, "chain.tar.zst")
+	cfg.Chains()[0].Snapshot = &config.VirtualChainSnapshot{Path: path}
+	orchestrator.On("SnapshotVirtualChainData", mock.Anything, mock.Anything, uint32(cfg.Chains()[0].Id), mock.Anything, path).Once().Return(nil)
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.Anything, mock.Anything).Once().Return(nil)
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+	orchestrator.AssertExpectations(t)
+
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+
+	// existing archives are never overwritten
+	existing := filepath.Join(dir, "existing.tar.zst")
+	require.NoError(t, ioutil.WriteFile(existing, []byte("archive"), 0644))
+	cfg.Chains()[0].Snapshot = &config.VirtualChainSnapshot{Path: existing}
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+
+	orchestrator.AssertExpectations(t)
+	orchestrator.AssertNumberOfCalls(t, "SnapshotVirtualChainData", 1)
+	orchestrator.AssertNumberOfCalls(t, "RunVirtualChain", 2)
+}
+
+func Test_BoyarProvisionVirtualChainsBootstrapsBeforeFirstRun(t *testing.T) {
+	orchestrator := &adapter.OrchestratorMock{}
+	cfg := getJSONConfig(t, ConfigWithSingleChain)
+	cache := NewCache()
+	b := NewBoyar(orchestrator, cfg, cache, helpers.DefaultTestLogger())
+
+	chain := cfg.Chains()[0]
+	chain.BootstrapFrom = &config.VirtualChainBootstrap{Source: "https://peer/chain.tar.zst", Sha256: "abcd"}
+	containerName := cfg.NamespacedContainerName(chain.GetContainerName())
+
+	var progress *snapshot.Progress
+	orchestrator.On("BootstrapVirtualChainData", mock.Anything, mock.Anything, uint32(chain.Id), containerName, chain.BootstrapFrom.GetSource(), mock.Anything).Return(true, nil).Run(func(args mock.Arguments) {
+		progress = args.Get(5).(*snapshot.Progress)
+		progress.Start(-1)
+	})
+
+	// the chain is not started while the bootstrap runs in the background
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+	orchestrator.AssertNotCalled(t, "RunVirtualChain", mock.Anything, mock.Anything, mock.Anything)
+	orchestrator.AssertNumberOfCalls(t, "BootstrapVirtualChainData", 1)
+	require.Equal(t, snapshot.PROGRESS_DOWNLOADING, cache.Bootstraps().Status()[containerName].State)
+
+	// failed bootstraps are retried
+	progress.Finish(fmt.Errorf("connection refused"))
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+	orchestrator.AssertNotCalled(t, "RunVirtualChain", mock.Anything, mock.Anything, mock.Anything)
+	orchestrator.AssertNumberOfCalls(t, "BootstrapVirtualChainData", 2)
+
+	progress.Finish(nil)
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.Anything, mock.Anything).Once().Return(nil)
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+	require.Equal(t, snapshot.PROGRESS_DONE, cache.Bootstraps().Status()[containerName].State)
+
+	// changing the source does not restart a running chain
+	chain.BootstrapFrom = &config.VirtualChainBootstrap{Source: "https://other-peer/chain.tar.zst", Sha256: "abcd"}
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+
+	orchestrator.AssertExpectations(t)
+	orchestrator.AssertNumberOfCalls(t, "BootstrapVirtualChainData", 2)
+}
+
+func Test_BoyarProvisionVirtualChainsFallsBackToGenesis(t *testing.T) {
+	orchestrator := &adapter.OrchestratorMock{}
+	cfg := getJSONConfig(t, ConfigWithSingleChain)
+	b := NewBoyar(orchestrator, cfg, NewCache(), helpers.DefaultTestLogger())
+
+	chain := cfg.Chains()[0]
+	chain.BootstrapFrom = &config.VirtualChainBootstrap{Source: "https://peer/chain.tar.zst", Sha256: "abcd", FallbackToGenesis: true}
+	containerName := cfg.NamespacedContainerName(chain.GetContainerName())
+
+	orchestrator.On("BootstrapVirtualChainData", mock.Anything, mock.Anything, uint32(chain.Id), containerName, chain.BootstrapFrom.GetSource(), mock.Anything).Once().Return(false, fmt.Errorf("no such volume"))
+	require.EqualError(t, b.ProvisionVirtualChains(context.Background()), "no such volume")
+	orchestrator.AssertNotCalled(t, "RunVirtualChain", mock.Anything, mock.Anything, mock.Anything)
+
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.Anything, mock.Anything).Once().Return(nil)
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+
+	orchestrator.AssertExpectations(t)
+	orchestrator.AssertNumberOfCalls(t, "BootstrapVirtualChainData", 1)
+}
@@ -0,0 +1,137 @@
+package boyar
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/boyarin/log_types"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/orbs-network/scribe/log"
+	"strings"
+)
+
+// Compares running services with the desired state and invalidates the cache for every service that drifted,
+// so that the next provisioning round creates, updates or removes only what differs.
+// Services that match the desired state are marked as applied: the config hash is stored with every container,
+// so after a restart the cache is restored from what is running instead of recreating everything.
+// Services without a stored hash are adopted as they are.
+// Services that are no longer present in the configuration are removed right away.
+func (b *boyar) Reconcile(ctx context.Context) error {
+	runningServices, err := b.orchestrator.ListServices(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list running services: %s", err)
+	}
+
+	running := make(map[string]string)
+	for _, service := range runningServices {
+		running[service.Name] = service.ConfigHash
+	}
+
+	configured := make(map[string]bool)
+
+	for _, chain := range b.config.Chains() {
+		containerName := b.config.NamespacedContainerName(chain.GetContainerName())
+		configured[containerName] = true
+
+		logger := b.logger.WithTags(log_types.VirtualChainId(int64(chain.Id)))
+		configHash := getVirtualChainConfigHash(b.config, chain)
+		if b.reconcileService(containerName, running, chain.Disabled, configHash, logger) {
+			b.cache.vChains.Clear(containerName)
+		} else if chain.Disabled {
+			b.cache.vChains.SetJsonValue(containerName, removed)
+		} else {
+			b.cache.vChains.SetValue(containerName, &utils.HashedValue{Value: configHash})
+		}
+	}
+
+	for serviceName, service := range b.config.Services() {
+		if service == nil {
+			continue
+		}
+
+		fullServiceName := b.config.NamespacedContainerName(serviceName)
+		configured[fullServiceName] = true
+
+		var configHash string
+		if !service.Disabled {
+			serviceConfig, _ := getServiceConfig(b.config, serviceName, service)
+			configHash = serviceConfig.ConfigHash
+		}
+
+		logger := b.logger.WithTags(log.String("service", serviceName))
+		if b.reconcileService(fullServiceName, running, service.Disabled, configHash, logger) {
+			b.cache.services.Clear(serviceName)
+		} else if service.Disabled {
+			b.cache.services.SetJsonValue(serviceName, removed)
+		} else {
+			b.cache.services.SetValue(serviceName, &utils.HashedValue{Value: configHash})
+		}
+	}
+
+	proxyName := b.config.NamespacedContainerName(adapter.PROXY_CONTAINER_NAME)
+	configured[proxyName] = true
+
+	nginxConfigHash := getNginxConfigHash(b.config)
+	if b.reconcileService(proxyName, running, false, nginxConfigHash, b.logger) {
+		b.cache.nginx.Clear()
+	} else {
+		b.cache.nginx.SetValue(&utils.HashedValue{Value: nginxConfigHash})
+	}
+
+	var errors []error
+	for name, configHash := range running {
+		if configured[name] || !b.isOrphan(name, configHash) {
+			continue
+		}
+
+		if err := b.orchestrator.RemoveService(ctx, name); err != nil {
+			b.logger.Error("failed to remove orphaned service", log.String("service", name), log.Error(err))
+			errors = append(errors, err)
+		} else {
+			b.logger.Info("removed orphaned service", log.String("service", name))
+		}
+	}
+
+	return utils.AggregateErrors(errors)
+}
+
+// Returns true if the service should be provisioned again
+func (b *boyar) reconcileService(name string, running map[string]string, disabled bool, configHash string, logger log.Logger) bool {
+	runningHash, isRunning := running[name]
+
+	if disabled {
+		if isRunning {
+			logger.Info("disabled service is still running")
+		}
+
+		return isRunning
+	}
+
+	if !isRunning {
+		logger.Info("service is not running")
+		return true
+	}
+
+	// started before config hashes were stored, the service is labeled once its configuration changes
+	if runningHash == "" {
+		logger.Info("adopting service without config hash")
+		return false
+	}
+
+	if runningHash != configHash {
+		logger.Info("service configuration drifted", log.String("running", runningHash), log.String("desired", configHash))
+		return true
+	}
+
+	return false
+}
+
+// Only touches services from the same namespace that carry the config hash label, so it is certain boyar started them.
+// Names alone are not enough: without a namespace every name matches, and other containers could be named like a virtual chain.
+func (b *boyar) isOrphan(name string, configHash string) bool {
+	if !strings.HasPrefix(name, b.config.NamespacedContainerName("")) {
+		return false
+	}
+
+	return configHash != ""
+}
@@ -0,0 +1,143 @@
+package boyar
+
+import (
+	"context"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func provisionAll(t *testing.T, b Boyar) {
+	require.NoError(t, b.ProvisionServices(context.Background()))
+	require.NoError(t, b.ProvisionVirtualChains(context.Background()))
+	require.NoError(t, b.ProvisionHttpAPIEndpoint(context.Background()))
+}
+
+func getRunningServices(cfg *boyar) []*adapter.RunningService {
+	services := []*adapter.RunningService{
+		{Name: adapter.PROXY_CONTAINER_NAME, ConfigHash: getNginxConfigHash(cfg.config)},
+	}
+
+	for _, chain := range cfg.config.Chains() {
+		services = append(services, &adapter.RunningService{
+			Name:       chain.GetContainerName(),
+			ConfigHash: getVirtualChainConfigHash(cfg.config, chain),
+		})
+	}
+
+	return services
+}
+
+func TestBoyar_ReconcileWithoutDrift(t *testing.T) {
+	cfg := getJSONConfig(t, ConfigWithActiveVchains)
+
+	orchestrator := &adapter.OrchestratorMock{}
+	orchestrator.On("GetOverlayNetwork", mock.Anything, mock.Anything).Return("fake-network-id", nil)
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+	orchestrator.On("RunReverseProxy", mock.Anything, mock.Anything).Return(nil).Once()
+
+	b := NewBoyar(orchestrator, cfg, NewCache(), helpers.DefaultTestLogger()).(*boyar)
+	provisionAll(t, b)
+
+	orchestrator.On("ListServices", mock.Anything).Return(getRunningServices(b), nil).Once()
+	require.NoError(t, b.Reconcile(context.Background()))
+
+	provisionAll(t, b)
+	orchestrator.AssertExpectations(t)
+}
+
+func TestBoyar_ReconcileRestartsMissingAndDriftedServices(t *testing.T) {
+	cfg := getJSONConfig(t, ConfigWithActiveVchains)
+
+	orchestrator := &adapter.OrchestratorMock{}
+	orchestrator.On("GetOverlayNetwork", mock.Anything, mock.Anything).Return("fake-network-id", nil)
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+	orchestrator.On("RunReverseProxy", mock.Anything, mock.Anything).Return(nil).Once()
+
+	b := NewBoyar(orchestrator, cfg, NewCache(), helpers.DefaultTestLogger()).(*boyar)
+	provisionAll(t, b)
+
+	running := getRunningServices(b)
+	running[1].ConfigHash = "drifted"
+	orchestrator.On("ListServices", mock.Anything).Return(running[:2], nil).Once()
+	require.NoError(t, b.Reconcile(context.Background()))
+
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.MatchedBy(func(serviceConfig *adapter.ServiceConfig) bool {
+		return serviceConfig.ContainerName == "chain-42" && serviceConfig.ConfigHash == getVirtualChainConfigHash(cfg, cfg.Chains()[0])
+	}), mock.Anything).Return(nil).Once()
+	orchestrator.On("RunVirtualChain", mock.Anything, mock.MatchedBy(func(serviceConfig *adapter.ServiceConfig) bool {
+		return serviceConfig.ContainerName == "chain-1991"
+	}), mock.Anything).Return(nil).Once()
+
+	provisionAll(t, b)
+	orchestrator.AssertExpectations(t)
+}
+
+func TestBoyar_ReconcileRemovesOrphanedServices(t *testing.T) {
+	cfg := getJSONConfig(t, ConfigWithActiveVchains)
+
+	orchestrator := &adapter.OrchestratorMock{}
+	b := NewBoyar(orchestrator, cfg, NewCache(), helpers.DefaultTestLogger()).(*boyar)
+
+	running := append(getRunningServices(b),
+		&adapter.RunningService{Name: "chain-2020", ConfigHash: "some-hash"},
+		&adapter.RunningService{Name: "old-service", ConfigHash: "some-hash"},
+		&adapter.RunningService{Name: "unrelated-service"},
+		&adapter.RunningService{Name: "chain-2021"}, // not started by boyar, only named like a virtual chain
+	)
+	orchestrator.On("ListServices", mock.Anything).Return(running, nil).Once()
+	orchestrator.On("RemoveService", mock.Anything, "chain-2020").Return(nil).Once()
+	orchestrator.On("RemoveService", mock.Anything, "old-service").Return(nil).Once()
+
+	require.NoError(t, b.Reconcile(context.Background()))
+	orchestrator.AssertExpectations(t)
+}
+
+func TestBoyar_ReconcileAfterRestartDoesNotRecreateServices(t *testing.T) {
+	cfg := getJSONConfig(t, ConfigWithSigner)
+
+	orchestrator := &adapter.OrchestratorMock{}
+	orchestrator.On("GetOverlayNetwork", mock.Anything, mock.Anything).Return("fake-network-id", nil)
+	b := NewBoyar(orchestrator, cfg, NewCache(), helpers.DefaultTestLogger()).(*boyar)
+
+	running := getRunningServices(b)
+	for serviceName, service := range cfg.Services() {
+		serviceConfig, _ := getServiceConfig(cfg, serviceName, service)
+		running = append(running, &adapter.RunningService{Name: serviceName, ConfigHash: serviceConfig.ConfigHash})
+	}
+
+	// disabled chain is not running
+	var enabled []*adapter.RunningService
+	for _, service := range running {
+		if service.Name != "chain-1976" {
+			enabled = append(enabled, service)
+		}
+	}
+
+	orchestrator.On("ListServices", mock.Anything).Return(enabled, nil).Once()
+	require.NoError(t, b.Reconcile(context.Background()))
+
+	provisionAll(t, b)
+	orchestrator.AssertExpectations(t)
+}
+
+func TestBoyar_ReconcileAdoptsServicesWithoutConfigHash(t *testing.T) {
+	cfg := getJSONConfig(t, ConfigWithActiveVchains)
+
+	orchestrator := &adapter.OrchestratorMock{}
+	orchestrator.On("GetOverlayNetwork", mock.Anything, mock.Anything).Return("fake-network-id", nil)
+	b := NewBoyar(orchestrator, cfg, NewCache(), helpers.DefaultTestLogger()).(*boyar)
+
+	running := getRunningServices(b)
+	for _, service := range running {
+		service.ConfigHash = ""
+	}
+
+	orchestrator.On("ListServices", mock.Anything).Return(running, nil).Once()
+	require.NoError(t, b.Reconcile(context.Background()))
+
+	provisionAll(t, b)
+	orchestrator.AssertExpectations(t)
+}
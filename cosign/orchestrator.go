@@ -0,0 +1,57 @@
+package cosign
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/orbs-network/boyarin/strelets/adapter"
+)
+
+// Refuses to run virtual chains and services from images that are not signed by the trusted keys,
+// and runs the verified ones by digest
+type verifyingOrchestrator struct {
+	adapter.Orchestrator
+	verifier *Verifier
+}
+
+// Returns the orchestrator as is if verification is disabled
+func NewVerifyingOrchestrator(orchestrator adapter.Orchestrator, verifier *Verifier) adapter.Orchestrator {
+	if verifier == nil {
+		return orchestrator
+	}
+
+	return &verifyingOrchestrator{
+		Orchestrator: orchestrator,
+		verifier:     verifier,
+	}
+}
+
+func (o *verifyingOrchestrator) pin(ctx context.Context, serviceConfig *adapter.ServiceConfig) (*adapter.ServiceConfig, error) {
+	pinned, err := o.verifier.Verify(ctx, serviceConfig.ImageName)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to run %s: %s", serviceConfig.ContainerName, err)
+	}
+
+	// config hash stays the same, otherwise every service would be considered changed on every reconciliation
+	pinnedConfig := *serviceConfig
+	pinnedConfig.ImageName = pinned
+	return &pinnedConfig, nil
+}
+
+func (o *verifyingOrchestrator) RunVirtualChain(ctx context.Context, serviceConfig *adapter.ServiceConfig, appConfig *adapter.AppConfig) error {
+	pinnedConfig, err := o.pin(ctx, serviceConfig)
+	if err != nil {
+		return err
+	}
+
+	return o.Orchestrator.RunVirtualChain(ctx, pinnedConfig, appConfig)
+}
+
+func (o *verifyingOrchestrator) RunService(ctx context.Context, serviceConfig *adapter.ServiceConfig, appConfig *adapter.AppConfig) error {
+	pinnedConfig, err := o.pin(ctx, serviceConfig)
+	if err != nil {
+		return err
+	}
+
+	return o.Orchestrator.RunService(ctx, pinnedConfig, appConfig)
+}
@@ -0,0 +1,219 @@
+package cosign
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const DOCKER_HUB_REGISTRY = "registry-1.docker.io"
+
+const MAX_REGISTRY_RESPONSE_SIZE = 4 * 1024 * 1024
+
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+var bearerChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+type imageReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// Follows docker conventions: the first path component is a registry only if it looks like a host,
+// official images on Docker Hub live under library/
+func parseImageReference(name string) (*imageReference, error) {
+	ref := &imageReference{}
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.digest = name[i+1:]
+		name = name[:i]
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.tag = name[i+1:]
+		name = name[:i]
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("image name is empty")
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.registry, ref.repository = parts[0], parts[1]
+	} else {
+		ref.registry, ref.repository = DOCKER_HUB_REGISTRY, name
+		if len(parts) == 1 {
+			ref.repository = "library/" + name
+		}
+	}
+
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+
+	return ref, nil
+}
+
+// Local registries are usually served over plain http, the same way docker treats them as insecure
+func (r *imageReference) baseUrl() string {
+	host := strings.Split(r.registry, ":")[0]
+	if host == "localhost" || host == "127.0.0.1" {
+		return "http://" + r.registry + "/v2/" + r.repository
+	}
+
+	return "https://" + r.registry + "/v2/" + r.repository
+}
+
+// Only anonymous access is supported, which is what public images need
+type registryClient struct {
+	client *http.Client
+}
+
+func (c *registryClient) get(ctx context.Context, ref *imageReference, path string, accept []string) ([]byte, http.Header, error) {
+	request, err := http.NewRequest(http.MethodGet, ref.baseUrl()+path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Accept", strings.Join(accept, ", "))
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized {
+		challenge := response.Header.Get("WWW-Authenticate")
+		response.Body.Close()
+
+		token, err := c.getToken(ctx, challenge)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not authorize with %s: %s", ref.registry, err)
+		}
+
+		request.Header.Set("Authorization", "Bearer "+token)
+		if response, err = c.client.Do(request); err != nil {
+			return nil, nil, err
+		}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s returned with status %s", request.URL, response.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, MAX_REGISTRY_RESPONSE_SIZE+1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(body) > MAX_REGISTRY_RESPONSE_SIZE {
+		return nil, nil, fmt.Errorf("%s returned more than %d bytes", request.URL, MAX_REGISTRY_RESPONSE_SIZE)
+	}
+
+	return body, response.Header, nil
+}
+
+// Anonymous token from the realm of the Bearer challenge
+func (c *registryClient) getToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, match := range bearerChallengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+
+	if params["realm"] == "" {
+		return "", fmt.Errorf("authentication challenge %q has no realm", challenge)
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+
+	request, err := http.NewRequest(http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := c.client.Do(request.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned with status %s", response.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.Token != "" {
+		return token.Token, nil
+	}
+
+	return token.AccessToken, nil
+}
+
+// Digest of the manifest the tag points to, as the registry reports it
+func (c *registryClient) resolveDigest(ctx context.Context, ref *imageReference) (string, error) {
+	if ref.digest != "" {
+		return ref.digest, nil
+	}
+
+	body, header, err := c.get(ctx, ref, "/manifests/"+ref.tag, manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+
+	if digest := header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	return sha256Digest(body), nil
+}
+
+func (c *registryClient) getBlob(ctx context.Context, ref *imageReference, digest string) ([]byte, error) {
+	body, _, err := c.get(ctx, ref, "/blobs/"+digest, []string{"*/*"})
+	if err != nil {
+		return nil, err
+	}
+
+	if actual := sha256Digest(body); actual != digest {
+		return nil, fmt.Errorf("blob %s has digest %s", digest, actual)
+	}
+
+	return body, nil
+}
+
+func sha256Digest(data []byte) string {
+	hash := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
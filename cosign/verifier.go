@@ -0,0 +1,217 @@
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const SIGNATURE_ANNOTATION = "dev.cosignproject.cosign/signature"
+const REGISTRY_TIMEOUT = 30 * time.Second
+
+var signatureManifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Signatures are stored next to the image under the tag sha256-<hex>.sig, one layer per signature
+type signatureManifest struct {
+	Layers []signatureLayer `json:"layers"`
+}
+
+type signatureLayer struct {
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Simple signing payload, only the digest it covers matters
+type signaturePayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// Verifies cosign-style signatures of images with ECDSA keys (what `cosign generate-key-pair` produces).
+// Verified digests are remembered for the lifetime of the process.
+type Verifier struct {
+	keys     []*ecdsa.PublicKey
+	registry *registryClient
+
+	mutex    sync.Mutex
+	verified map[string]bool
+}
+
+func NewVerifier(keys []*ecdsa.PublicKey) *Verifier {
+	return &Verifier{
+		keys:     keys,
+		registry: &registryClient{client: &http.Client{Timeout: REGISTRY_TIMEOUT}},
+		verified: make(map[string]bool),
+	}
+}
+
+// Reads PEM encoded public keys
+func LoadPublicKeys(paths []string) (keys []*ecdsa.PublicKey, err error) {
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read public key: %s", err)
+		}
+
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse public key %s: %s", path, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return
+}
+
+func ParsePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("not a PEM encoded public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("only ECDSA keys are supported")
+	}
+
+	return ecdsaKey, nil
+}
+
+// Returns the image reference pinned to the digest that was verified, so that the registry could not serve anything else
+func (v *Verifier) Verify(ctx context.Context, imageName string) (string, error) {
+	ref, err := parseImageReference(imageName)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := v.registry.resolveDigest(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve digest of %s: %s", imageName, err)
+	}
+
+	pinned := imageName
+	if ref.digest == "" {
+		pinned = imageName + "@" + digest
+	}
+
+	key := ref.registry + "/" + ref.repository + "@" + digest
+	if v.isVerified(key) {
+		return pinned, nil
+	}
+
+	if err := v.verifyDigest(ctx, ref, digest); err != nil {
+		return "", fmt.Errorf("could not verify signature of %s: %s", pinned, err)
+	}
+
+	v.mutex.Lock()
+	v.verified[key] = true
+	v.mutex.Unlock()
+
+	return pinned, nil
+}
+
+func (v *Verifier) isVerified(key string) bool {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	return v.verified[key]
+}
+
+func (v *Verifier) verifyDigest(ctx context.Context, ref *imageReference, digest string) error {
+	signatureTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	rawManifest, _, err := v.registry.get(ctx, ref, "/manifests/"+signatureTag, signatureManifestMediaTypes)
+	if err != nil {
+		return fmt.Errorf("no signatures found: %s", err)
+	}
+
+	manifest := &signatureManifest{}
+	if err := json.Unmarshal(rawManifest, manifest); err != nil {
+		return fmt.Errorf("could not parse signature manifest: %s", err)
+	}
+
+	var errors []string
+	for _, layer := range manifest.Layers {
+		if err := v.verifyLayer(ctx, ref, digest, layer.Digest, layer.Annotations[SIGNATURE_ANNOTATION]); err != nil {
+			errors = append(errors, err.Error())
+		} else {
+			return nil
+		}
+	}
+
+	if len(errors) == 0 {
+		return fmt.Errorf("no signatures found")
+	}
+
+	return fmt.Errorf("none of the signatures is valid: %s", strings.Join(errors, "; "))
+}
+
+func (v *Verifier) verifyLayer(ctx context.Context, ref *imageReference, digest string, layerDigest string, encodedSignature string) error {
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("layer %s has no signature", layerDigest)
+	}
+
+	payload, err := v.registry.getBlob(ctx, ref, layerDigest)
+	if err != nil {
+		return err
+	}
+
+	if !v.verifySignature(payload, signature) {
+		return fmt.Errorf("signature of layer %s does not match any of the trusted keys", layerDigest)
+	}
+
+	parsed := &signaturePayload{}
+	if err := json.Unmarshal(payload, parsed); err != nil {
+		return fmt.Errorf("could not parse payload of layer %s: %s", layerDigest, err)
+	}
+
+	if signed := parsed.Critical.Image.DockerManifestDigest; signed != digest {
+		return fmt.Errorf("layer %s signs a different digest %s", layerDigest, signed)
+	}
+
+	return nil
+}
+
+func (v *Verifier) verifySignature(payload []byte, signature []byte) bool {
+	parsed := &ecdsaSignature{}
+	if rest, err := asn1.Unmarshal(signature, parsed); err != nil || len(rest) > 0 || parsed.R == nil || parsed.S == nil {
+		return false
+	}
+
+	hash := sha256.Sum256(payload)
+	for _, key := range v.keys {
+		if ecdsa.Verify(key, hash[:], parsed.R, parsed.S) {
+			return true
+		}
+	}
+
+	return false
+}
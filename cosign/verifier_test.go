@@ -0,0 +1,172 @@
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+const IMAGE_MANIFEST = `{"schemaVersion":2,"config":{"digest":"sha256:c0ffee"}}`
+
+type fakeRegistry struct {
+	server     helpers.HttpServer
+	blobs      map[string][]byte
+	signatures map[string]string
+}
+
+// Serves a single image repository with its cosign signatures
+func newFakeRegistry() *fakeRegistry {
+	registry := &fakeRegistry{
+		blobs:      make(map[string][]byte),
+		signatures: make(map[string]string),
+	}
+
+	registry.server = helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		path := strings.TrimPrefix(request.URL.Path, "/v2/orbsnetwork/node")
+		switch {
+		case path == "/manifests/v2.0.0":
+			writer.Header().Set("Docker-Content-Digest", sha256Digest([]byte(IMAGE_MANIFEST)))
+			writer.Write([]byte(IMAGE_MANIFEST))
+		case strings.HasPrefix(path, "/manifests/") && strings.HasSuffix(path, ".sig"):
+			manifest := signatureManifest{}
+			for digest, signature := range registry.signatures {
+				manifest.Layers = append(manifest.Layers, signatureLayer{digest, map[string]string{SIGNATURE_ANNOTATION: signature}})
+			}
+
+			if len(manifest.Layers) == 0 {
+				writer.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(writer).Encode(manifest)
+		case strings.HasPrefix(path, "/blobs/"):
+			blob, found := registry.blobs[strings.TrimPrefix(path, "/blobs/")]
+			if !found {
+				writer.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writer.Write(blob)
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	})
+	registry.server.Start()
+
+	return registry
+}
+
+func (r *fakeRegistry) image() string {
+	return fmt.Sprintf("127.0.0.1:%d/orbsnetwork/node:v2.0.0", r.server.Port())
+}
+
+func (r *fakeRegistry) sign(t *testing.T, key *ecdsa.PrivateKey, digest string) {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"orbsnetwork/node"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, digest))
+	hash := sha256.Sum256(payload)
+
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	require.NoError(t, err)
+
+	r.blobs[sha256Digest(payload)] = payload
+	r.signatures[sha256Digest(payload)] = base64.StdEncoding.EncodeToString(signature)
+}
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func writePublicKey(t *testing.T, dir string, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "cosign.pub")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+	return path
+}
+
+func TestVerifier_AcceptsImageSignedByTrustedKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boyar-cosign")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := newFakeRegistry()
+	defer registry.server.Shutdown()
+
+	key := generateKey(t)
+	digest := sha256Digest([]byte(IMAGE_MANIFEST))
+	registry.sign(t, key, digest)
+
+	keys, err := LoadPublicKeys([]string{writePublicKey(t, dir, key)})
+	require.NoError(t, err)
+
+	pinned, err := NewVerifier(keys).Verify(context.Background(), registry.image())
+	require.NoError(t, err)
+	require.Equal(t, registry.image()+"@"+digest, pinned)
+
+	orchestrator := adapter.NewRecordingOrchestrator(nil)
+	verifying := NewVerifyingOrchestrator(orchestrator, NewVerifier(keys))
+	require.NoError(t, verifying.RunVirtualChain(context.Background(), &adapter.ServiceConfig{ContainerName: "chain-42", ImageName: registry.image()}, &adapter.AppConfig{}))
+
+	require.Equal(t, orchestrator, NewVerifyingOrchestrator(orchestrator, nil))
+}
+
+func TestVerifier_RejectsUntrustedImages(t *testing.T) {
+	registry := newFakeRegistry()
+	defer registry.server.Shutdown()
+
+	trusted := generateKey(t)
+	verifier := NewVerifier([]*ecdsa.PublicKey{&trusted.PublicKey})
+
+	_, err := verifier.Verify(context.Background(), registry.image())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no signatures found")
+
+	registry.sign(t, generateKey(t), sha256Digest([]byte(IMAGE_MANIFEST)))
+	_, err = verifier.Verify(context.Background(), registry.image())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match any of the trusted keys")
+
+	registry.signatures = make(map[string]string)
+	registry.sign(t, trusted, "sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	_, err = verifier.Verify(context.Background(), registry.image())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "signs a different digest")
+
+	orchestrator := adapter.NewRecordingOrchestrator(nil)
+	err = NewVerifyingOrchestrator(orchestrator, verifier).RunService(context.Background(), &adapter.ServiceConfig{ContainerName: "signer", ImageName: registry.image()}, &adapter.AppConfig{})
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "refusing to run signer: "))
+}
+
+func TestParseImageReference(t *testing.T) {
+	for name, expected := range map[string]imageReference{
+		"nginx":                   {DOCKER_HUB_REGISTRY, "library/nginx", "latest", ""},
+		"orbsnetwork/node:v2.0.0": {DOCKER_HUB_REGISTRY, "orbsnetwork/node", "v2.0.0", ""},
+		"localhost:5000/node:v1":  {"localhost:5000", "node", "v1", ""},
+		"ghcr.io/orbs-network/node@sha256:c0ffee": {"ghcr.io", "orbs-network/node", "", "sha256:c0ffee"},
+	} {
+		ref, err := parseImageReference(name)
+		require.NoError(t, err)
+		require.Equal(t, expected, *ref, name)
+	}
+
+	_, err := parseImageReference(":v1")
+	require.EqualError(t, err, "image name is empty")
+}
@@ -0,0 +1,173 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+const ED25519 = "ed25519"
+const SECP256K1 = "secp256k1"
+
+const SECP256K1_SIGNATURE_SIZE = 65
+const ETHEREUM_ADDRESS_SIZE = 20
+
+// Secp256k1 keys could be either a public key or an Ethereum-style address (like orbs node addresses)
+type PublicKey struct {
+	Scheme string
+	Key    []byte
+}
+
+// Accepts keys in the form of `ed25519:<hex>` or `secp256k1:<hex>`
+func ParsePublicKey(value string) (*PublicKey, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("public key %s should be prefixed with the signature scheme (%s or %s)", value, ED25519, SECP256K1)
+	}
+
+	scheme := strings.ToLower(parts[0])
+	key, err := decodeHex(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not decode public key %s: %s", value, err)
+	}
+
+	switch scheme {
+	case ED25519:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("ed25519 public key should be %d bytes long, got %d", ed25519.PublicKeySize, len(key))
+		}
+	case SECP256K1:
+		if len(key) != ETHEREUM_ADDRESS_SIZE {
+			if _, err := secp256k1.ParsePubKey(key); err != nil {
+				return nil, fmt.Errorf("secp256k1 key should be either an address or a valid public key: %s", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signature scheme %s", parts[0])
+	}
+
+	return &PublicKey{
+		Scheme: scheme,
+		Key:    key,
+	}, nil
+}
+
+func ParsePublicKeys(values []string) ([]*PublicKey, error) {
+	var keys []*PublicKey
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		key, err := ParsePublicKey(value)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (k *PublicKey) Verify(data []byte, signature []byte) bool {
+	switch k.Scheme {
+	case ED25519:
+		return len(signature) == ed25519.SignatureSize && ed25519.Verify(k.Key, data, signature)
+	case SECP256K1:
+		return k.verifySecp256k1(data, signature)
+	}
+
+	return false
+}
+
+// Expects Ethereum-style signature (R || S || V) of the keccak256 digest of the data
+func (k *PublicKey) verifySecp256k1(data []byte, signature []byte) bool {
+	if len(signature) != SECP256K1_SIGNATURE_SIZE {
+		return false
+	}
+
+	recoveryId := signature[64]
+	if recoveryId >= 27 {
+		recoveryId -= 27
+	}
+
+	if recoveryId > 1 {
+		return false
+	}
+
+	compactSignature := make([]byte, SECP256K1_SIGNATURE_SIZE)
+	compactSignature[0] = 27 + recoveryId
+	copy(compactSignature[1:], signature[:64])
+
+	publicKey, _, err := ecdsa.RecoverCompact(compactSignature, Keccak256(data))
+	if err != nil {
+		return false
+	}
+
+	if len(k.Key) == ETHEREUM_ADDRESS_SIZE {
+		return bytes.Equal(k.Key, PublicKeyToAddress(publicKey))
+	}
+
+	expected, err := secp256k1.ParsePubKey(k.Key)
+	return err == nil && publicKey.IsEqual(expected)
+}
+
+func (k *PublicKey) String() string {
+	return k.Scheme + ":" + hex.EncodeToString(k.Key)
+}
+
+// Returns the key that produced a valid signature, or nil if none of them did
+func VerifySignature(keys []*PublicKey, data []byte, signature []byte) *PublicKey {
+	for _, key := range keys {
+		if key.Verify(data, signature) {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// Detached signature file contains one hex encoded signature per line, so the keys could be rotated
+func VerifyDetachedSignature(keys []*PublicKey, data []byte, signatures []byte) (*PublicKey, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no trusted keys provided")
+	}
+
+	for _, line := range strings.Split(string(signatures), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		signature, err := decodeHex(line)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode signature: %s", err)
+		}
+
+		if key := VerifySignature(keys, data, signature); key != nil {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("signature does not match any of the trusted keys")
+}
+
+func Keccak256(data []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+func PublicKeyToAddress(publicKey *secp256k1.PublicKey) []byte {
+	return Keccak256(publicKey.SerializeUncompressed()[1:])[12:]
+}
+
+func decodeHex(value string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(value), "0x"))
+}
@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/ecdsa"
+	"github.com/stretchr/testify/require"
+)
+
+// Produces Ethereum-style signature (R || S || V)
+func signSecp256k1(privateKey *secp256k1.PrivateKey, data []byte) []byte {
+	compactSignature := ecdsa.SignCompact(privateKey, Keccak256(data), false)
+	return append(compactSignature[1:], compactSignature[0]-27)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	publicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	key, err := ParsePublicKey("ed25519:" + hex.EncodeToString(publicKey))
+	require.NoError(t, err)
+	require.Equal(t, ED25519, key.Scheme)
+
+	_, err = ParsePublicKey("secp256k1:0x" + "a328846cd5b4979d68a8c58a9bdfeee657b34de7")
+	require.NoError(t, err)
+
+	_, err = ParsePublicKey(hex.EncodeToString(publicKey))
+	require.Error(t, err, "should require the scheme prefix")
+
+	_, err = ParsePublicKey("rsa:" + hex.EncodeToString(publicKey))
+	require.EqualError(t, err, "unsupported signature scheme rsa")
+
+	_, err = ParsePublicKey("ed25519:abcd")
+	require.EqualError(t, err, "ed25519 public key should be 32 bytes long, got 2")
+}
+
+func TestPublicKey_VerifyEd25519(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	key := &PublicKey{Scheme: ED25519, Key: publicKey}
+
+	data := []byte(`{"chains":[]}`)
+	signature := ed25519.Sign(privateKey, data)
+
+	require.True(t, key.Verify(data, signature))
+	require.False(t, key.Verify([]byte(`{"chains":[{}]}`), signature))
+	require.False(t, key.Verify(data, signature[1:]))
+}
+
+func TestPublicKey_VerifySecp256k1(t *testing.T) {
+	privateKey, _ := secp256k1.GeneratePrivateKey()
+	otherPrivateKey, _ := secp256k1.GeneratePrivateKey()
+
+	data := []byte(`{"chains":[]}`)
+	signature := signSecp256k1(privateKey, data)
+
+	byAddress := &PublicKey{Scheme: SECP256K1, Key: PublicKeyToAddress(privateKey.PubKey())}
+	require.True(t, byAddress.Verify(data, signature))
+	require.False(t, byAddress.Verify([]byte(`{"chains":[{}]}`), signature))
+	require.False(t, byAddress.Verify(data, signSecp256k1(otherPrivateKey, data)))
+
+	byPublicKey := &PublicKey{Scheme: SECP256K1, Key: privateKey.PubKey().SerializeCompressed()}
+	require.True(t, byPublicKey.Verify(data, signature))
+
+	ethereumSignature := append([]byte{}, signature...)
+	ethereumSignature[64] += 27
+	require.True(t, byAddress.Verify(data, ethereumSignature), "should accept recovery id with Ethereum offset")
+}
+
+func TestVerifySignature(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	keys := []*PublicKey{
+		{Scheme: ED25519, Key: otherPublicKey},
+		{Scheme: ED25519, Key: publicKey},
+	}
+
+	data := []byte("data")
+	require.Equal(t, keys[1], VerifySignature(keys, data, ed25519.Sign(privateKey, data)))
+	require.Nil(t, VerifySignature(keys[:1], data, ed25519.Sign(privateKey, data)))
+}
@@ -0,0 +1,180 @@
+package notifications
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/scribe/log"
+)
+
+const (
+	SEVERITY_INFO     = "info"
+	SEVERITY_WARNING  = "warning"
+	SEVERITY_CRITICAL = "critical"
+)
+
+const (
+	EVENT_HEALTH_CHANGED         = "health-changed"
+	EVENT_CONFIG_INVALID         = "config-invalid"
+	EVENT_CONFIG_FAILED          = "config-failed"
+	EVENT_CONFIG_REVERTED        = "config-reverted"
+	EVENT_BOOTSTRAP_RESET_NEARBY = "bootstrap-reset-nearby"
+	EVENT_SELF_UPDATE            = "self-update"
+)
+
+const NOTIFICATION_QUEUE_SIZE = 100
+const NOTIFICATION_TIMEOUT = 10 * time.Second
+
+// Notifications queued right before shutdown (self-update, rollback) are still sent within this period
+const NOTIFICATION_SHUTDOWN_TIMEOUT = 30 * time.Second
+
+type Event struct {
+	Type        string
+	Subject     string // what the event is about (vchain, disk, configuration hash), events are deduplicated by type and subject
+	Severity    string
+	Message     string
+	NodeAddress string
+	Timestamp   time.Time
+}
+
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event *Event) error
+}
+
+type Options struct {
+	NodeAddress string
+
+	// The same event (type, subject and severity) is not sent again within this period, disabled if zero
+	DedupPeriod time.Duration
+
+	// At most RateLimit events are sent within RatePeriod, the rest are dropped; disabled if zero
+	RateLimit  int
+	RatePeriod time.Duration
+}
+
+type sentEvent struct {
+	severity string
+	at       time.Time
+}
+
+// Filters events synchronously and delivers them in the background so a slow sink never blocks provisioning, nil without sinks
+type Notifier struct {
+	logger  log.Logger
+	options Options
+	sinks   []Sink
+	queue   chan *Event
+
+	mutex    sync.Mutex
+	lastSent map[string]*sentEvent
+	sentAt   []time.Time
+}
+
+func NewNotifier(logger log.Logger, options Options, sinks ...Sink) *Notifier {
+	return &Notifier{
+		logger:   logger,
+		options:  options,
+		sinks:    sinks,
+		queue:    make(chan *Event, NOTIFICATION_QUEUE_SIZE),
+		lastSent: make(map[string]*sentEvent),
+	}
+}
+
+func (n *Notifier) Notify(eventType string, subject string, severity string, message string) {
+	if n == nil {
+		return
+	}
+
+	event := &Event{
+		Type:        eventType,
+		Subject:     subject,
+		Severity:    severity,
+		Message:     message,
+		NodeAddress: n.options.NodeAddress,
+		Timestamp:   time.Now(),
+	}
+
+	if !n.accept(event) {
+		return
+	}
+
+	select {
+	case n.queue <- event:
+	default:
+		n.logger.Error("notification queue is full, dropping notification", log.String("type", eventType), log.String("subject", subject))
+	}
+}
+
+func (n *Notifier) accept(event *Event) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	key := event.Type + "/" + event.Subject
+	if last, found := n.lastSent[key]; found && n.options.DedupPeriod > 0 &&
+		last.severity == event.Severity && event.Timestamp.Sub(last.at) < n.options.DedupPeriod {
+		return false
+	}
+
+	if n.options.RateLimit > 0 {
+		var recent []time.Time
+		for _, at := range n.sentAt {
+			if event.Timestamp.Sub(at) < n.options.RatePeriod {
+				recent = append(recent, at)
+			}
+		}
+		n.sentAt = recent
+
+		if len(n.sentAt) >= n.options.RateLimit {
+			n.logger.Info("notification rate limit reached, dropping notification", log.String("type", event.Type), log.String("subject", event.Subject))
+			return false
+		}
+
+		n.sentAt = append(n.sentAt, event.Timestamp)
+	}
+
+	n.lastSent[key] = &sentEvent{severity: event.Severity, at: event.Timestamp}
+	return true
+}
+
+// Deliveries are not cancelled by the shutdown, the queue is flushed before the notifier stops
+func (n *Notifier) Start(ctx context.Context) govnr.ShutdownWaiter {
+	return govnr.Forever(ctx, "notifications", utils.NewLogErrors("notifications", n.logger), func() {
+		select {
+		case <-ctx.Done():
+			ctxWithTimeout, cancel := context.WithTimeout(context.Background(), NOTIFICATION_SHUTDOWN_TIMEOUT)
+			defer cancel()
+			n.flush(ctxWithTimeout)
+		case event := <-n.queue:
+			n.deliver(context.Background(), event)
+		}
+	})
+}
+
+func (n *Notifier) flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case event := <-n.queue:
+			n.deliver(ctx, event)
+		default:
+			return
+		}
+	}
+
+	if pending := len(n.queue); pending > 0 {
+		n.logger.Error("dropping notifications on shutdown", log.Int("count", pending))
+	}
+}
+
+// Every sink gets its own attempt, failures are only logged
+func (n *Notifier) deliver(ctx context.Context, event *Event) {
+	for _, sink := range n.sinks {
+		ctxWithTimeout, cancel := context.WithTimeout(ctx, NOTIFICATION_TIMEOUT)
+		if err := sink.Send(ctxWithTimeout, event); err != nil {
+			n.logger.Error("failed to send notification", log.String("sink", sink.Name()), log.String("type", event.Type), log.Error(err))
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,120 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	events []*Event
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Send(ctx context.Context, event *Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func drain(n *Notifier) {
+	n.flush(context.Background())
+}
+
+func TestNotifier_Deduplicates(t *testing.T) {
+	sink := &recordingSink{}
+	n := NewNotifier(helpers.DefaultTestLogger(), Options{NodeAddress: "a328846cd5b4979d68a8c58a9bdfeee657b34de7", DedupPeriod: time.Hour}, sink)
+
+	n.Notify(EVENT_HEALTH_CHANGED, "disk /", SEVERITY_WARNING, "disk usage is higher than 90% (currently at 91.00%)")
+	n.Notify(EVENT_HEALTH_CHANGED, "disk /", SEVERITY_WARNING, "disk usage is higher than 90% (currently at 95.00%)")
+	n.Notify(EVENT_HEALTH_CHANGED, "vchain chain-42", SEVERITY_CRITICAL, "chain-42 is failed")
+	n.Notify(EVENT_HEALTH_CHANGED, "disk /", SEVERITY_INFO, "disk usage is at 50.00%")
+	drain(n)
+
+	require.Len(t, sink.events, 3)
+	require.Equal(t, "disk usage is higher than 90% (currently at 91.00%)", sink.events[0].Message)
+	require.Equal(t, "a328846cd5b4979d68a8c58a9bdfeee657b34de7", sink.events[0].NodeAddress)
+	require.Equal(t, "vchain chain-42", sink.events[1].Subject)
+	require.Equal(t, SEVERITY_INFO, sink.events[2].Severity)
+}
+
+func TestNotifier_RateLimits(t *testing.T) {
+	sink := &recordingSink{}
+	n := NewNotifier(helpers.DefaultTestLogger(), Options{RateLimit: 2, RatePeriod: time.Hour}, sink)
+
+	n.Notify(EVENT_CONFIG_INVALID, "", SEVERITY_WARNING, "first")
+	n.Notify(EVENT_CONFIG_INVALID, "", SEVERITY_WARNING, "second")
+	n.Notify(EVENT_CONFIG_INVALID, "", SEVERITY_WARNING, "third")
+	drain(n)
+
+	require.Len(t, sink.events, 2)
+	require.Equal(t, "second", sink.events[1].Message)
+}
+
+func TestNotifier_SendsQueuedNotificationsOnShutdown(t *testing.T) {
+	sink := &recordingSink{}
+	n := NewNotifier(helpers.DefaultTestLogger(), Options{}, sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n.Notify(EVENT_SELF_UPDATE, "abcd", SEVERITY_INFO, "boyar binary was updated")
+
+	n.Start(ctx).WaitUntilShutdown(context.Background())
+	require.Len(t, sink.events, 1)
+}
+
+func TestNotifier_IsOptional(t *testing.T) {
+	var n *Notifier
+	require.NotPanics(t, func() {
+		n.Notify(EVENT_SELF_UPDATE, "", SEVERITY_INFO, "updated")
+	})
+}
+
+func TestWebhookAndSlackSinks(t *testing.T) {
+	payloads := make(chan map[string]interface{}, 2)
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		payload := make(map[string]interface{})
+		json.NewDecoder(request.Body).Decode(&payload)
+		payloads <- payload
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	event := &Event{Type: EVENT_CONFIG_REVERTED, Subject: "5f1d", Severity: SEVERITY_CRITICAL, Message: "chain-42: non-zero exit (1)", NodeAddress: "a328846c"}
+
+	require.NoError(t, NewWebhookSink(server.Url()+"webhook").Send(context.Background(), event))
+	require.Equal(t, "chain-42: non-zero exit (1)", (<-payloads)["Message"])
+
+	require.NoError(t, NewSlackSink(server.Url()+"slack").Send(context.Background(), event))
+	require.Equal(t, "[CRITICAL] config-reverted 5f1d on node a328846c: chain-42: non-zero exit (1)", (<-payloads)["text"])
+}
+
+func TestScriptSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boyar-notifications")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "output")
+	script := filepath.Join(dir, "notify.sh")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho $BOYAR_EVENT_TYPE > "+output+"\ncat >> "+output+"\n"), 0755))
+
+	event := &Event{Type: EVENT_SELF_UPDATE, Severity: SEVERITY_INFO, Message: "boyar binary was updated"}
+	require.NoError(t, NewScriptSink(script).Send(context.Background(), event))
+
+	written, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	require.Contains(t, string(written), "self-update\n")
+	require.Contains(t, string(written), `"Message":"boyar binary was updated"`)
+
+	require.Error(t, NewScriptSink(filepath.Join(dir, "missing.sh")).Send(context.Background(), event))
+}
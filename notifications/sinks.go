@@ -0,0 +1,122 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Posts the event as is
+type webhookSink struct {
+	url string
+}
+
+func NewWebhookSink(url string) Sink {
+	return &webhookSink{url: url}
+}
+
+func (s *webhookSink) Name() string {
+	return "webhook " + s.url
+}
+
+func (s *webhookSink) Send(ctx context.Context, event *Event) error {
+	return postJSON(ctx, s.url, event)
+}
+
+// Slack incoming webhooks (and compatible services) only take text
+type slackSink struct {
+	url string
+}
+
+func NewSlackSink(url string) Sink {
+	return &slackSink{url: url}
+}
+
+func (s *slackSink) Name() string {
+	return "slack " + s.url
+}
+
+func (s *slackSink) Send(ctx context.Context, event *Event) error {
+	return postJSON(ctx, s.url, map[string]string{
+		"text": formatText(event),
+	})
+}
+
+func formatText(event *Event) string {
+	text := fmt.Sprintf("[%s] %s", strings.ToUpper(event.Severity), event.Type)
+	if event.Subject != "" {
+		text += " " + event.Subject
+	}
+
+	if event.NodeAddress != "" {
+		text += " on node " + event.NodeAddress
+	}
+
+	return text + ": " + event.Message
+}
+
+func postJSON(ctx context.Context, url string, value interface{}) error {
+	rawJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(rawJSON))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned with status %s", response.Status)
+	}
+
+	return nil
+}
+
+// Runs a local executable with the event in json on stdin and in environment variables
+type scriptSink struct {
+	path string
+}
+
+func NewScriptSink(path string) Sink {
+	return &scriptSink{path: path}
+}
+
+func (s *scriptSink) Name() string {
+	return "script " + s.path
+}
+
+func (s *scriptSink) Send(ctx context.Context, event *Event) error {
+	rawJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, s.path)
+	cmd.Stdin = bytes.NewReader(rawJSON)
+	cmd.Env = append(os.Environ(),
+		"BOYAR_EVENT_TYPE="+event.Type,
+		"BOYAR_EVENT_SUBJECT="+event.Subject,
+		"BOYAR_EVENT_SEVERITY="+event.Severity,
+		"BOYAR_EVENT_MESSAGE="+event.Message,
+		"BOYAR_NODE_ADDRESS="+event.NodeAddress,
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package recovery
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/orbs-network/boyarin/utils"
+)
+
+const (
+	JOURNAL_FILE_NAME        = "journal.log"
+	DEFAULT_JOURNAL_MAX_SIZE = 10 * 1024 * 1024
+	DEFAULT_JOURNAL_ENTRIES  = 10
+	DEFAULT_MAX_OUTPUT_SIZE  = 64 * 1024
+)
+
+// single recovery run, stored as a json line
+type JournalEntry struct {
+	Timestamp       time.Time `json:"timestamp"`
+	InstructionHash string    `json:"instructionHash"`
+	ExitCode        int       `json:"exitCode"`
+	DurationMs      int64     `json:"durationMs"`
+	Stdout          string    `json:"stdout"`
+	Stderr          string    `json:"stderr"`
+	Truncated       bool      `json:"truncated"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// append only log, the last entries are also kept in memory for status
+type Journal struct {
+	lines      *utils.JsonLinesLog
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries []*JournalEntry
+}
+
+func NewJournal(path string, maxSize int64, maxEntries int) *Journal {
+	j := &Journal{
+		lines:      utils.NewJsonLinesLog(path, maxSize),
+		maxEntries: maxEntries,
+	}
+
+	// restore history from previous runs, an unreadable journal only means there is none
+	j.lines.Scan(func(line []byte) {
+		entry := &JournalEntry{}
+		// skip partially written lines
+		if json.Unmarshal(line, entry) == nil {
+			j.remember(entry)
+		}
+	})
+
+	return j
+}
+
+func (j *Journal) remember(entry *JournalEntry) {
+	j.entries = append(j.entries, entry)
+	if len(j.entries) > j.maxEntries {
+		j.entries = j.entries[len(j.entries)-j.maxEntries:]
+	}
+}
+
+func (j *Journal) Record(entry *JournalEntry) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.remember(entry)
+	return j.lines.Append(entry)
+}
+
+// newest entries last
+func (j *Journal) LastEntries() []*JournalEntry {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return append([]*JournalEntry{}, j.entries...)
+}
+
+// keeps only the tail of the output, which is usually where the error is
+type cappedBuffer struct {
+	mutex     sync.Mutex
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+		b.truncated = true
+	}
+
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return string(b.buf)
+}
@@ -0,0 +1,45 @@
+package recovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal_RotatesBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boyar-journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, JOURNAL_FILE_NAME)
+	journal := NewJournal(path, 512, 3)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, journal.Record(&JournalEntry{
+			Timestamp: time.Now(),
+			ExitCode:  i,
+			Stdout:    "some output that takes space in the journal",
+		}))
+	}
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.True(t, info.Size() <= 512, "journal should be rotated")
+
+	_, err = os.Stat(path + utils.ROTATED_SUFFIX)
+	require.NoError(t, err, "rotated journal should be kept")
+
+	entries := journal.LastEntries()
+	require.Len(t, entries, 3)
+	require.Equal(t, 9, entries[2].ExitCode)
+
+	restored := NewJournal(path, 512, 3).LastEntries()
+	require.Len(t, restored, 3)
+	require.Equal(t, 7, restored[0].ExitCode)
+	require.Equal(t, 9, restored[2].ExitCode)
+}
@@ -11,8 +11,10 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/orbs-network/boyarin/crypto"
 	"github.com/orbs-network/scribe/log"
 )
 
@@ -21,10 +23,19 @@ const (
 	e_no_bash_prefix  = "e_no_bash_prefix"
 	e_no_code_or_args = "e_no_code_or_args"
 	e_json_no_binary  = "e_json_no_binary"
+	e_bin_not_allowed = "e_bin_not_allowed"
+	e_no_trusted_keys = "e_no_trusted_keys"
+	e_no_expiry       = "e_no_expiry"
+	e_stdin_no_hash   = "e_stdin_no_hash"
 	//e_content_not_changed = "e_content_not_changed"
 	DDMMYYYYhhmmss = "2006-01-02 15:04:05"
+
+	// detached signature is expected next to the instructions, stdins are covered by their hashes
+	SIGNATURE_SUFFIX = ".sig"
 )
 
+var DEFAULT_ALLOWED_BINS = []string{"/bin/bash"}
+
 /////////////////////////////////////////////////
 // INSTRUCTIONS JSON
 // {
@@ -34,7 +45,13 @@ const (
 //     "stdins": [
 //         "https://raw.githubusercontent.com/amihaz/staging-deployment/main/boyar_recovery/shared/disk_cleanup_1.sh",
 //         "https://raw.githubusercontent.com/amihaz/staging-deployment/main/boyar_recovery/shared/docker_cleanup_1.sh"
-//     ]
+//     ],
+//     "stdinSha256": [
+//         "<sha256 of disk_cleanup_1.sh>",
+//         "<sha256 of docker_cleanup_1.sh>"
+//     ],
+//     "nodeAddress": "9f0988cd37f14dfe95d44cf21f9987526d6147ba",
+//     "expiresAt": "2021-03-01T00:00:00Z"
 // }
 
 ///////////////////////////////////////////////
@@ -43,22 +60,39 @@ type Instructions struct {
 	Args   []string `json:"args"`
 	Dir    string   `json:dir`
 	Stdins []string `json:"stdins"`
+
+	// signed together with the instructions, so that they can not be mixed with other stdins or replayed
+	StdinHashes []string  `json:"stdinSha256"`
+	NodeAddress string    `json:"nodeAddress"` // optional
+	ExpiresAt   time.Time `json:"expiresAt"`
 }
 
 type Config struct {
 	IntervalMinute uint
 	TimeoutMinute  uint
 	Url            string
+	NodeAddress    string
+	TrustedKeys    []string
+	AllowedBins    []string
+
+	JournalPath          string
+	JournalMaxSizeBytes  int64
+	JournalStatusEntries int
+	MaxOutputBytes       int
 }
 
 type Recovery struct {
-	config     Config
-	ticker     *time.Ticker
-	tickCount  uint32
-	lastTick   time.Time
-	lastExec   time.Time
-	lastOutput string
-	lastError  string
+	config      Config
+	trustedKeys []*crypto.PublicKey
+	journal     *Journal
+	ticker      *time.Ticker
+	tickCount   uint32
+	lastTick    time.Time
+	lastExec    time.Time
+	lastOutput  string
+	lastError   string
+
+	failedTickCount uint32
 }
 
 /////////////////////////////////////////////////
@@ -77,7 +111,28 @@ func Init(c Config, _logger log.Logger) {
 	if c.IntervalMinute == 0 {
 		c.IntervalMinute = 60 * 6
 	}
-	single = &Recovery{config: c, tickCount: 0}
+	if len(c.AllowedBins) == 0 {
+		c.AllowedBins = DEFAULT_ALLOWED_BINS
+	}
+	if c.JournalPath == "" {
+		c.JournalPath = getWDPath() + JOURNAL_FILE_NAME
+	}
+	if c.JournalMaxSizeBytes == 0 {
+		c.JournalMaxSizeBytes = DEFAULT_JOURNAL_MAX_SIZE
+	}
+	if c.JournalStatusEntries == 0 {
+		c.JournalStatusEntries = DEFAULT_JOURNAL_ENTRIES
+	}
+	if c.MaxOutputBytes == 0 {
+		c.MaxOutputBytes = DEFAULT_MAX_OUTPUT_SIZE
+	}
+	// invalid keys are not trusted, nothing will be executed until they are fixed
+	trustedKeys, err := crypto.ParsePublicKeys(c.TrustedKeys)
+	if err != nil {
+		logger.Error("recovery - failed to parse trusted keys", log.Error(err))
+	}
+	journal := NewJournal(c.JournalPath, c.JournalMaxSizeBytes, c.JournalStatusEntries)
+	single = &Recovery{config: c, trustedKeys: trustedKeys, journal: journal, tickCount: 0}
 }
 
 //GetInstanceA - get singleton instance pre-initialized
@@ -144,6 +199,40 @@ func (r *Recovery) readUrl(url string) (string, error) {
 	return body.String(), nil
 }
 
+/////////////////////////////////////////////////////////////
+// reads url content and verifies its detached signature against trusted keys
+func (r *Recovery) readSignedUrl(url string) (string, error) {
+	if len(r.trustedKeys) == 0 {
+		return "", errors.New(e_no_trusted_keys)
+	}
+
+	content, err := r.readUrl(url)
+	if err != nil {
+		return "", err
+	}
+
+	signatures, err := r.readUrl(url + SIGNATURE_SUFFIX)
+	if err != nil {
+		return "", fmt.Errorf("could not read signature of %s: %s", url, err)
+	}
+
+	if _, err := crypto.VerifyDetachedSignature(r.trustedKeys, []byte(content), []byte(signatures)); err != nil {
+		return "", fmt.Errorf("invalid signature of %s: %s", url, err)
+	}
+
+	return content, nil
+}
+
+/////////////////////////////////////////////////////////////
+func (r *Recovery) isBinAllowed(bin string) bool {
+	for _, allowed := range r.config.AllowedBins {
+		if bin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
 /////////////////////////////////////////////////////////////
 func getWDPath() string {
 	cwd, err := os.Getwd()
@@ -156,31 +245,40 @@ func getWDPath() string {
 /////////////////////////////////////////////////////////////
 func (r *Recovery) tick() {
 	logger.Info("Recovery tick")
-	r.tickCount += 1
+	atomic.AddUint32(&r.tickCount, 1)
 	r.lastTick = time.Now()
 
+	if err := r.runInstructions(); err != nil {
+		atomic.AddUint32(&r.failedTickCount, 1)
+		r.lastError = err.Error()
+		logger.Error(r.lastError)
+	}
+}
+
+func (r *Recovery) runInstructions() error {
 	// read json
-	jsnTxt, err := r.readUrl(r.config.Url) //, getWDPath())
+	jsnTxt, err := r.readSignedUrl(r.config.Url) //, getWDPath())
 	if err != nil {
-		r.lastError = err.Error()
-		logger.Error(err.Error())
-		return
+		return err
 	}
 
 	// read JSON
 	var inst Instructions
 	err = json.Unmarshal([]byte(jsnTxt), &inst)
 	if err != nil {
-		r.lastError = err.Error()
-		logger.Error(err.Error())
-		return
+		return err
 	}
 
 	// mandatory
 	if len(inst.Bin) == 0 {
-		r.lastError = e_json_no_binary
-		logger.Error(r.lastError)
-		return
+		return errors.New(e_json_no_binary)
+	}
+	if !r.isBinAllowed(inst.Bin) {
+		logger.Info("recovery instructions use an executable that is not allowed", log.String("bin", inst.Bin))
+		return errors.New(e_bin_not_allowed)
+	}
+	if err := r.checkValidity(&inst, time.Now()); err != nil {
+		return err
 	}
 	// optional - if no std in, args may be executed
 	if len(inst.Stdins) == 0 {
@@ -188,23 +286,64 @@ func (r *Recovery) tick() {
 	}
 	// read all code
 	fullCode := ""
-	for _, url := range inst.Stdins {
+	for i, url := range inst.Stdins {
 		// append code
 		code, err := r.readUrl(url)
 		if err != nil {
-			r.lastError = err.Error()
-			logger.Error(err.Error())
-			return
-		} else {
-			fullCode += code + "\n"
+			return err
+		}
+		if hash := crypto.CalculateHash([]byte(code)); hash != strings.ToLower(inst.StdinHashes[i]) {
+			return fmt.Errorf("sha256 of %s is %s instead of %s", url, hash, inst.StdinHashes[i])
 		}
+		fullCode += code + "\n"
 	}
 
 	// execute all with timeout
-	err = r.runCommand(inst.Bin, inst.Dir, fullCode, inst.Args)
-	if err != nil {
-		r.lastError = err.Error()
-		logger.Error(r.lastError)
+	return r.runCommand(inst.Bin, inst.Dir, fullCode, inst.Args)
+}
+
+// Signed instructions are bound to their stdins, and to the node and the time they were meant for
+func (r *Recovery) checkValidity(inst *Instructions, now time.Time) error {
+	if len(inst.StdinHashes) != len(inst.Stdins) {
+		return errors.New(e_stdin_no_hash)
+	}
+	if inst.ExpiresAt.IsZero() {
+		return errors.New(e_no_expiry)
+	}
+	if now.After(inst.ExpiresAt) {
+		return fmt.Errorf("recovery instructions expired at %s", inst.ExpiresAt.Format(time.RFC3339))
+	}
+	if inst.NodeAddress != "" && normalizeAddress(inst.NodeAddress) != normalizeAddress(r.config.NodeAddress) {
+		return fmt.Errorf("recovery instructions are for node %s", inst.NodeAddress)
+	}
+	return nil
+}
+
+func normalizeAddress(address string) string {
+	return strings.TrimPrefix(strings.ToLower(address), "0x")
+}
+
+// Number of ticks since start and how many of them failed
+func (r *Recovery) TickCount() (uint32, uint32) {
+	return atomic.LoadUint32(&r.tickCount), atomic.LoadUint32(&r.failedTickCount)
+}
+
+// Missing instructions are the normal state of a node, any other failure of the last tick is not.
+// Recovery without trusted keys is disabled rather than failing, unless the keys are provided but invalid.
+// The message explains a healthy state that is not obvious, otherwise it is the last error.
+func (r *Recovery) Healthy() (bool, string) {
+	if len(r.trustedKeys) == 0 {
+		if len(r.config.TrustedKeys) > 0 {
+			return false, "recovery is disabled, --recovery-trusted-keys are invalid"
+		}
+		return true, "recovery is disabled, --recovery-trusted-keys is empty"
+	}
+
+	switch r.lastError {
+	case "", fmt.Sprintf("status: %d", http.StatusNotFound):
+		return true, ""
+	default:
+		return false, r.lastError
 	}
 }
 
@@ -240,30 +379,71 @@ func (r *Recovery) runCommand(bin, dir, code string, args []string) error {
 
 	// stdin code execution
 	if code != "" {
-		stdin, err := cmd.StdinPipe()
-		if err != nil {
-			return err
-		}
-
-		// stream code stdin
-		go func() {
-			defer stdin.Close()
-			io.WriteString(stdin, code)
-		}()
+		cmd.Stdin = strings.NewReader(code)
 	}
 
-	out, err := cmd.CombinedOutput()
+	// output is kept on failure too, that is when it is needed the most
+	stdout := &cappedBuffer{max: r.config.MaxOutputBytes}
+	stderr := &cappedBuffer{max: r.config.MaxOutputBytes}
+	combined := &cappedBuffer{max: r.config.MaxOutputBytes}
+	cmd.Stdout = io.MultiWriter(stdout, combined)
+	cmd.Stderr = io.MultiWriter(stderr, combined)
+
+	err := cmd.Run()
 	// context error such timeout
 	if ctx.Err() != nil {
-		return ctx.Err()
+		err = ctx.Err()
 	}
-	// cmd error
-	if err != nil {
-		return err
+
+	r.lastOutput = combined.String()
+	r.record(&JournalEntry{
+		Timestamp:       r.lastExec,
+		InstructionHash: getInstructionHash(bin, dir, code, args),
+		ExitCode:        getExitCode(cmd),
+		DurationMs:      time.Since(r.lastExec).Milliseconds(),
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		Truncated:       stdout.truncated || stderr.truncated,
+		Error:           errorString(err),
+	})
+
+	return err
+}
+
+/////////////////////////////////////////////////
+func (r *Recovery) record(entry *JournalEntry) {
+	if r.journal == nil {
+		return
+	}
+	if err := r.journal.Record(entry); err != nil {
+		logger.Error("recovery - failed to write journal", log.Error(err))
 	}
+}
 
-	r.lastOutput = string(out)
-	return nil
+// identifies what exactly was executed
+func getInstructionHash(bin, dir, code string, args []string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"bin":  bin,
+		"dir":  dir,
+		"code": code,
+		"args": args,
+	})
+	return crypto.CalculateHash(data)
+}
+
+// -1 if the process did not exit on its own (failed to start or killed on timeout)
+func getExitCode(cmd *exec.Cmd) int {
+	if cmd.ProcessState == nil {
+		return -1
+	}
+	return cmd.ProcessState.ExitCode()
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
 
 /////////////////////////////////////////////////
@@ -275,12 +455,17 @@ func (r *Recovery) Status() interface{} {
 		return map[string]interface{}{
 			"intervalMinute": r.config.IntervalMinute,
 			"url":            r.config.Url,
+			"trustedKeys":    len(r.trustedKeys),
+			"allowedBins":    r.config.AllowedBins,
 			"tickCount":      "before first tick",
+			"journal":        r.journalEntries(),
 		}
 	}
 	return map[string]interface{}{
 		"intervalMinute":    r.config.IntervalMinute,
 		"url":               r.config.Url,
+		"trustedKeys":       len(r.trustedKeys),
+		"allowedBins":       r.config.AllowedBins,
 		"tickCount":         r.tickCount,
 		"lastTick":          r.lastTick,
 		"nextTickTime":      nextTickTime.Format(DDMMYYYYhhmmss),
@@ -288,5 +473,13 @@ func (r *Recovery) Status() interface{} {
 		"lastOutput":        r.lastOutput,
 		"lastError":         r.lastError,
 		"execTimeoutMinute": r.config.TimeoutMinute,
+		"journal":           r.journalEntries(),
+	}
+}
+
+func (r *Recovery) journalEntries() []*JournalEntry {
+	if r.journal == nil {
+		return nil
 	}
+	return r.journal.LastEntries()
 }
@@ -1,12 +1,113 @@
 package recovery
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/orbs-network/boyarin/crypto"
+	"github.com/orbs-network/boyarin/test/helpers"
 	"github.com/orbs-network/scribe/log"
 )
 
+const RECOVERY_TEST_NODE_ADDRESS = "9f0988cd37f14dfe95d44cf21f9987526d6147ba"
+
+// serves recovery files from memory, signing them with a freshly generated key unless told otherwise
+type recoveryServer struct {
+	server      helpers.HttpServer
+	files       map[string]string
+	publicKey   ed25519.PublicKey
+	privateKey  ed25519.PrivateKey
+	journalPath string
+}
+
+func newRecoveryServer() *recoveryServer {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	journalDir, _ := ioutil.TempDir("", "boyar-recovery")
+	s := &recoveryServer{
+		files:       make(map[string]string),
+		publicKey:   publicKey,
+		privateKey:  privateKey,
+		journalPath: filepath.Join(journalDir, JOURNAL_FILE_NAME),
+	}
+
+	s.server = helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		content, found := s.files[request.URL.Path]
+		if !found {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writer.Write([]byte(content))
+	})
+	s.server.Start()
+
+	return s
+}
+
+func (s *recoveryServer) close() {
+	s.server.Shutdown()
+	os.RemoveAll(filepath.Dir(s.journalPath))
+}
+
+func (s *recoveryServer) url(path string) string {
+	return s.server.Url() + strings.TrimPrefix(path, "/")
+}
+
+func (s *recoveryServer) addUnsigned(path string, content string) string {
+	s.files[path] = content
+	return s.url(path)
+}
+
+func (s *recoveryServer) add(path string, content string) string {
+	s.files[path+SIGNATURE_SUFFIX] = hex.EncodeToString(ed25519.Sign(s.privateKey, []byte(content)))
+	return s.addUnsigned(path, content)
+}
+
+// signed instructions for the test node that are valid for an hour, with the hashes of the stdins served at the moment
+func (s *recoveryServer) addInstructions(path string, inst Instructions) string {
+	for _, url := range inst.Stdins {
+		inst.StdinHashes = append(inst.StdinHashes, crypto.CalculateHash([]byte(s.files["/"+strings.TrimPrefix(url, s.server.Url())])))
+	}
+	if inst.NodeAddress == "" {
+		inst.NodeAddress = RECOVERY_TEST_NODE_ADDRESS
+	}
+	if inst.ExpiresAt.IsZero() {
+		inst.ExpiresAt = time.Now().Add(time.Hour)
+	}
+
+	data, _ := json.Marshal(inst)
+	return s.add(path, string(data))
+}
+
+func (s *recoveryServer) trustedKeys() []string {
+	return []string{"ed25519:" + hex.EncodeToString(s.publicKey)}
+}
+
+func tickWithServer(s *recoveryServer, url string) *Recovery {
+	config := Config{
+		IntervalMinute: 1,
+		Url:            url,
+		NodeAddress:    "0x" + RECOVERY_TEST_NODE_ADDRESS,
+		TrustedKeys:    s.trustedKeys(),
+		JournalPath:    s.journalPath,
+	}
+
+	logger = log.GetLogger()
+	Init(config, logger)
+
+	r := GetInstance()
+	r.tick()
+	return r
+}
+
 func Test_RecoveryConfigSingleton(t *testing.T) {
 
 	// init recovery config
@@ -54,19 +155,13 @@ func Test_Recovery404(t *testing.T) {
 }
 
 func Test_RecoveryJsonHappy(t *testing.T) {
-	url := "https://raw.githubusercontent.com/amihaz/staging-deployment/main/boyar_recovery/node/0xTEST/main.json"
+	s := newRecoveryServer()
+	defer s.close()
 
-	// init recovery config
-	config := Config{
-		IntervalMinute: 1,
-		Url:            url,
-	}
-
-	logger = log.GetLogger()
-	Init(config, logger)
+	script := s.add("/shared/identical.sh", "echo identical")
+	url := s.addInstructions("/node/0xTEST/main.json", Instructions{Bin: "/bin/bash", Stdins: []string{script, script, script}})
 
-	r := GetInstance()
-	r.tick()
+	r := tickWithServer(s, url)
 
 	expect := "identical\nidentical\nidentical\n"
 	if r.lastOutput != expect {
@@ -76,19 +171,10 @@ func Test_RecoveryJsonHappy(t *testing.T) {
 }
 
 func Test_RecoveryEmptyJson(t *testing.T) {
-	url := "https://raw.githubusercontent.com/amihaz/staging-deployment/main/boyar_recovery/node/0xTEST/empty.json"
+	s := newRecoveryServer()
+	defer s.close()
 
-	// init recovery config
-	config := Config{
-		IntervalMinute: 1,
-		Url:            url,
-	}
-
-	logger = log.GetLogger()
-	Init(config, logger)
-
-	r := GetInstance()
-	r.tick()
+	r := tickWithServer(s, s.add("/node/0xTEST/empty.json", "{}"))
 
 	if r.lastError != e_json_no_binary {
 		t.Errorf("expect:\n%s got:\n%s", e_json_no_binary, r.lastError)
@@ -96,32 +182,146 @@ func Test_RecoveryEmptyJson(t *testing.T) {
 }
 
 func Test_RecoveryJsonInvalid(t *testing.T) {
-	url := "https://raw.githubusercontent.com/amihaz/staging-deployment/main/boyar_recovery/node/0xTEST/invalid.json"
+	s := newRecoveryServer()
+	defer s.close()
 
-	// init recovery config
-	config := Config{
-		IntervalMinute: 1,
-		Url:            url,
+	r := tickWithServer(s, s.add("/node/0xTEST/invalid.json", "{bin: /bin/bash}"))
+
+	e := "invalid character"
+	if r.lastError[:len(e)] != e {
+		t.Errorf("expect:\n%s got:\n%s", e, r.lastError)
+	}
+}
+
+func Test_RecoveryUnsignedJson(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	r := tickWithServer(s, s.addUnsigned("/node/0xTEST/main.json", `{"bin": "/bin/bash", "args": ["-c", "echo unsigned"]}`))
+
+	e := "could not read signature"
+	if !strings.HasPrefix(r.lastError, e) || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e, r.lastError)
+	}
+}
+
+func Test_RecoveryInvalidSignature(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	url := s.add("/node/0xTEST/main.json", `{"bin": "/bin/bash", "args": ["-c", "echo signed"]}`)
+	s.files["/node/0xTEST/main.json"] = `{"bin": "/bin/bash", "args": ["-c", "echo tampered"]}`
+
+	r := tickWithServer(s, url)
+
+	e := "invalid signature"
+	if !strings.HasPrefix(r.lastError, e) || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e, r.lastError)
+	}
+}
+
+func Test_RecoveryTamperedStdin(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	first := s.add("/shared/first.sh", "echo first")
+	second := s.add("/shared/second.sh", "echo second")
+	url := s.addInstructions("/node/0xTEST/main.json", Instructions{Bin: "/bin/bash", Stdins: []string{first, second}})
+	s.files["/shared/second.sh"] = "echo tampered"
+
+	r := tickWithServer(s, url)
+
+	e := "sha256 of " + second + " is " + crypto.CalculateHash([]byte("echo tampered"))
+	if !strings.HasPrefix(r.lastError, e) || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e, r.lastError)
+	}
+}
+
+func Test_RecoveryStdinWithoutHash(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	script := s.add("/shared/script.sh", "echo signed")
+	data, _ := json.Marshal(Instructions{Bin: "/bin/bash", Stdins: []string{script}, ExpiresAt: time.Now().Add(time.Hour)})
+	r := tickWithServer(s, s.add("/node/0xTEST/main.json", string(data)))
+
+	if r.lastError != e_stdin_no_hash || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e_stdin_no_hash, r.lastError)
+	}
+}
+
+func Test_RecoveryReplay(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	data, _ := json.Marshal(Instructions{Bin: "/bin/bash", Args: []string{"-c", "echo forever"}})
+	r := tickWithServer(s, s.add("/node/0xTEST/main.json", string(data)))
+	if r.lastError != e_no_expiry || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e_no_expiry, r.lastError)
+	}
+
+	expiresAt := time.Now().Add(-time.Minute)
+	r = tickWithServer(s, s.addInstructions("/node/0xTEST/expired.json", Instructions{Bin: "/bin/bash", Args: []string{"-c", "echo expired"}, ExpiresAt: expiresAt}))
+	if e := "recovery instructions expired at " + expiresAt.Format(time.RFC3339); r.lastError != e || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e, r.lastError)
+	}
+
+	otherNode := "0xa328846cd5b4979d68a8c58a9bdfeee657b34de7"
+	r = tickWithServer(s, s.addInstructions("/node/0xOTHER/main.json", Instructions{Bin: "/bin/bash", Args: []string{"-c", "echo other node"}, NodeAddress: otherNode}))
+	if e := "recovery instructions are for node " + otherNode; r.lastError != e || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e, r.lastError)
 	}
+}
+
+func Test_RecoveryBinNotAllowed(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	r := tickWithServer(s, s.add("/node/0xTEST/main.json", `{"bin": "/usr/bin/env", "args": ["echo", "not allowed"]}`))
+
+	if r.lastError != e_bin_not_allowed || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e_bin_not_allowed, r.lastError)
+	}
+}
+
+func Test_RecoveryNoTrustedKeys(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
 
 	logger = log.GetLogger()
-	Init(config, logger)
+	Init(Config{
+		IntervalMinute: 1,
+		Url:            s.add("/node/0xTEST/main.json", `{"bin": "/bin/bash", "args": ["-c", "echo no keys"]}`),
+		JournalPath:    s.journalPath,
+	}, logger)
 
 	r := GetInstance()
 	r.tick()
 
-	e := "invalid character"
-	if r.lastError[:len(e)] != e {
-		t.Errorf("expect:\n%s got:\n%s", e, r.lastError)
+	if r.lastError != e_no_trusted_keys || r.lastOutput != "" {
+		t.Errorf("expect:\n%s got:\n%s", e_no_trusted_keys, r.lastError)
+	}
+
+	if healthy, message := r.Healthy(); !healthy || message != "recovery is disabled, --recovery-trusted-keys is empty" {
+		t.Errorf("recovery that is not configured should be reported as disabled, got:\n%s", message)
+	}
+
+	Init(Config{IntervalMinute: 1, TrustedKeys: []string{"ed25519:invalid"}, JournalPath: s.journalPath}, logger)
+	if healthy, _ := GetInstance().Healthy(); healthy {
+		t.Errorf("recovery with invalid trusted keys should not be reported as healthy")
 	}
 }
 
 func Test_RecoveryTimeout(t *testing.T) {
+	journalDir, _ := ioutil.TempDir("", "boyar-recovery")
+	defer os.RemoveAll(journalDir)
+
 	// init recovery config
 	config := Config{
 		IntervalMinute: 5,
 		TimeoutMinute:  1,
 		Url:            "",
+		JournalPath:    filepath.Join(journalDir, JOURNAL_FILE_NAME),
 	}
 	logger = log.GetLogger()
 	Init(config, logger)
@@ -137,23 +337,66 @@ func Test_RecoveryTimeout(t *testing.T) {
 }
 
 func Test_RecoveryStderr(t *testing.T) {
-	url := "https://raw.githubusercontent.com/amihaz/staging-deployment/main/boyar_recovery/node/0xTEST/stderr.json"
+	s := newRecoveryServer()
+	defer s.close()
 
-	// init recovery config
-	config := Config{
-		IntervalMinute: 1,
-		Url:            url,
+	script := s.add("/shared/stderr.sh", "echo write_stderr >&2")
+	r := tickWithServer(s, s.addInstructions("/node/0xTEST/stderr.json", Instructions{Bin: "/bin/bash", Stdins: []string{script}}))
+
+	e := "write_stderr"
+	if r.lastOutput[:len(e)] != e {
+		t.Errorf("expect:\n%s got:\n%s", e, r.lastOutput)
 	}
+}
+
+func Test_RecoveryJournalCapturesFailedRun(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	script := s.add("/shared/failure.sh", "echo before failure\necho failure details >&2\nexit 3")
+	r := tickWithServer(s, s.addInstructions("/node/0xTEST/main.json", Instructions{Bin: "/bin/bash", Stdins: []string{script}}))
+
+	if r.lastError != "exit status 3" {
+		t.Errorf("expect:\n%s got:\n%s", "exit status 3", r.lastError)
+	}
+	if r.lastOutput != "before failure\nfailure details\n" {
+		t.Errorf("output of failed run was not captured, got:\n%s", r.lastOutput)
+	}
+
+	entries := r.Status().(map[string]interface{})["journal"].([]*JournalEntry)
+	if len(entries) != 1 {
+		t.Fatalf("expected a single journal entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ExitCode != 3 || entry.Stdout != "before failure\n" || entry.Stderr != "failure details\n" || entry.InstructionHash == "" {
+		t.Errorf("unexpected journal entry %+v", entry)
+	}
+
+	// journal survives restarts
+	restored := NewJournal(s.journalPath, DEFAULT_JOURNAL_MAX_SIZE, DEFAULT_JOURNAL_ENTRIES).LastEntries()
+	if len(restored) != 1 || restored[0].InstructionHash != entry.InstructionHash {
+		t.Errorf("journal was not restored from disk, got %+v", restored)
+	}
+}
+
+func Test_RecoveryOutputIsTruncated(t *testing.T) {
+	journalDir, _ := ioutil.TempDir("", "boyar-recovery")
+	defer os.RemoveAll(journalDir)
 
 	logger = log.GetLogger()
-	Init(config, logger)
+	Init(Config{
+		JournalPath:    filepath.Join(journalDir, JOURNAL_FILE_NAME),
+		MaxOutputBytes: 4,
+	}, logger)
 
 	r := GetInstance()
-	r.tick()
+	if err := r.runCommand("/bin/bash", "", "", []string{"-c", "echo 1234567890"}); err != nil {
+		t.Error(err)
+	}
 
-	e := "write_stderr"
-	if r.lastOutput[:len(e)] != e {
-		t.Errorf("expect:\n%s got:\n%s", e, r.lastOutput)
+	entry := r.journal.LastEntries()[0]
+	if entry.Stdout != "890\n" || !entry.Truncated {
+		t.Errorf("expected truncated output, got %+v", entry)
 	}
 }
 
@@ -170,3 +413,18 @@ func Test_RecoveryStderr(t *testing.T) {
 // 		t.Errorf("error is not timeout: %s", err.Error())
 // 	}
 // }
+
+func Test_RecoveryHealthy(t *testing.T) {
+	s := newRecoveryServer()
+	defer s.close()
+
+	r := tickWithServer(s, s.url("/node/0xTEST/main.json"))
+	if healthy, lastError := r.Healthy(); !healthy {
+		t.Errorf("missing instructions should be healthy, got:\n%s", lastError)
+	}
+
+	r = tickWithServer(s, s.add("/node/0xTEST/empty.json", "{}"))
+	if healthy, lastError := r.Healthy(); healthy || lastError != e_json_no_binary {
+		t.Errorf("expect:\n%s got:\n%s", e_json_no_binary, lastError)
+	}
+}
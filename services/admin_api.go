@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/scribe/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const ADMIN_API_RESTART_DELAY = 5 * time.Second
+const ADMIN_API_SHUTDOWN_TIMEOUT = 5 * time.Second
+
+const REDACTED = "REDACTED"
+
+var sensitiveConfigKeys = regexp.MustCompile(`(?i)(private|secret|password|token)`)
+
+func ServeAdminAPI(ctx context.Context, logger log.Logger, flags *config.Flags, state *DaemonState) govnr.ShutdownWaiter {
+	errorHandler := utils.NewLogErrors("admin api", logger)
+	handler := NewAdminAPIHandler(state)
+
+	return govnr.Forever(ctx, "admin api", errorHandler, func() {
+		server := &http.Server{
+			Addr:    flags.AdminListen,
+			Handler: handler,
+		}
+
+		errors := make(chan error, 1)
+		go func() {
+			logger.Info("admin api is listening", log.String("address", flags.AdminListen))
+			errors <- server.ListenAndServe()
+		}()
+
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), ADMIN_API_SHUTDOWN_TIMEOUT)
+			defer cancel()
+
+			server.Shutdown(shutdownCtx)
+		case err := <-errors:
+			logger.Error("admin api failed", log.Error(err))
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(ADMIN_API_RESTART_DELAY):
+			}
+		}
+	})
+}
+
+func NewAdminAPIHandler(state *DaemonState) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", func(writer http.ResponseWriter, request *http.Request) {
+		status := state.Status()
+		if status == nil {
+			writeJSON(writer, http.StatusServiceUnavailable, map[string]string{"Error": "status was not reported yet"})
+			return
+		}
+
+		writeJSON(writer, http.StatusOK, status)
+	})
+
+	mux.Handle("/metrics", promhttp.HandlerFor(state.Registry(), promhttp.HandlerOpts{}))
+
+	mux.HandleFunc("/config", func(writer http.ResponseWriter, request *http.Request) {
+		cfg := state.Config()
+		if cfg == nil {
+			writeJSON(writer, http.StatusServiceUnavailable, map[string]string{"Error": "no valid configuration was received yet"})
+			return
+		}
+
+		writeJSON(writer, http.StatusOK, getRedactedConfig(cfg))
+	})
+
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, request *http.Request) {
+		if applied, err := state.ConfigApplied(); !applied {
+			message := "configuration was not applied yet"
+			if err != nil {
+				message = err.Error()
+			}
+
+			writeJSON(writer, http.StatusServiceUnavailable, map[string]interface{}{"Healthy": false, "Error": message})
+			return
+		}
+
+		writeJSON(writer, http.StatusOK, map[string]interface{}{"Healthy": true})
+	})
+
+	return mux
+}
+
+func writeJSON(writer http.ResponseWriter, statusCode int, value interface{}) {
+	rawJSON, _ := json.MarshalIndent(value, "", "  ")
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	writer.Write(rawJSON)
+}
+
+// Key pair never leaves the node, and neither do passwords or tokens that could be passed to the services
+func getRedactedConfig(cfg config.NodeConfiguration) interface{} {
+	rawJSON, _ := json.Marshal(map[string]interface{}{
+		"NodeAddress":  cfg.NodeAddress(),
+		"Hash":         cfg.Hash(),
+		"orchestrator": cfg.OrchestratorOptions(),
+		"network":      cfg.FederationNodes(),
+		"chains":       cfg.Chains(),
+		"services":     cfg.Services(),
+	})
+
+	var value interface{}
+	json.Unmarshal(rawJSON, &value)
+
+	var secrets []string
+	if keys, err := config.NewKeysConfig(cfg.KeyConfigPath()); err == nil && keys.PrivateKey() != "" {
+		secrets = append(secrets, strings.TrimPrefix(keys.PrivateKey(), "0x"))
+	}
+
+	return redact(value, secrets)
+}
+
+// Booleans and numbers are flags and ports even under sensitive keys (InjectNodePrivateKey),
+// secrets could also be copied under any other key, so they are looked for in every string
+func redact(value interface{}, secrets []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if sensitiveConfigKeys.MatchString(key) && couldBeSecret(nested) {
+				v[key] = REDACTED
+			} else {
+				v[key] = redact(nested, secrets)
+			}
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redact(nested, secrets)
+		}
+	case string:
+		for _, secret := range secrets {
+			if strings.Contains(strings.ToLower(v), strings.ToLower(secret)) {
+				return REDACTED
+			}
+		}
+	}
+
+	return value
+}
+
+func couldBeSecret(value interface{}) bool {
+	switch value.(type) {
+	case bool, float64, nil:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func getAdminAPI(t *testing.T, handler http.Handler, path string) (int, string) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+
+	body, err := ioutil.ReadAll(recorder.Result().Body)
+	require.NoError(t, err)
+
+	return recorder.Code, string(body)
+}
+
+func TestAdminAPI_BeforeFirstReport(t *testing.T) {
+	handler := NewAdminAPIHandler(NewDaemonState())
+
+	for _, path := range []string{"/status", "/config", "/healthz"} {
+		code, _ := getAdminAPI(t, handler, path)
+		require.EqualValues(t, http.StatusServiceUnavailable, code, path)
+	}
+
+	code, _ := getAdminAPI(t, handler, "/metrics")
+	require.EqualValues(t, http.StatusOK, code)
+}
+
+func TestAdminAPI_ServesState(t *testing.T) {
+	state := NewDaemonState()
+	handler := NewAdminAPIHandler(state)
+
+	state.SetStatus(StatusResponse{Status: "OK"})
+	code, body := getAdminAPI(t, handler, "/status")
+	require.EqualValues(t, http.StatusOK, code)
+
+	status := StatusResponse{}
+	require.NoError(t, json.Unmarshal([]byte(body), &status))
+	require.EqualValues(t, "OK", status.Status)
+
+	promauto.With(state.Registry()).NewGauge(prometheus.GaugeOpts{Name: "boyar_test_gauge"}).Set(42)
+
+	_, body = getAdminAPI(t, handler, "/metrics")
+	require.Contains(t, body, "boyar_test_gauge 42")
+
+	state.SetConfigApplied(fmt.Errorf("unbearable catastrophe"))
+	code, body = getAdminAPI(t, handler, "/healthz")
+	require.EqualValues(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, "unbearable catastrophe")
+
+	state.SetConfigApplied(nil)
+	code, _ = getAdminAPI(t, handler, "/healthz")
+	require.EqualValues(t, http.StatusOK, code)
+}
+
+func TestAdminAPI_RedactsConfig(t *testing.T) {
+	data, err := ioutil.ReadFile("../boyar/config/test/configWithSigner.json")
+	require.NoError(t, err)
+
+	cfg, err := config.NewStringConfigurationSource(string(data), "", "../boyar/config/test/fake-key-pair.json", false)
+	require.NoError(t, err)
+	cfg.Services()["custom"] = &config.Service{
+		Config: map[string]interface{}{
+			"Nested": map[string]interface{}{
+				"ApiToken": "do-not-show",
+			},
+			"NodePrivateKey": "do-not-show",
+			"Port":           8080,
+			"Copy":           "0xc30bf9e301a19c319818b34a75901fd8f067b676a834eeb4169ec887dd03d2a8",
+		},
+		InjectNodePrivateKey: true,
+	}
+
+	state := NewDaemonState()
+	state.SetConfig(cfg)
+
+	code, body := getAdminAPI(t, NewAdminAPIHandler(state), "/config")
+	require.EqualValues(t, http.StatusOK, code)
+	require.NotContains(t, body, "do-not-show")
+	require.Contains(t, body, REDACTED)
+	require.Contains(t, body, `"Port": 8080`)
+	require.Contains(t, body, `"InjectNodePrivateKey": true`)
+	require.NotContains(t, body, "c30bf9e301a19c319818b34a75901fd8f067b676a834eeb4169ec887dd03d2a8")
+	require.Contains(t, body, string(cfg.NodeAddress()))
+}
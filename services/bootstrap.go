@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"github.com/orbs-network/boyarin/audit"
 	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/scribe/log"
 	"io/ioutil"
@@ -11,19 +12,55 @@ import (
 func Bootstrap(ctx context.Context, flags *config.Flags, logger log.Logger) (*config.Flags, error) {
 	logger.Info("bootstrapping from management config", log.String("config", flags.ManagementConfig))
 
-	data, err := ioutil.ReadFile(flags.ManagementConfig)
+	cfg, err := readManagementConfig(flags)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg, err := config.NewStringConfigurationSource(string(data), flags.EthereumEndpoint, flags.KeyPairConfigPath, flags.WithNamespace)
+	newFlags := getManagementFlags(flags, cfg)
+
+	coreBoyar := NewCoreBoyarService(logger)
+	coreBoyar.auditLog = audit.NewLog(flags.AuditLogPath, audit.DEFAULT_LOG_MAX_SIZE)
+	if coreBoyar.verifier, err = newImageVerifier(flags); err != nil {
+		return nil, err
+	}
+	if shouldExit := coreBoyar.CheckForUpdates(flags, string(cfg.NodeAddress()), cfg.OrchestratorOptions().ExecutableImage); shouldExit {
+		logger.Info("shutting down after updating boyar binary")
+		return flags, errors.New("restart needed after an update")
+	}
+
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+	WatchAndReportStatusAndMetrics(ctxWithCancel, logger, flags, NewDaemonState())
+
+	return newFlags, coreBoyar.OnConfigChange(ctx, cfg)
+}
+
+func readManagementConfig(flags *config.Flags) (config.NodeConfiguration, error) {
+	data, err := ioutil.ReadFile(flags.ManagementConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	return config.NewStringConfigurationSource(string(data), flags.EthereumEndpoint, flags.KeyPairConfigPath, flags.WithNamespace)
+}
+
+// Flags of the daemon that takes over once the management config is applied
+func getManagementFlags(flags *config.Flags, cfg config.NodeConfiguration) *config.Flags {
+	managementOptions := cfg.OrchestratorOptions().DynamicManagementConfig
+	configSignatureUrl := flags.ConfigSignatureUrl
+	if configSignatureUrl == "" {
+		configSignatureUrl = managementOptions.SignatureUrl
+	}
+
 	// FIXME find a better way to pass the flags
-	newFlags := &config.Flags{
-		ConfigUrl: cfg.OrchestratorOptions().DynamicManagementConfig.Url,
+	return &config.Flags{
+		ConfigUrl: managementOptions.Url,
+
+		ConfigTrustedKeys:  append(append([]string{}, flags.ConfigTrustedKeys...), managementOptions.TrustedKeys...),
+		ConfigSignatureUrl: configSignatureUrl,
+		ConfigSources:      flags.ConfigSources,
+		ConfigCachePath:    flags.ConfigCachePath,
 
 		KeyPairConfigPath: flags.KeyPairConfigPath,
 
@@ -31,6 +68,7 @@ func Bootstrap(ctx context.Context, flags *config.Flags, logger log.Logger) (*co
 		PollingInterval:       flags.PollingInterval,
 		MaxReloadTimeDelay:    flags.MaxReloadTimeDelay,
 		BootstrapResetTimeout: flags.BootstrapResetTimeout,
+		ConfigSettlePeriod:    flags.ConfigSettlePeriod,
 
 		SSLPrivateKeyPath:  flags.SSLPrivateKeyPath,
 		SSLCertificatePath: flags.SSLCertificatePath,
@@ -42,23 +80,29 @@ func Bootstrap(ctx context.Context, flags *config.Flags, logger log.Logger) (*co
 		LogFilePath:     flags.LogFilePath,
 		StatusFilePath:  flags.StatusFilePath,
 		MetricsFilePath: flags.MetricsFilePath,
+		AdminListen:     flags.AdminListen,
+		AuditLogPath:    flags.AuditLogPath,
+
+		ImageTrustedKeys: flags.ImageTrustedKeys,
+
+		HealthMaxCPULoad:    flags.HealthMaxCPULoad,
+		HealthMaxMemoryUsed: flags.HealthMaxMemoryUsed,
+		HealthMaxDiskUsed:   flags.HealthMaxDiskUsed,
+		HealthMaxConfigAge:  flags.HealthMaxConfigAge,
+
+		NotificationWebhooks:      flags.NotificationWebhooks,
+		NotificationSlackWebhooks: flags.NotificationSlackWebhooks,
+		NotificationScript:        flags.NotificationScript,
+		NotificationDedupPeriod:   flags.NotificationDedupPeriod,
+		NotificationRateLimit:     flags.NotificationRateLimit,
 
 		WithNamespace: flags.WithNamespace,
 
 		AutoUpdate:          flags.AutoUpdate,
 		ShutdownAfterUpdate: flags.AutoUpdate,
 		BoyarBinaryPath:     flags.BoyarBinaryPath,
-	}
 
-	coreBoyar := NewCoreBoyarService(logger)
-	if shouldExit := coreBoyar.CheckForUpdates(flags, cfg.OrchestratorOptions().ExecutableImage); shouldExit {
-		logger.Info("shutting down after updating boyar binary")
-		return flags, errors.New("restart needed after an update")
+		UpdateTrustedKeys:     flags.UpdateTrustedKeys,
+		UpdateRollbackTimeout: flags.UpdateRollbackTimeout,
 	}
-
-	ctxWithCancel, cancel := context.WithCancel(ctx)
-	defer cancel()
-	WatchAndReportStatusAndMetrics(ctxWithCancel, logger, flags)
-
-	return newFlags, coreBoyar.OnConfigChange(ctx, cfg)
 }
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"time"
+
+	"github.com/orbs-network/boyarin/recovery"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	METRIC_RESULT_SUCCESS   = "success"
+	METRIC_RESULT_FAILURE   = "failure"
+	METRIC_RESULT_UNCHANGED = "unchanged"
+)
+
+const (
+	PHASE_RECONCILE = "reconcile"
+	PHASE_SERVICES  = "services"
+	PHASE_VCHAINS   = "vchains"
+	PHASE_NGINX     = "nginx"
+	PHASE_SETTLE    = "settle"
+)
+
+// Durations of provisioning range from milliseconds (nothing changed) to minutes (pulling images)
+var durationBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600}
+
+// What Boyar itself is doing, as opposed to the host and the containers it runs; nil outside of the daemon
+type BoyarMetrics struct {
+	configPolls          *prometheus.CounterVec
+	configApplyDuration  *prometheus.HistogramVec
+	imagePullDuration    *prometheus.HistogramVec
+	selfUpdateAttempts   *prometheus.CounterVec
+	bootstrapResetRemain prometheus.Gauge
+}
+
+func NewBoyarMetrics(registry *prometheus.Registry) *BoyarMetrics {
+	factory := promauto.With(registry)
+
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name:        "boyar_recovery_ticks_total",
+		ConstLabels: prometheus.Labels{"result": METRIC_RESULT_SUCCESS},
+	}, func() float64 {
+		ticks, failures := getRecoveryTickCount()
+		return float64(ticks - failures)
+	})
+
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name:        "boyar_recovery_ticks_total",
+		ConstLabels: prometheus.Labels{"result": METRIC_RESULT_FAILURE},
+	}, func() float64 {
+		_, failures := getRecoveryTickCount()
+		return float64(failures)
+	})
+
+	return &BoyarMetrics{
+		configPolls: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "boyar_config_polls_total",
+		}, []string{"result"}),
+		configApplyDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "boyar_config_apply_duration_seconds",
+			Buckets: durationBuckets,
+		}, []string{"phase", "result"}),
+		imagePullDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "boyar_image_pull_duration_seconds",
+			Buckets: durationBuckets,
+		}, []string{"result"}),
+		selfUpdateAttempts: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "boyar_self_update_attempts_total",
+		}, []string{"result"}),
+		bootstrapResetRemain: factory.NewGauge(prometheus.GaugeOpts{
+			Name: "boyar_bootstrap_reset_seconds_remaining",
+		}),
+	}
+}
+
+func getRecoveryTickCount() (uint32, uint32) {
+	if r := recovery.GetInstance(); r != nil {
+		return r.TickCount()
+	}
+
+	return 0, 0
+}
+
+func resultOf(err error) string {
+	if err != nil {
+		return METRIC_RESULT_FAILURE
+	}
+
+	return METRIC_RESULT_SUCCESS
+}
+
+func (m *BoyarMetrics) ConfigPolled(result string) {
+	if m == nil {
+		return
+	}
+
+	m.configPolls.WithLabelValues(result).Inc()
+}
+
+func (m *BoyarMetrics) PhaseCompleted(phase string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	m.configApplyDuration.WithLabelValues(phase, resultOf(err)).Observe(time.Since(start).Seconds())
+}
+
+func (m *BoyarMetrics) ImagePulled(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	m.imagePullDuration.WithLabelValues(resultOf(err)).Observe(time.Since(start).Seconds())
+}
+
+func (m *BoyarMetrics) SelfUpdateAttempted(err error) {
+	if m == nil {
+		return
+	}
+
+	m.selfUpdateAttempts.WithLabelValues(resultOf(err)).Inc()
+}
+
+// Negative values are not reported, zero means the reset is disabled or imminent
+func (m *BoyarMetrics) SetBootstrapResetRemaining(remaining time.Duration) {
+	if m == nil {
+		return
+	}
+
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	m.bootstrapResetRemain.Set(remaining.Seconds())
+}
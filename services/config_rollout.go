@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/orbs-network/boyarin/strelets/adapter"
+)
+
+const CONFIG_SETTLE_POLL_INTERVAL = 5 * time.Second
+const ROLLOUT_STATE_SUFFIX = ".rollout"
+
+// Kept next to the config cache, so that a restart neither forgets the quarantine nor skips the settle watch,
+// and a configuration that fails to settle after a restart can still be reverted
+type rolloutState struct {
+	LastGoodHash    string          `json:"lastGoodHash,omitempty"`
+	LastGoodConfig  json.RawMessage `json:"lastGoodConfig,omitempty"`
+	QuarantinedHash string          `json:"quarantinedHash,omitempty"`
+}
+
+// Missing state is empty
+func readRolloutState(path string) (*rolloutState, error) {
+	state := &rolloutState{}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("could not parse rollout state: %s", err)
+	}
+
+	return state, nil
+}
+
+// Written separately and moved, so that a crash does not leave a broken state
+func writeRolloutState(path string, state *rolloutState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// Remembers which services were (re)started while applying the configuration, measures image pulls
+type touchingOrchestrator struct {
+	adapter.Orchestrator
+	touched map[string]bool
+	metrics *BoyarMetrics
+}
+
+func newTouchingOrchestrator(orchestrator adapter.Orchestrator, metrics *BoyarMetrics) *touchingOrchestrator {
+	return &touchingOrchestrator{
+		Orchestrator: orchestrator,
+		touched:      make(map[string]bool),
+		metrics:      metrics,
+	}
+}
+
+func (o *touchingOrchestrator) PullImage(ctx context.Context, imageName string) error {
+	start := time.Now()
+	err := o.Orchestrator.PullImage(ctx, imageName)
+	o.metrics.ImagePulled(start, err)
+	return err
+}
+
+func (o *touchingOrchestrator) RunVirtualChain(ctx context.Context, serviceConfig *adapter.ServiceConfig, appConfig *adapter.AppConfig) error {
+	o.touched[serviceConfig.ContainerName] = true
+	return o.Orchestrator.RunVirtualChain(ctx, serviceConfig, appConfig)
+}
+
+func (o *touchingOrchestrator) RunService(ctx context.Context, serviceConfig *adapter.ServiceConfig, appConfig *adapter.AppConfig) error {
+	o.touched[serviceConfig.ContainerName] = true
+	return o.Orchestrator.RunService(ctx, serviceConfig, appConfig)
+}
+
+func (o *touchingOrchestrator) RunReverseProxy(ctx context.Context, config *adapter.ReverseProxyConfig) error {
+	o.touched[config.ContainerName] = true
+	return o.Orchestrator.RunReverseProxy(ctx, config)
+}
+
+// Returns errors of the tasks of touched services that were created after the change.
+// Docker reports creation time in seconds, hence the truncation.
+func getFailedTasks(statuses []*adapter.ContainerStatus, touched map[string]bool, since time.Time) []string {
+	since = since.Truncate(time.Second)
+
+	var failures []string
+	for _, status := range statuses {
+		if !touched[status.Name] || status.Error == "" || status.CreatedAt.Before(since) {
+			continue
+		}
+
+		failures = append(failures, status.Name+": "+status.Error)
+	}
+
+	sort.Strings(failures)
+	return failures
+}
+
+// Polls the tasks of touched services until the settle period is over, fails on the first failed task
+func watchTouchedServices(ctx context.Context, orchestrator adapter.Orchestrator, touched map[string]bool, since time.Time, settlePeriod time.Duration, pollInterval time.Duration) error {
+	deadline := since.Add(settlePeriod)
+
+	for {
+		statuses, err := orchestrator.GetStatus(ctx, pollInterval)
+		if err != nil {
+			return fmt.Errorf("could not check the status of updated services: %s", err)
+		}
+
+		if failures := getFailedTasks(statuses, touched, since); len(failures) > 0 {
+			return fmt.Errorf("updated services failed: %s", strings.Join(failures, "; "))
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil
+		} else if wait > pollInterval {
+			wait = pollInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("could not wait for updated services to settle: %s", ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+)
+
+type statusOrchestrator struct {
+	*adapter.RecordingOrchestrator
+	statuses []*adapter.ContainerStatus
+}
+
+func (o *statusOrchestrator) GetStatus(ctx context.Context, since time.Duration) ([]*adapter.ContainerStatus, error) {
+	return o.statuses, nil
+}
+
+func readTestConfig(t *testing.T, path string) config.NodeConfiguration {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	cfg, err := config.NewStringConfigurationSource(string(data), "", "../boyar/config/test/fake-key-pair.json", false)
+	require.NoError(t, err)
+
+	return cfg
+}
+
+func TestGetFailedTasks(t *testing.T) {
+	since := time.Now()
+	statuses := []*adapter.ContainerStatus{
+		{Name: "chain-42", Error: "non-zero exit (1)", CreatedAt: since.Add(time.Second)},
+		{Name: "chain-42", Error: "non-zero exit (137)", CreatedAt: since.Add(-time.Hour)},
+		{Name: "chain-1991", Error: "non-zero exit (1)", CreatedAt: since.Add(time.Second)},
+		{Name: "signer", CreatedAt: since.Add(time.Second)},
+	}
+
+	failures := getFailedTasks(statuses, map[string]bool{"chain-42": true, "signer": true}, since)
+	require.Equal(t, []string{"chain-42: non-zero exit (1)"}, failures)
+}
+
+func TestOnConfigChange_RevertsAndQuarantinesConfigThatFailsToSettle(t *testing.T) {
+	good := readTestConfig(t, "../boyar/config/test/configWithSingleChain.json")
+	bad := readTestConfig(t, "../boyar/config/test/configWithActiveVchains.json")
+	fixed := readTestConfig(t, "../boyar/config/test/configWithSigner.json")
+
+	var statuses []*adapter.ContainerStatus
+	var orchestrators []*statusOrchestrator
+
+	coreBoyar := NewCoreBoyarService(helpers.DefaultTestLogger())
+	coreBoyar.settlePeriod = 50 * time.Millisecond
+	coreBoyar.newOrchestrator = func(options *adapter.OrchestratorOptions, logger log.Logger) (adapter.Orchestrator, error) {
+		orchestrator := &statusOrchestrator{RecordingOrchestrator: adapter.NewRecordingOrchestrator(nil), statuses: statuses}
+		orchestrators = append(orchestrators, orchestrator)
+		return orchestrator, nil
+	}
+
+	ctx := context.Background()
+	require.NoError(t, coreBoyar.OnConfigChange(ctx, good))
+
+	statuses = []*adapter.ContainerStatus{
+		{Name: "chain-1991", Error: "non-zero exit (1)", CreatedAt: time.Now().Add(time.Hour)},
+	}
+
+	err := coreBoyar.OnConfigChange(ctx, bad)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "chain-1991: non-zero exit (1)")
+	require.Equal(t, bad.Hash(), coreBoyar.quarantinedHash)
+	require.False(t, coreBoyar.healthy)
+
+	reverted := make(map[string]string)
+	for _, change := range orchestrators[len(orchestrators)-1].Changes() {
+		reverted[change.Name] = change.Action
+	}
+	require.Equal(t, adapter.PLAN_CREATE, reverted["chain-42"], "should revert to the last good configuration")
+	require.NotContains(t, reverted, "chain-1991")
+
+	err = coreBoyar.OnConfigChange(ctx, bad)
+	require.EqualError(t, err, "configuration "+bad.Hash()+" is quarantined, running the last good configuration "+good.Hash())
+
+	statuses = nil
+	require.NoError(t, coreBoyar.OnConfigChange(ctx, fixed))
+	require.Empty(t, coreBoyar.quarantinedHash)
+	require.Equal(t, fixed.Hash(), coreBoyar.lastGoodConfig.Hash())
+}
+
+func TestOnConfigChange_KeepsQuarantineAfterRestart(t *testing.T) {
+	good := readTestConfig(t, "../boyar/config/test/configWithSingleChain.json")
+	bad := readTestConfig(t, "../boyar/config/test/configWithActiveVchains.json")
+
+	dir, err := ioutil.TempDir("", "rollout-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	flags := &config.Flags{
+		ConfigCachePath:   filepath.Join(dir, "config.json"),
+		KeyPairConfigPath: "../boyar/config/test/fake-key-pair.json",
+	}
+
+	var statuses []*adapter.ContainerStatus
+	applied := 0
+	restart := func() *BoyarService {
+		coreBoyar := NewCoreBoyarService(helpers.DefaultTestLogger())
+		coreBoyar.settlePeriod = 50 * time.Millisecond
+		coreBoyar.newOrchestrator = func(options *adapter.OrchestratorOptions, logger log.Logger) (adapter.Orchestrator, error) {
+			applied++
+			return &statusOrchestrator{RecordingOrchestrator: adapter.NewRecordingOrchestrator(nil), statuses: statuses}, nil
+		}
+		require.NoError(t, coreBoyar.RestoreRolloutState(flags))
+		return coreBoyar
+	}
+
+	ctx := context.Background()
+	coreBoyar := restart()
+	require.NoError(t, coreBoyar.OnConfigChange(ctx, good))
+
+	statuses = []*adapter.ContainerStatus{
+		{Name: "chain-1991", Error: "non-zero exit (1)", CreatedAt: time.Now().Add(time.Hour)},
+	}
+	require.Error(t, coreBoyar.OnConfigChange(ctx, bad))
+
+	// the last good configuration is still enforced
+	coreBoyar = restart()
+	require.Equal(t, good.Hash(), coreBoyar.lastGoodConfig.Hash())
+	applied = 0
+	require.EqualError(t, coreBoyar.OnConfigChange(ctx, bad), "configuration "+bad.Hash()+" is quarantined, running the last good configuration "+good.Hash())
+	require.Equal(t, 1, applied)
+	require.False(t, coreBoyar.healthy)
+
+	// the first configuration after a restart is watched unless it is the last good one
+	coreBoyar = restart()
+	coreBoyar.quarantinedHash = ""
+	err = coreBoyar.OnConfigChange(ctx, bad)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "chain-1991: non-zero exit (1)")
+	require.Equal(t, bad.Hash(), coreBoyar.quarantinedHash)
+
+	state, err := readRolloutState(flags.ConfigCachePath + ROLLOUT_STATE_SUFFIX)
+	require.NoError(t, err)
+	require.Equal(t, good.Hash(), state.LastGoodHash)
+	require.Equal(t, bad.Hash(), state.QuarantinedHash)
+
+	// state saved without the last good configuration leaves whatever was reverted to running
+	require.NoError(t, writeRolloutState(flags.ConfigCachePath+ROLLOUT_STATE_SUFFIX, &rolloutState{LastGoodHash: good.Hash(), QuarantinedHash: bad.Hash()}))
+	coreBoyar = restart()
+	applied = 0
+	require.EqualError(t, coreBoyar.OnConfigChange(ctx, bad), "configuration "+bad.Hash()+" is quarantined, keeping the running services")
+	require.Zero(t, applied)
+}
+
+func TestOnConfigChange_RevertsAfterRestart(t *testing.T) {
+	good := readTestConfig(t, "../boyar/config/test/configWithSingleChain.json")
+	bad := readTestConfig(t, "../boyar/config/test/configWithActiveVchains.json")
+
+	dir, err := ioutil.TempDir("", "rollout-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	flags := &config.Flags{
+		ConfigCachePath:   filepath.Join(dir, "config.json"),
+		KeyPairConfigPath: "../boyar/config/test/fake-key-pair.json",
+	}
+
+	var statuses []*adapter.ContainerStatus
+	var orchestrators []*statusOrchestrator
+	restart := func() *BoyarService {
+		coreBoyar := NewCoreBoyarService(helpers.DefaultTestLogger())
+		coreBoyar.settlePeriod = 50 * time.Millisecond
+		coreBoyar.newOrchestrator = func(options *adapter.OrchestratorOptions, logger log.Logger) (adapter.Orchestrator, error) {
+			orchestrator := &statusOrchestrator{RecordingOrchestrator: adapter.NewRecordingOrchestrator(nil), statuses: statuses}
+			orchestrators = append(orchestrators, orchestrator)
+			return orchestrator, nil
+		}
+		require.NoError(t, coreBoyar.RestoreRolloutState(flags))
+		return coreBoyar
+	}
+
+	ctx := context.Background()
+	require.NoError(t, restart().OnConfigChange(ctx, good))
+
+	coreBoyar := restart()
+	statuses = []*adapter.ContainerStatus{
+		{Name: "chain-1991", Error: "non-zero exit (1)", CreatedAt: time.Now().Add(time.Hour)},
+	}
+	err = coreBoyar.OnConfigChange(ctx, bad)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "chain-1991: non-zero exit (1)")
+	require.Equal(t, bad.Hash(), coreBoyar.quarantinedHash)
+
+	reverted := make(map[string]string)
+	for _, change := range orchestrators[len(orchestrators)-1].Changes() {
+		reverted[change.Name] = change.Action
+	}
+	require.Equal(t, adapter.PLAN_CREATE, reverted["chain-42"], "should revert to the last good configuration saved before restart")
+	require.NotContains(t, reverted, "chain-1991")
+}
@@ -2,10 +2,14 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/orbs-network/boyarin/audit"
 	"github.com/orbs-network/boyarin/boyar"
 	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/cosign"
+	"github.com/orbs-network/boyarin/notifications"
 	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/utils"
 	"github.com/orbs-network/scribe/log"
@@ -15,37 +19,206 @@ type BoyarService struct {
 	cache   *boyar.Cache
 	logger  log.Logger
 	healthy bool
+
+	// services touched by the new configuration are watched for the settle period, disabled if zero
+	settlePeriod    time.Duration
+	newOrchestrator func(options *adapter.OrchestratorOptions, logger log.Logger) (adapter.Orchestrator, error)
+
+	metrics  *BoyarMetrics
+	notifier *notifications.Notifier
+	auditLog *audit.Log
+	verifier *cosign.Verifier
+
+	// survive a restart in the rollout state, except for the quarantined configuration itself
+	lastGoodConfig   config.NodeConfiguration
+	lastGoodHash     string
+	quarantinedHash  string
+	rolloutStatePath string
 }
 
 func NewCoreBoyarService(logger log.Logger) *BoyarService {
 	return &BoyarService{
-		cache:  boyar.NewCache(),
-		logger: logger,
+		cache:           boyar.NewCache(),
+		logger:          logger,
+		newOrchestrator: adapter.NewOrchestrator,
 	}
 }
 
-func (coreBoyar *BoyarService) OnConfigChange(ctx context.Context, cfg config.NodeConfiguration) error {
+// Returns nil if image verification is disabled
+func newImageVerifier(flags *config.Flags) (*cosign.Verifier, error) {
+	if len(flags.ImageTrustedKeys) == 0 {
+		return nil, nil
+	}
+
+	keys, err := cosign.LoadPublicKeys(flags.ImageTrustedKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image trusted keys: %s", err)
+	}
+
+	return cosign.NewVerifier(keys), nil
+}
+
+// Persists the rollout state next to the config cache, disabled if the path is empty
+func (coreBoyar *BoyarService) RestoreRolloutState(flags *config.Flags) error {
+	if flags.ConfigCachePath == "" {
+		return nil
+	}
 
-	orchestrator, err := adapter.NewDockerSwarm(cfg.OrchestratorOptions(), coreBoyar.logger)
+	coreBoyar.rolloutStatePath = flags.ConfigCachePath + ROLLOUT_STATE_SUFFIX
+	state, err := readRolloutState(coreBoyar.rolloutStatePath)
 	if err != nil {
 		return err
 	}
-	defer orchestrator.Close()
 
-	b := boyar.NewBoyar(orchestrator, cfg, coreBoyar.cache, coreBoyar.logger)
+	coreBoyar.lastGoodHash = state.LastGoodHash
+	coreBoyar.quarantinedHash = state.QuarantinedHash
 
-	var errors []error
+	// state saved by older versions only has the hashes
+	if len(state.LastGoodConfig) == 0 {
+		return nil
+	}
+
+	lastGoodConfig, err := config.ParseSavedConfiguration(flags, state.LastGoodConfig)
+	if err != nil {
+		return err
+	}
+
+	// flags that override the configuration changed since it was saved
+	if lastGoodConfig.Hash() != state.LastGoodHash {
+		return fmt.Errorf("last good configuration %s does not match its hash %s", lastGoodConfig.Hash(), state.LastGoodHash)
+	}
+
+	coreBoyar.lastGoodConfig = lastGoodConfig
+	return nil
+}
+
+func (coreBoyar *BoyarService) saveRolloutState() {
+	if coreBoyar.rolloutStatePath == "" {
+		return
+	}
+
+	state := &rolloutState{
+		LastGoodHash:    coreBoyar.lastGoodHash,
+		QuarantinedHash: coreBoyar.quarantinedHash,
+	}
 
-	if err := b.ProvisionServices(ctx); err != nil {
-		errors = append(errors, err)
+	if coreBoyar.lastGoodConfig != nil && coreBoyar.lastGoodConfig.Hash() == coreBoyar.lastGoodHash {
+		data, err := config.MarshalNodeConfiguration(coreBoyar.lastGoodConfig)
+		if err != nil {
+			coreBoyar.logger.Error("failed to save last good configuration", log.Error(err))
+		}
+		state.LastGoodConfig = data
 	}
 
-	if err := b.ProvisionVirtualChains(ctx); err != nil {
-		errors = append(errors, err)
+	if err := writeRolloutState(coreBoyar.rolloutStatePath, state); err != nil {
+		coreBoyar.logger.Error("failed to save rollout state", log.Error(err))
 	}
+}
 
-	if err := b.ProvisionHttpAPIEndpoint(ctx); err != nil {
-		errors = append(errors, err)
+// Configuration that failed to settle is reverted to the last good one and quarantined until the source publishes a different one.
+// Every configuration other than the last good one is watched, including the first one after a restart.
+func (coreBoyar *BoyarService) OnConfigChange(ctx context.Context, cfg config.NodeConfiguration) error {
+	if coreBoyar.quarantinedHash != "" && coreBoyar.quarantinedHash != cfg.Hash() {
+		coreBoyar.logger.Info("configuration changed, lifting the quarantine", log.String("quarantinedHash", coreBoyar.quarantinedHash))
+		coreBoyar.quarantinedHash = ""
+		coreBoyar.saveRolloutState()
+	}
+
+	if coreBoyar.quarantinedHash != "" {
+		// quarantined before a restart by a version that did not save the last good configuration
+		if coreBoyar.lastGoodConfig == nil {
+			coreBoyar.healthy = false
+			return fmt.Errorf("configuration %s is quarantined, keeping the running services", cfg.Hash())
+		}
+
+		if err := coreBoyar.apply(ctx, coreBoyar.lastGoodConfig, false); err != nil {
+			return err
+		}
+
+		// not healthy in terms of the desired configuration, the next one should not be delayed
+		coreBoyar.healthy = false
+		return fmt.Errorf("configuration %s is quarantined, running the last good configuration %s", cfg.Hash(), coreBoyar.lastGoodConfig.Hash())
+	}
+
+	canary := coreBoyar.settlePeriod > 0 && coreBoyar.lastGoodHash != cfg.Hash()
+	err := coreBoyar.apply(ctx, cfg, canary)
+	if err == nil {
+		coreBoyar.lastGoodConfig = cfg
+		if coreBoyar.lastGoodHash != cfg.Hash() {
+			coreBoyar.lastGoodHash = cfg.Hash()
+			coreBoyar.saveRolloutState()
+		}
+		return nil
+	}
+
+	if _, failedToSettle := err.(*settleError); !failedToSettle {
+		coreBoyar.notifier.Notify(notifications.EVENT_CONFIG_FAILED, cfg.Hash(), notifications.SEVERITY_WARNING, err.Error())
+		return err
+	}
+
+	coreBoyar.quarantinedHash = cfg.Hash()
+	coreBoyar.saveRolloutState()
+
+	if coreBoyar.lastGoodConfig == nil {
+		coreBoyar.logger.Error("configuration failed to settle, there is no good configuration to revert to", log.Error(err),
+			log.String("quarantinedHash", cfg.Hash()))
+		coreBoyar.notifier.Notify(notifications.EVENT_CONFIG_FAILED, cfg.Hash(), notifications.SEVERITY_CRITICAL,
+			"no good configuration to revert to: "+err.Error())
+		return err
+	}
+
+	coreBoyar.logger.Error("configuration failed to settle, reverting to the last good configuration", log.Error(err),
+		log.String("quarantinedHash", cfg.Hash()), log.String("lastGoodHash", coreBoyar.lastGoodConfig.Hash()))
+
+	if revertErr := coreBoyar.apply(ctx, coreBoyar.lastGoodConfig, false); revertErr != nil {
+		err = utils.AggregateErrors([]error{err, fmt.Errorf("failed to revert configuration: %s", revertErr)})
+		coreBoyar.notifier.Notify(notifications.EVENT_CONFIG_REVERTED, cfg.Hash(), notifications.SEVERITY_CRITICAL, err.Error())
+		return err
+	}
+
+	coreBoyar.notifier.Notify(notifications.EVENT_CONFIG_REVERTED, cfg.Hash(), notifications.SEVERITY_WARNING,
+		fmt.Sprintf("reverted to the last good configuration %s: %s", coreBoyar.lastGoodConfig.Hash(), err))
+
+	coreBoyar.healthy = false
+	return err
+}
+
+type settleError struct {
+	error
+}
+
+func (coreBoyar *BoyarService) apply(ctx context.Context, cfg config.NodeConfiguration, watch bool) error {
+	orchestrator, err := coreBoyar.newOrchestrator(cfg.OrchestratorOptions(), coreBoyar.logger)
+	if err != nil {
+		return err
+	}
+	defer orchestrator.Close()
+
+	// images are verified innermost, so that refusals are recorded in the audit log as failed creates
+	verifyingOrchestrator := cosign.NewVerifyingOrchestrator(orchestrator, coreBoyar.verifier)
+	auditingOrchestrator := audit.NewAuditingOrchestrator(verifyingOrchestrator, coreBoyar.auditLog, cfg.Hash())
+	touchingOrchestrator := newTouchingOrchestrator(auditingOrchestrator, coreBoyar.metrics)
+	b := boyar.NewBoyar(touchingOrchestrator, cfg, coreBoyar.cache, coreBoyar.logger)
+	appliedAt := time.Now()
+
+	var errors []error
+
+	// provisioning relies on the cache, reconciliation invalidates it for services that drifted
+	for _, phase := range []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{PHASE_RECONCILE, b.Reconcile},
+		{PHASE_SERVICES, b.ProvisionServices},
+		{PHASE_VCHAINS, b.ProvisionVirtualChains},
+		{PHASE_NGINX, b.ProvisionHttpAPIEndpoint},
+	} {
+		start := time.Now()
+		err := phase.run(ctx)
+		coreBoyar.metrics.PhaseCompleted(phase.name, start, err)
+		if err != nil {
+			errors = append(errors, err)
+		}
 	}
 
 	if len(errors) > 0 {
@@ -53,6 +226,17 @@ func (coreBoyar *BoyarService) OnConfigChange(ctx context.Context, cfg config.No
 		return utils.AggregateErrors(errors)
 	}
 
+	if watch && len(touchingOrchestrator.touched) > 0 {
+		coreBoyar.logger.Info("watching updated services", log.String("settlePeriod", coreBoyar.settlePeriod.String()))
+		start := time.Now()
+		err := watchTouchedServices(ctx, orchestrator, touchingOrchestrator.touched, appliedAt, coreBoyar.settlePeriod, CONFIG_SETTLE_POLL_INTERVAL)
+		coreBoyar.metrics.PhaseCompleted(PHASE_SETTLE, start, err)
+		if err != nil {
+			coreBoyar.healthy = false
+			return &settleError{err}
+		}
+	}
+
 	coreBoyar.healthy = true
 	return nil
 }
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/notifications"
+	"github.com/orbs-network/boyarin/snapshot"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Shared between the config loop, the status reporter and the admin API
+type DaemonState struct {
+	mutex sync.RWMutex
+
+	status   *StatusResponse
+	registry *prometheus.Registry
+	config   config.NodeConfiguration
+
+	metrics      *PrometheusMetrics
+	boyarMetrics *BoyarMetrics
+
+	configApplied bool
+	lastError     error
+
+	configError      error
+	configReceivedAt time.Time
+	configFetcher    *config.ConfigFetcher
+
+	notifier *notifications.Notifier
+
+	bootstraps *snapshot.Tracker
+}
+
+// Metrics are registered once for the lifetime of the daemon
+func NewDaemonState() *DaemonState {
+	registry := prometheus.NewRegistry()
+
+	return &DaemonState{
+		registry:     registry,
+		metrics:      NewPrometheusMetrics(registry),
+		boyarMetrics: NewBoyarMetrics(registry),
+	}
+}
+
+func (s *DaemonState) Metrics() *PrometheusMetrics {
+	return s.metrics
+}
+
+func (s *DaemonState) BoyarMetrics() *BoyarMetrics {
+	return s.boyarMetrics
+}
+
+func (s *DaemonState) SetStatus(status StatusResponse) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.status = &status
+}
+
+func (s *DaemonState) Status() *StatusResponse {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.status
+}
+
+// Never replaced, the metrics registered with it are updated in place
+func (s *DaemonState) Registry() *prometheus.Registry {
+	return s.registry
+}
+
+func (s *DaemonState) SetConfig(cfg config.NodeConfiguration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.config = cfg
+}
+
+func (s *DaemonState) Config() config.NodeConfiguration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.config
+}
+
+// Records the outcome of the last attempt to apply the configuration
+func (s *DaemonState) SetConfigApplied(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.configApplied = err == nil
+	s.lastError = err
+}
+
+func (s *DaemonState) ConfigApplied() (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.configApplied, s.lastError
+}
+
+// Records the outcome of the last attempt to download and verify the configuration
+func (s *DaemonState) SetConfigError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.configError = err
+	if err == nil {
+		s.configReceivedAt = time.Now()
+	}
+}
+
+// Last time the configuration was downloaded and verified, zero if it never was
+func (s *DaemonState) ConfigReceivedAt() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.configReceivedAt
+}
+
+func (s *DaemonState) ConfigError() error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.configError
+}
+
+func (s *DaemonState) SetConfigFetcher(fetcher *config.ConfigFetcher) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.configFetcher = fetcher
+}
+
+func (s *DaemonState) ConfigFetcher() *config.ConfigFetcher {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.configFetcher
+}
+
+func (s *DaemonState) SetNotifier(notifier *notifications.Notifier) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.notifier = notifier
+}
+
+func (s *DaemonState) Notifier() *notifications.Notifier {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.notifier
+}
+
+func (s *DaemonState) SetBootstraps(bootstraps *snapshot.Tracker) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.bootstraps = bootstraps
+}
+
+func (s *DaemonState) Bootstraps() *snapshot.Tracker {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.bootstraps
+}
@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"fmt"
+	"github.com/orbs-network/boyarin/audit"
 	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/notifications"
 	"github.com/orbs-network/boyarin/utils"
 	"github.com/orbs-network/govnr"
 	"github.com/orbs-network/scribe/log"
@@ -20,6 +22,25 @@ func Execute(ctx context.Context, flags *config.Flags, logger log.Logger) (govnr
 		return nil, fmt.Errorf("--keys is a required parameter for provisioning flow")
 	}
 
+	verifier, err := newImageVerifier(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	// the updated binary keeps restarting without ever applying the configuration
+	var pending *pendingUpdate
+	if flags.UpdateRollbackTimeout > 0 && flags.BoyarBinaryPath != "" {
+		if pending, err = startPendingUpdate(flags.BoyarBinaryPath); err != nil {
+			logger.Error("failed to read pending update", log.Error(err))
+		} else if pending != nil && time.Since(pending.RestartedAt) > flags.UpdateRollbackTimeout {
+			if err := rollbackUpdate(flags.BoyarBinaryPath, pending, audit.NewLog(flags.AuditLogPath, audit.DEFAULT_LOG_MAX_SIZE)); err != nil {
+				return nil, fmt.Errorf("failed to roll back boyar binary: %s", err)
+			}
+
+			return nil, fmt.Errorf("updated boyar binary did not apply the configuration within %s, rolled back to the previous one; restart needed", flags.UpdateRollbackTimeout)
+		}
+	}
+
 	// crucial for a proper shutdown
 	ctxWithCancel, cancelAndExit := context.WithCancel(ctx)
 	supervisor := &govnr.TreeSupervisor{}
@@ -33,15 +54,44 @@ func Execute(ctx context.Context, flags *config.Flags, logger log.Logger) (govnr
 		return nil, fmt.Errorf("invalid configuration: bootstrap reset timeout is less or equal to config polling interval")
 	}
 
-	if flags.StatusFilePath == "" && flags.MetricsFilePath == "" {
-		logger.Info("status file path and metrics file path are empty, periodical report disabled")
+	state := NewDaemonState()
+
+	notifier := newNotifier(flags, logger)
+	if notifier != nil {
+		state.SetNotifier(notifier)
+		supervisor.Supervise(notifier.Start(ctxWithCancel))
+	}
+
+	// health changes are detected by the status reporter
+	if flags.StatusFilePath == "" && flags.MetricsFilePath == "" && flags.AdminListen == "" && notifier == nil {
+		logger.Info("status file path, metrics file path, admin api address and notifications are empty, periodical report disabled")
 	} else {
-		supervisor.Supervise(WatchAndReportStatusAndMetrics(ctxWithCancel, logger, flags))
+		supervisor.Supervise(WatchAndReportStatusAndMetrics(ctxWithCancel, logger, flags, state))
+	}
+
+	if flags.AdminListen != "" {
+		supervisor.Supervise(ServeAdminAPI(ctxWithCancel, logger, flags, state))
 	}
 
 	coreBoyar := NewCoreBoyarService(logger)
+	coreBoyar.settlePeriod = flags.ConfigSettlePeriod
+	if err := coreBoyar.RestoreRolloutState(flags); err != nil {
+		logger.Error("failed to restore rollout state", log.Error(err))
+	}
+	coreBoyar.metrics = state.BoyarMetrics()
+	coreBoyar.notifier = notifier
+	coreBoyar.auditLog = audit.NewLog(flags.AuditLogPath, audit.DEFAULT_LOG_MAX_SIZE)
+	coreBoyar.verifier = verifier
+	state.SetBootstraps(coreBoyar.cache.Bootstraps())
+
+	if pending != nil {
+		supervisor.Supervise(coreBoyar.WatchUpdatedBinary(ctxWithCancel, flags, state, pending, cancelAndExit))
+	}
 	configCache := utils.NewCacheFilter()
 
+	configFetcher := config.NewConfigFetcher(flags.ConfigCachePath)
+	state.SetConfigFetcher(configFetcher)
+
 	configUpdateTimestamp := time.Now()
 
 	// wire cfg and boyar
@@ -55,15 +105,32 @@ func Execute(ctx context.Context, flags *config.Flags, logger log.Logger) (govnr
 		case <-timeout(flags.BootstrapResetTimeout):
 			logger.Error("bootstrap reset timeout reached", log.String("configUpdateTimestamp", configUpdateTimestamp.Format(time.RFC3339)))
 		case <-time.After(flags.PollingInterval):
-			cfg, err = config.GetConfiguration(flags)
+			cfg, err = config.GetConfigurationWithFetcher(flags, configFetcher)
+			state.SetConfigError(err)
 			if err != nil {
+				coreBoyar.metrics.ConfigPolled(METRIC_RESULT_FAILURE)
+				notifier.Notify(notifications.EVENT_CONFIG_INVALID, "", notifications.SEVERITY_WARNING, err.Error())
 				logger.Error("invalid configuration", log.Error(err))
 			} else {
+				if err := configFetcher.Commit(); err != nil {
+					logger.Error("failed to save last known good configuration", log.Error(err))
+				}
+				state.SetConfig(cfg)
 				configUpdateTimestamp = time.Now()
 				logger.Info("last valid configuration timestamp updated", log.String("configUpdateTimestamp", configUpdateTimestamp.Format(time.RFC3339)))
 			}
 		}
 
+		if flags.BootstrapResetTimeout > 0 {
+			remaining := flags.BootstrapResetTimeout - time.Since(configUpdateTimestamp)
+			coreBoyar.metrics.SetBootstrapResetRemaining(remaining)
+
+			if cfg == nil && remaining < flags.BootstrapResetTimeout/BOOTSTRAP_RESET_NOTIFICATION_RATIO {
+				notifier.Notify(notifications.EVENT_BOOTSTRAP_RESET_NEARBY, "", notifications.SEVERITY_CRITICAL,
+					fmt.Sprintf("no valid configuration since %s, shutting down in %s", configUpdateTimestamp.Format(time.RFC3339), remaining.Truncate(time.Second)))
+			}
+		}
+
 		if cfg == nil {
 			if resetInNanos := flags.BootstrapResetTimeout.Nanoseconds(); resetInNanos > 0 && time.Since(configUpdateTimestamp).Nanoseconds() >= resetInNanos {
 				logger.Error(fmt.Sprintf("did not receive new valid configuratin for %s, shutting down", flags.BootstrapResetTimeout))
@@ -73,19 +140,23 @@ func Execute(ctx context.Context, flags *config.Flags, logger log.Logger) (govnr
 			return
 		}
 
-		if !configCache.CheckNewValue(cfg) {
-			logger.Info("configuration has not changed")
-			return
-		}
+		// unchanged configuration is still applied to reconcile it with running services
+		if configCache.CheckNewValue(cfg) {
+			coreBoyar.metrics.ConfigPolled(METRIC_RESULT_SUCCESS)
 
-		// random delay when provisioning change (that is, not bootstrap flow or repairing broken system)
-		if coreBoyar.healthy {
-			maybeDelayConfigUpdate(ctxWithCancel, cfg, flags.MaxReloadTimeDelay, coreBoyar.logger)
+			// random delay when provisioning change (that is, not bootstrap flow or repairing broken system)
+			if coreBoyar.healthy {
+				maybeDelayConfigUpdate(ctxWithCancel, cfg, flags.MaxReloadTimeDelay, coreBoyar.logger)
+			} else {
+				logger.Info("applying new configuration immediately")
+			}
 		} else {
-			logger.Info("applying new configuration immediately")
+			coreBoyar.metrics.ConfigPolled(METRIC_RESULT_UNCHANGED)
+			logger.Info("configuration has not changed, reconciling running services")
 		}
 
-		if shouldExit := coreBoyar.CheckForUpdates(flags, cfg.OrchestratorOptions().ExecutableImage); shouldExit {
+		// checked on every poll, updates postponed by the rollout policy are applied once it allows
+		if shouldExit := coreBoyar.CheckForUpdates(flags, string(cfg.NodeAddress()), cfg.OrchestratorOptions().ExecutableImage); shouldExit {
 			logger.Info("shutting down after updating boyar binary")
 			cancelAndExit()
 			return
@@ -95,6 +166,7 @@ func Execute(ctx context.Context, flags *config.Flags, logger log.Logger) (govnr
 		defer cancel()
 
 		err = coreBoyar.OnConfigChange(ctxWithTimeout, cfg)
+		state.SetConfigApplied(err)
 		if err != nil {
 			logger.Error("error executing configuration", log.Error(err))
 			configCache.Clear()
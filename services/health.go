@@ -0,0 +1,247 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/snapshot"
+)
+
+const (
+	HEALTH_OK       = "ok"
+	HEALTH_WARNING  = "warning"
+	HEALTH_CRITICAL = "critical"
+)
+
+const DEFAULT_MAX_CPU_LOAD = 75
+const DEFAULT_MAX_MEMORY_USED = 75
+const DEFAULT_MAX_DISK_USED = 90
+
+var healthSeverityOrder = map[string]int{
+	HEALTH_OK:       0,
+	HEALTH_WARNING:  1,
+	HEALTH_CRITICAL: 2,
+}
+
+type HealthCheck struct {
+	Name     string
+	Severity string
+	Message  string
+}
+
+// Overall severity is the severity of the worst check
+type HealthReport struct {
+	Severity string
+	Checks   []*HealthCheck
+}
+
+type HealthThresholds struct {
+	MaxCPULoad    float64
+	MaxMemoryUsed float64
+	MaxDiskUsed   float64
+	MaxConfigAge  time.Duration // disabled if zero
+}
+
+func NewHealthReport() *HealthReport {
+	return &HealthReport{
+		Severity: HEALTH_OK,
+	}
+}
+
+func (h *HealthReport) Add(name string, severity string, message string) {
+	h.Checks = append(h.Checks, &HealthCheck{
+		Name:     name,
+		Severity: severity,
+		Message:  message,
+	})
+
+	if healthSeverityOrder[severity] > healthSeverityOrder[h.Severity] {
+		h.Severity = severity
+	}
+}
+
+// Checks that are not ok, the most severe first
+func (h *HealthReport) Problems() (problems []string) {
+	var checks []*HealthCheck
+	for _, check := range h.Checks {
+		if check.Severity != HEALTH_OK {
+			checks = append(checks, check)
+		}
+	}
+
+	sort.SliceStable(checks, func(i, j int) bool {
+		return healthSeverityOrder[checks[i].Severity] > healthSeverityOrder[checks[j].Severity]
+	})
+
+	for _, check := range checks {
+		problems = append(problems, check.Name+": "+check.Message)
+	}
+
+	return
+}
+
+func getHealthThresholds(flags *config.Flags) HealthThresholds {
+	thresholds := HealthThresholds{
+		MaxCPULoad:    flags.HealthMaxCPULoad,
+		MaxMemoryUsed: flags.HealthMaxMemoryUsed,
+		MaxDiskUsed:   flags.HealthMaxDiskUsed,
+		MaxConfigAge:  flags.HealthMaxConfigAge,
+	}
+
+	if thresholds.MaxCPULoad == 0 {
+		thresholds.MaxCPULoad = DEFAULT_MAX_CPU_LOAD
+	}
+
+	if thresholds.MaxMemoryUsed == 0 {
+		thresholds.MaxMemoryUsed = DEFAULT_MAX_MEMORY_USED
+	}
+
+	if thresholds.MaxDiskUsed == 0 {
+		thresholds.MaxDiskUsed = DEFAULT_MAX_DISK_USED
+	}
+
+	return thresholds
+}
+
+func checkDocker(health *HealthReport, err error) {
+	if err != nil {
+		health.Add("docker", HEALTH_CRITICAL, err.Error())
+	} else {
+		health.Add("docker", HEALTH_OK, "docker is reachable")
+	}
+}
+
+func checkResources(health *HealthReport, metrics Metrics, thresholds HealthThresholds) {
+	checkThreshold(health, "cpu", "CPU load", metrics.CPULoadPercent, thresholds.MaxCPULoad)
+	checkThreshold(health, "memory", "memory usage", metrics.MemoryUsedPercent, thresholds.MaxMemoryUsed)
+
+	for _, disk := range metrics.Disks {
+		checkThreshold(health, "disk "+disk.Mountpoint, "disk usage", disk.UsedPercent, thresholds.MaxDiskUsed)
+	}
+}
+
+func checkThreshold(health *HealthReport, name string, description string, value float64, max float64) {
+	if value >= max {
+		health.Add(name, HEALTH_WARNING, fmt.Sprintf("%s is higher than %.0f%% (currently at %.2f%%)", description, max, value))
+	} else {
+		health.Add(name, HEALTH_OK, fmt.Sprintf("%s is at %.2f%%", description, value))
+	}
+}
+
+// Without configuration (querying the status from the command line) every container that is found is expected to run.
+// Signer is not exposed to the host, so its task state is the best indication of it being reachable.
+func checkContainers(health *HealthReport, cfg config.NodeConfiguration, containers []ContainerMetric) {
+	byName := make(map[string]ContainerMetric)
+	for _, container := range containers {
+		byName[container.Name] = container
+	}
+
+	var vchains []string
+	signer := ""
+
+	if cfg != nil {
+		for _, chain := range cfg.Chains() {
+			if !chain.Disabled {
+				vchains = append(vchains, cfg.NamespacedContainerName(chain.GetContainerName()))
+			}
+		}
+
+		if s := cfg.Services().Signer(); s != nil && !s.Disabled {
+			signer = cfg.NamespacedContainerName(config.SIGNER)
+		}
+	} else {
+		for _, container := range containers {
+			if container.VirtualChainId != "" {
+				vchains = append(vchains, container.Name)
+			} else if container.Service == config.SIGNER || strings.HasSuffix(container.Service, "-"+config.SIGNER) {
+				signer = container.Name
+			}
+		}
+	}
+
+	for _, name := range vchains {
+		checkContainer(health, "vchain "+name, name, byName)
+	}
+
+	if signer != "" {
+		checkContainer(health, "signer", signer, byName)
+	}
+}
+
+func checkContainer(health *HealthReport, checkName string, containerName string, containers map[string]ContainerMetric) {
+	container, found := containers[containerName]
+	if !found {
+		health.Add(checkName, HEALTH_CRITICAL, containerName+" is not running")
+	} else if !strings.EqualFold(container.State, "running") {
+		health.Add(checkName, HEALTH_CRITICAL, fmt.Sprintf("%s is %s", containerName, container.State))
+	} else {
+		health.Add(checkName, HEALTH_OK, containerName+" is running")
+	}
+}
+
+// Only a configured recovery that failed is a warning, disabled recovery is reported as such
+func checkRecovery(health *HealthReport, healthy bool, message string) {
+	if !healthy {
+		health.Add("recovery", HEALTH_WARNING, message)
+	} else if message != "" {
+		health.Add("recovery", HEALTH_OK, message)
+	} else {
+		health.Add("recovery", HEALTH_OK, "last recovery run did not fail")
+	}
+}
+
+// Configuration is fresh if it was received recently, even if it did not change
+func checkConfig(health *HealthReport, receivedAt time.Time, configError error, maxAge time.Duration, now time.Time) {
+	if receivedAt.IsZero() {
+		message := "configuration was not received yet"
+		if configError != nil {
+			message = configError.Error()
+		}
+
+		health.Add("config", HEALTH_CRITICAL, message)
+		return
+	}
+
+	age := now.Sub(receivedAt).Truncate(time.Second)
+	if maxAge > 0 && age > maxAge {
+		message := fmt.Sprintf("configuration was last received %s ago", age)
+		if configError != nil {
+			message += ": " + configError.Error()
+		}
+
+		health.Add("config", HEALTH_WARNING, message)
+	} else {
+		health.Add("config", HEALTH_OK, fmt.Sprintf("configuration was received %s ago", age))
+	}
+}
+
+// Chains wait for their bootstrap, so a download in progress is only a warning
+func checkBootstraps(health *HealthReport, bootstraps map[string]snapshot.ProgressStatus) {
+	var names []string
+	for name := range bootstraps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch progress := bootstraps[name]; progress.State {
+		case snapshot.PROGRESS_FAILED:
+			health.Add("bootstrap "+name, HEALTH_WARNING, "failed to bootstrap from "+progress.Source+": "+progress.Error)
+		case snapshot.PROGRESS_PENDING, snapshot.PROGRESS_DOWNLOADING, snapshot.PROGRESS_VERIFYING, snapshot.PROGRESS_UNPACKING:
+			health.Add("bootstrap "+name, HEALTH_WARNING, fmt.Sprintf("bootstrapping from %s, %s", progress.Source, formatProgress(progress)))
+		default:
+			health.Add("bootstrap "+name, HEALTH_OK, progress.State)
+		}
+	}
+}
+
+func formatProgress(progress snapshot.ProgressStatus) string {
+	if progress.TotalBytes > 0 {
+		return fmt.Sprintf("%.2f%% of %dmb", progress.Percent, progress.TotalBytes/1024/1024)
+	}
+
+	return fmt.Sprintf("%dmb", progress.Bytes/1024/1024)
+}
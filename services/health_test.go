@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/snapshot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthReport_RollsUpTheWorstSeverity(t *testing.T) {
+	health := NewHealthReport()
+	require.Equal(t, HEALTH_OK, health.Severity)
+
+	health.Add("docker", HEALTH_OK, "docker is reachable")
+	health.Add("cpu", HEALTH_WARNING, "CPU load is too high")
+	health.Add("vchain chain-42", HEALTH_CRITICAL, "chain-42 is not running")
+	health.Add("memory", HEALTH_OK, "memory usage is at 10.00%")
+
+	require.Equal(t, HEALTH_CRITICAL, health.Severity)
+	require.Equal(t, []string{"vchain chain-42: chain-42 is not running", "cpu: CPU load is too high"}, health.Problems())
+}
+
+func TestReportHealth_DoesNotHideWarnings(t *testing.T) {
+	metrics := Metrics{CPULoadPercent: 80, MemoryUsedPercent: 10, Disks: []DiskMetric{{Mountpoint: "/", UsedPercent: 95}}}
+
+	health := NewHealthReport()
+	checkResources(health, metrics, getHealthThresholds(&config.Flags{HealthMaxCPULoad: 90}))
+	require.Equal(t, HEALTH_WARNING, health.Severity)
+	require.Equal(t, []string{"disk /: disk usage is higher than 90% (currently at 95.00%)"}, health.Problems())
+
+	status := StatusResponse{Status: statusFromMetrics(metrics), Health: health}
+	reportHealth(&status)
+	require.Equal(t, "Warning: disk /: disk usage is higher than 90% (currently at 95.00%)", status.Status)
+	require.Equal(t, "disk /: disk usage is higher than 90% (currently at 95.00%)", status.Error)
+
+	healthy := StatusResponse{Status: statusFromMetrics(metrics), Health: NewHealthReport()}
+	reportHealth(&healthy)
+	require.Regexp(t, "RAM.*CPU.*EFSAccess.*", healthy.Status)
+	require.Empty(t, healthy.Error)
+}
+
+func TestCheckContainers(t *testing.T) {
+	containers := []ContainerMetric{
+		{Name: "chain-42", VirtualChainId: "42", State: "running"},
+		{Name: "chain-1991", VirtualChainId: "1991", State: "failed"},
+		{Name: "signer", Service: "signer", State: "running"},
+	}
+
+	observed := NewHealthReport()
+	checkContainers(observed, nil, containers[:2])
+	require.Equal(t, []string{"vchain chain-1991: chain-1991 is failed"}, observed.Problems())
+
+	cfg := readTestConfig(t, "../boyar/config/test/configWithSigner.json")
+
+	expected := NewHealthReport()
+	checkContainers(expected, cfg, containers[:2])
+	require.Equal(t, HEALTH_CRITICAL, expected.Severity)
+	require.Equal(t, []string{"vchain chain-1991: chain-1991 is failed", "signer: signer is not running"}, expected.Problems())
+
+	healthy := NewHealthReport()
+	checkContainers(healthy, cfg, []ContainerMetric{containers[0], {Name: "chain-1991", State: "running"}, containers[2]})
+	require.Equal(t, HEALTH_OK, healthy.Severity)
+	require.Len(t, healthy.Checks, 3, "disabled chain should not be checked")
+}
+
+func TestCheckConfig(t *testing.T) {
+	now := time.Now()
+
+	missing := NewHealthReport()
+	checkConfig(missing, time.Time{}, fmt.Errorf("management config url returned with status 500"), time.Hour, now)
+	require.Equal(t, []string{"config: management config url returned with status 500"}, missing.Problems())
+	require.Equal(t, HEALTH_CRITICAL, missing.Severity)
+
+	stale := NewHealthReport()
+	checkConfig(stale, now.Add(-2*time.Hour), nil, time.Hour, now)
+	require.Equal(t, []string{"config: configuration was last received 2h0m0s ago"}, stale.Problems())
+
+	unlimited := NewHealthReport()
+	checkConfig(unlimited, now.Add(-2*time.Hour), nil, 0, now)
+	require.Equal(t, HEALTH_OK, unlimited.Severity)
+}
+
+func TestCheckRecovery(t *testing.T) {
+	disabled := NewHealthReport()
+	checkRecovery(disabled, true, "recovery is disabled, --recovery-trusted-keys is empty")
+	require.Equal(t, HEALTH_OK, disabled.Severity)
+	require.Equal(t, "recovery is disabled, --recovery-trusted-keys is empty", disabled.Checks[0].Message)
+
+	failed := NewHealthReport()
+	checkRecovery(failed, false, "exit status 3")
+	require.Equal(t, []string{"recovery: exit status 3"}, failed.Problems())
+	require.Equal(t, HEALTH_WARNING, failed.Severity)
+}
+
+func TestCheckBootstraps(t *testing.T) {
+	health := NewHealthReport()
+	checkBootstraps(health, map[string]snapshot.ProgressStatus{
+		"chain-42":   {Source: "https://peer/chain-42.tar.zst", State: snapshot.PROGRESS_DOWNLOADING, Bytes: 512 * 1024 * 1024, TotalBytes: 2048 * 1024 * 1024, Percent: 25},
+		"chain-1991": {Source: "/backups/chain-1991.tar.zst", State: snapshot.PROGRESS_FAILED, Error: "checksum does not match"},
+		"chain-2020": {Source: "/backups/chain-2020.tar.zst", State: snapshot.PROGRESS_SKIPPED},
+	})
+
+	require.Equal(t, HEALTH_WARNING, health.Severity)
+	require.Equal(t, []string{
+		"bootstrap chain-1991: failed to bootstrap from /backups/chain-1991.tar.zst: checksum does not match",
+		"bootstrap chain-42: bootstrapping from https://peer/chain-42.tar.zst, 25.00% of 2048mb",
+	}, health.Problems())
+}
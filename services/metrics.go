@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/utils"
 	"github.com/orbs-network/scribe/log"
@@ -54,6 +55,21 @@ type ProcessMetric struct {
 	ParentPID        int32
 }
 
+// Either VirtualChainId or Service is set, depending on what the container runs
+type ContainerMetric struct {
+	Name                    string
+	VirtualChainId          string
+	Service                 string
+	State                   string
+	RestartCount            int
+	SecondsSinceStateChange float64
+	CPUPercent              float64
+	MemoryUsedMbytes        float64
+	MemoryLimitMbytes       float64
+	NetworkRxBytes          uint64
+	NetworkTxBytes          uint64
+}
+
 type Metrics struct {
 	BoyarUptime       float64
 	CPULoadPercent    float64
@@ -63,84 +79,170 @@ type Metrics struct {
 	EFSAccessTimeMs   uint64
 	Disks             []DiskMetric
 	Processes         []ProcessMetric
+	Containers        []ContainerMetric
 }
 
+// Registered once, updated after every collection. Metrics with labels are reset on every update,
+// so that disks, processes and containers that are gone are not reported anymore.
 type PrometheusMetrics struct {
-	cpuLoadPercent    prometheus.Gauge
-	memoryUsedPercent prometheus.Gauge
-	memoryUsedMbytes  prometheus.Gauge
-	memoryTotalMbytes prometheus.Gauge
-	efsAccessTimeMs   prometheus.Gauge
+	boyarUptimeSeconds prometheus.Gauge
+	cpuLoadPercent     prometheus.Gauge
+	memoryUsedPercent  prometheus.Gauge
+	memoryUsedMbytes   prometheus.Gauge
+	memoryTotalMbytes  prometheus.Gauge
+	efsAccessTimeMs    prometheus.Gauge
+
+	diskTotalMbytes       *prometheus.GaugeVec
+	diskUsedMbytes        *prometheus.GaugeVec
+	diskUsedPercent       *prometheus.GaugeVec
+	processMemoryUsedMbs  *prometheus.GaugeVec
+	containerCPUPercent   *prometheus.GaugeVec
+	containerMemoryUsed   *prometheus.GaugeVec
+	containerMemoryLimit  *prometheus.GaugeVec
+	containerNetworkRx    *prometheus.GaugeVec
+	containerNetworkTx    *prometheus.GaugeVec
+	containerRestartCount *prometheus.GaugeVec
+	containerStateAge     *prometheus.GaugeVec
+	containerState        *prometheus.GaugeVec
 }
 
-func InitializeAndUpdatePrometheusMetrics(registry *prometheus.Registry, metrics Metrics) {
-	boyarUptimeSeconds := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-		Name: "boyar_uptime_seconds",
-	})
+// vcid alone is not unique, the same chain could run in several namespaces
+var containerLabels = []string{"vcid", "service", "name"}
 
-	boyarUptimeSeconds.Set(metrics.BoyarUptime)
+func NewPrometheusMetrics(registry *prometheus.Registry) *PrometheusMetrics {
+	factory := promauto.With(registry)
 
-	memoryTotalMbytes := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-		Name: "memory_total_mbs",
-	})
-	memoryUsedMbytes := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-		Name: "memory_used_mbs",
-	})
-	memoryUsedPercent := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-		Name: "memory_used_percent",
-	})
-	cpuLoadPercent := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-		Name: "cpu_load_percent",
-	})
-	efsAccessTimeMs := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-		Name: "efs_access_time_ms",
-	})
+	newGauge := func(name string) prometheus.Gauge {
+		return factory.NewGauge(prometheus.GaugeOpts{Name: name})
+	}
+
+	newGaugeVec := func(name string, labels ...string) *prometheus.GaugeVec {
+		return factory.NewGaugeVec(prometheus.GaugeOpts{Name: name}, labels)
+	}
+
+	return &PrometheusMetrics{
+		boyarUptimeSeconds: newGauge("boyar_uptime_seconds"),
+		memoryTotalMbytes:  newGauge("memory_total_mbs"),
+		memoryUsedMbytes:   newGauge("memory_used_mbs"),
+		memoryUsedPercent:  newGauge("memory_used_percent"),
+		cpuLoadPercent:     newGauge("cpu_load_percent"),
+		efsAccessTimeMs:    newGauge("efs_access_time_ms"),
+
+		diskTotalMbytes:       newGaugeVec("disk_total_mbs", "mountpoint"),
+		diskUsedMbytes:        newGaugeVec("disk_used_mbs", "mountpoint"),
+		diskUsedPercent:       newGaugeVec("disk_used_percent", "mountpoint"),
+		processMemoryUsedMbs:  newGaugeVec("process_memory_used_mbs", "name", "pid", "parent"),
+		containerCPUPercent:   newGaugeVec("container_cpu_percent", containerLabels...),
+		containerMemoryUsed:   newGaugeVec("container_memory_used_mbs", containerLabels...),
+		containerMemoryLimit:  newGaugeVec("container_memory_limit_mbs", containerLabels...),
+		containerNetworkRx:    newGaugeVec("container_network_rx_bytes", containerLabels...),
+		containerNetworkTx:    newGaugeVec("container_network_tx_bytes", containerLabels...),
+		containerRestartCount: newGaugeVec("container_restart_count", containerLabels...),
+		containerStateAge:     newGaugeVec("container_seconds_since_state_change", containerLabels...),
+		containerState:        newGaugeVec("container_state", append(containerLabels, "state")...),
+	}
+}
 
-	memoryTotalMbytes.Set(metrics.MemoryTotalMBytes)
-	memoryUsedMbytes.Set(float64(metrics.MemoryUsedMBytes))
-	memoryUsedPercent.Set(metrics.MemoryUsedPercent)
-	cpuLoadPercent.Set(metrics.CPULoadPercent)
-	efsAccessTimeMs.Set(float64(metrics.EFSAccessTimeMs))
+func (m *PrometheusMetrics) Update(metrics Metrics) {
+	m.boyarUptimeSeconds.Set(metrics.BoyarUptime)
+	m.memoryTotalMbytes.Set(metrics.MemoryTotalMBytes)
+	m.memoryUsedMbytes.Set(float64(metrics.MemoryUsedMBytes))
+	m.memoryUsedPercent.Set(metrics.MemoryUsedPercent)
+	m.cpuLoadPercent.Set(metrics.CPULoadPercent)
+	m.efsAccessTimeMs.Set(float64(metrics.EFSAccessTimeMs))
+
+	for _, vec := range []*prometheus.GaugeVec{
+		m.diskTotalMbytes, m.diskUsedMbytes, m.diskUsedPercent, m.processMemoryUsedMbs,
+		m.containerCPUPercent, m.containerMemoryUsed, m.containerMemoryLimit, m.containerNetworkRx, m.containerNetworkTx,
+		m.containerRestartCount, m.containerStateAge, m.containerState,
+	} {
+		vec.Reset()
+	}
 
 	for _, diskMetric := range metrics.Disks {
-		diskTotalMbs := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-			Name: "disk_total_mbs",
-			ConstLabels: map[string]string{
-				"mountpoint": diskMetric.Mountpoint,
-			},
-		})
+		m.diskTotalMbytes.WithLabelValues(diskMetric.Mountpoint).Set(diskMetric.TotalMbytes)
+		m.diskUsedMbytes.WithLabelValues(diskMetric.Mountpoint).Set(diskMetric.UsedMbytes)
+		m.diskUsedPercent.WithLabelValues(diskMetric.Mountpoint).Set(diskMetric.UsedPercent)
+	}
 
-		diskUsedMbs := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-			Name: "disk_used_mbs",
-			ConstLabels: map[string]string{
-				"mountpoint": diskMetric.Mountpoint,
-			},
-		})
+	for _, processMetric := range metrics.Processes {
+		m.processMemoryUsedMbs.WithLabelValues(
+			processMetric.Name,
+			strconv.FormatInt(int64(processMetric.PID), 10),
+			strconv.FormatInt(int64(processMetric.ParentPID), 10),
+		).Set(processMetric.MemoryUsedMbytes)
+	}
 
-		diskUsedPercent := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-			Name: "disk_used_percent",
-			ConstLabels: map[string]string{
-				"mountpoint": diskMetric.Mountpoint,
-			},
-		})
+	for _, containerMetric := range metrics.Containers {
+		vcid, service, name := containerMetric.VirtualChainId, containerMetric.Service, containerMetric.Name
+
+		m.containerCPUPercent.WithLabelValues(vcid, service, name).Set(containerMetric.CPUPercent)
+		m.containerMemoryUsed.WithLabelValues(vcid, service, name).Set(containerMetric.MemoryUsedMbytes)
+		m.containerMemoryLimit.WithLabelValues(vcid, service, name).Set(containerMetric.MemoryLimitMbytes)
+		m.containerNetworkRx.WithLabelValues(vcid, service, name).Set(float64(containerMetric.NetworkRxBytes))
+		m.containerNetworkTx.WithLabelValues(vcid, service, name).Set(float64(containerMetric.NetworkTxBytes))
+		m.containerRestartCount.WithLabelValues(vcid, service, name).Set(float64(containerMetric.RestartCount))
+		m.containerStateAge.WithLabelValues(vcid, service, name).Set(containerMetric.SecondsSinceStateChange)
+		m.containerState.WithLabelValues(vcid, service, name, containerMetric.State).Set(1)
+	}
+}
+
+// Registers the metrics and sets their values, should only be called once per registry
+func InitializeAndUpdatePrometheusMetrics(registry *prometheus.Registry, metrics Metrics) {
+	NewPrometheusMetrics(registry).Update(metrics)
+}
 
-		diskTotalMbs.Set(diskMetric.TotalMbytes)
-		diskUsedMbs.Set(diskMetric.UsedMbytes)
-		diskUsedPercent.Set(diskMetric.UsedPercent)
+// Combines the latest task of every container with its resource usage
+func getContainerMetrics(statuses []*adapter.ContainerStatus, stats []*adapter.ContainerStats, now time.Time) (containerMetrics []ContainerMetric) {
+	latest := make(map[string]*adapter.ContainerStatus)
+	for _, status := range statuses {
+		if status.Name == "" {
+			continue
+		}
+
+		if previous, found := latest[status.Name]; !found || status.CreatedAt.After(previous.CreatedAt) {
+			latest[status.Name] = status
+		}
 	}
 
-	for _, processMetric := range metrics.Processes {
-		processMemoryUsedMbs := promauto.With(registry).NewGauge(prometheus.GaugeOpts{
-			Name: "process_memory_used_mbs",
-			ConstLabels: map[string]string{
-				"name":   processMetric.Name,
-				"pid":    strconv.FormatInt(int64(processMetric.PID), 10),
-				"parent": strconv.FormatInt(int64(processMetric.ParentPID), 10),
-			},
-		})
+	statsByName := make(map[string]*adapter.ContainerStats)
+	for _, s := range stats {
+		statsByName[s.Name] = s
+	}
 
-		processMemoryUsedMbs.Set(processMetric.MemoryUsedMbytes)
+	for name, status := range latest {
+		containerMetric := ContainerMetric{
+			Name:         name,
+			State:        status.TaskState,
+			RestartCount: status.RestartCount,
+		}
+
+		if vcid, found := config.ParseVirtualChainContainerName(name); found {
+			containerMetric.VirtualChainId = vcid
+		} else {
+			containerMetric.Service = name
+		}
+
+		if !status.StateChangedAt.IsZero() {
+			containerMetric.SecondsSinceStateChange = now.Sub(status.StateChangedAt).Seconds()
+		}
+
+		if s, found := statsByName[name]; found {
+			containerMetric.CPUPercent = s.CPUPercent
+			containerMetric.MemoryUsedMbytes = toMB(s.MemoryUsedBytes)
+			containerMetric.MemoryLimitMbytes = toMB(s.MemoryLimitBytes)
+			containerMetric.NetworkRxBytes = s.NetworkRxBytes
+			containerMetric.NetworkTxBytes = s.NetworkTxBytes
+		}
+
+		containerMetrics = append(containerMetrics, containerMetric)
 	}
+
+	sort.Slice(containerMetrics, func(i, j int) bool {
+		return containerMetrics[i].Name < containerMetrics[j].Name
+	})
+
+	return
 }
 
 func measureEFSAccessTime(ctx context.Context) (uint64, error) {
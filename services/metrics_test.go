@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/scribe/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -43,3 +44,86 @@ func TestCollectMetrics(t *testing.T) {
 	require.NoError(t, err)
 	fmt.Println(serializedMetrics)
 }
+
+func TestGetContainerMetrics(t *testing.T) {
+	now := time.Now()
+	statuses := []*adapter.ContainerStatus{
+		{Name: "chain-42", TaskState: "failed", CreatedAt: now.Add(-time.Hour), StateChangedAt: now.Add(-50 * time.Minute)},
+		{Name: "chain-42", TaskState: "running", CreatedAt: now.Add(-time.Minute), StateChangedAt: now.Add(-30 * time.Second), RestartCount: 1},
+		{Name: "signer", TaskState: "running", CreatedAt: now.Add(-time.Hour)},
+		{Name: "staging-chain-42", TaskState: "running", CreatedAt: now.Add(-time.Hour)},
+	}
+	stats := []*adapter.ContainerStats{
+		{Name: "chain-42", CPUPercent: 12.5, MemoryUsedBytes: 200 * 1000 * 1000, MemoryLimitBytes: 1000 * 1000 * 1000, NetworkRxBytes: 10, NetworkTxBytes: 20},
+	}
+
+	containerMetrics := getContainerMetrics(statuses, stats, now)
+	require.Equal(t, []ContainerMetric{
+		{
+			Name:                    "chain-42",
+			VirtualChainId:          "42",
+			State:                   "running",
+			RestartCount:            1,
+			SecondsSinceStateChange: 30,
+			CPUPercent:              12.5,
+			MemoryUsedMbytes:        200,
+			MemoryLimitMbytes:       1000,
+			NetworkRxBytes:          10,
+			NetworkTxBytes:          20,
+		},
+		{
+			Name:    "signer",
+			Service: "signer",
+			State:   "running",
+		},
+		{
+			Name:           "staging-chain-42",
+			VirtualChainId: "42",
+			State:          "running",
+		},
+	}, containerMetrics)
+
+	registry := prometheus.NewRegistry()
+	InitializeAndUpdatePrometheusMetrics(registry, Metrics{Containers: containerMetrics})
+
+	serializedMetrics, err := GetSerializedMetrics(registry)
+	require.NoError(t, err)
+	require.Contains(t, serializedMetrics, `container_cpu_percent{name="chain-42",service="",vcid="42"} 12.5`)
+	require.Contains(t, serializedMetrics, `container_restart_count{name="chain-42",service="",vcid="42"} 1`)
+	require.Contains(t, serializedMetrics, `container_restart_count{name="staging-chain-42",service="",vcid="42"} 0`)
+	require.Contains(t, serializedMetrics, `container_state{name="signer",service="signer",state="running",vcid=""} 1`)
+}
+
+func TestDaemonStateMetricsAreRegisteredOnce(t *testing.T) {
+	state := NewDaemonState()
+
+	state.Metrics().Update(Metrics{MemoryUsedMBytes: 10})
+	state.Metrics().Update(Metrics{MemoryUsedMBytes: 20})
+
+	state.BoyarMetrics().ConfigPolled(METRIC_RESULT_UNCHANGED)
+	state.BoyarMetrics().ConfigPolled(METRIC_RESULT_UNCHANGED)
+	state.BoyarMetrics().PhaseCompleted(PHASE_VCHAINS, time.Now(), fmt.Errorf("could not pull image"))
+	state.BoyarMetrics().SelfUpdateAttempted(nil)
+	state.BoyarMetrics().SetBootstrapResetRemaining(-time.Second)
+
+	serializedMetrics, err := GetSerializedMetrics(state.Registry())
+	require.NoError(t, err)
+	require.Contains(t, serializedMetrics, "memory_used_mbs 20")
+	require.Contains(t, serializedMetrics, `boyar_config_polls_total{result="unchanged"} 2`)
+	require.Contains(t, serializedMetrics, `boyar_config_apply_duration_seconds_count{phase="vchains",result="failure"} 1`)
+	require.Contains(t, serializedMetrics, `boyar_self_update_attempts_total{result="success"} 1`)
+	require.Contains(t, serializedMetrics, "boyar_bootstrap_reset_seconds_remaining 0")
+	require.Contains(t, serializedMetrics, `boyar_recovery_ticks_total{result="failure"} 0`)
+}
+
+func TestBoyarMetricsAreOptional(t *testing.T) {
+	var metrics *BoyarMetrics
+
+	require.NotPanics(t, func() {
+		metrics.ConfigPolled(METRIC_RESULT_SUCCESS)
+		metrics.PhaseCompleted(PHASE_NGINX, time.Now(), nil)
+		metrics.ImagePulled(time.Now(), nil)
+		metrics.SelfUpdateAttempted(nil)
+		metrics.SetBootstrapResetRemaining(time.Minute)
+	})
+}
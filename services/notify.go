@@ -0,0 +1,70 @@
+package services
+
+import (
+	"time"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/notifications"
+	"github.com/orbs-network/scribe/log"
+)
+
+// Bootstrap reset is announced once the remaining time drops below this part of the timeout
+const BOOTSTRAP_RESET_NOTIFICATION_RATIO = 4
+
+var healthNotificationSeverity = map[string]string{
+	HEALTH_OK:       notifications.SEVERITY_INFO,
+	HEALTH_WARNING:  notifications.SEVERITY_WARNING,
+	HEALTH_CRITICAL: notifications.SEVERITY_CRITICAL,
+}
+
+// Returns nil if no sinks are configured
+func newNotifier(flags *config.Flags, logger log.Logger) *notifications.Notifier {
+	var sinks []notifications.Sink
+	for _, url := range flags.NotificationWebhooks {
+		sinks = append(sinks, notifications.NewWebhookSink(url))
+	}
+
+	for _, url := range flags.NotificationSlackWebhooks {
+		sinks = append(sinks, notifications.NewSlackSink(url))
+	}
+
+	if flags.NotificationScript != "" {
+		sinks = append(sinks, notifications.NewScriptSink(flags.NotificationScript))
+	}
+
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	options := notifications.Options{
+		DedupPeriod: flags.NotificationDedupPeriod,
+		RateLimit:   flags.NotificationRateLimit,
+		RatePeriod:  time.Hour,
+	}
+
+	if keys, err := config.NewKeysConfig(flags.KeyPairConfigPath); err == nil {
+		options.NodeAddress = keys.Address()
+	}
+
+	return notifications.NewNotifier(logger, options, sinks...)
+}
+
+// Only transitions are reported: checks that got worse or recovered since the previous report.
+// Checks that were not reported before are considered ok, so that problems that exist on startup are not missed.
+func notifyHealthChanges(notifier *notifications.Notifier, previous map[string]string, health *HealthReport) map[string]string {
+	current := make(map[string]string)
+	for _, check := range health.Checks {
+		current[check.Name] = check.Severity
+
+		previousSeverity, found := previous[check.Name]
+		if !found {
+			previousSeverity = HEALTH_OK
+		}
+
+		if previousSeverity != check.Severity {
+			notifier.Notify(notifications.EVENT_HEALTH_CHANGED, check.Name, healthNotificationSeverity[check.Severity], check.Message)
+		}
+	}
+
+	return current
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/notifications"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Send(ctx context.Context, event *notifications.Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, event.Severity+" "+event.Subject+": "+event.Message)
+	return nil
+}
+
+func (s *recordingSink) Events() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.events...)
+}
+
+func TestNotifyHealthChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sink := &recordingSink{}
+	notifier := notifications.NewNotifier(helpers.DefaultTestLogger(), notifications.Options{}, sink)
+	notifier.Start(ctx)
+
+	first := NewHealthReport()
+	first.Add("docker", HEALTH_OK, "docker is reachable")
+	first.Add("vchain chain-42", HEALTH_CRITICAL, "chain-42 is failed")
+	previous := notifyHealthChanges(notifier, nil, first)
+
+	second := NewHealthReport()
+	second.Add("docker", HEALTH_OK, "docker is reachable")
+	second.Add("vchain chain-42", HEALTH_CRITICAL, "chain-42 is failed")
+	previous = notifyHealthChanges(notifier, previous, second)
+
+	third := NewHealthReport()
+	third.Add("docker", HEALTH_OK, "docker is reachable")
+	third.Add("vchain chain-42", HEALTH_OK, "chain-42 is running")
+	notifyHealthChanges(notifier, previous, third)
+
+	helpers.RequireEventually(t, time.Second, func(t helpers.TestingT) {
+		require.Equal(t, []string{
+			"critical vchain chain-42: chain-42 is failed",
+			"info vchain chain-42: chain-42 is running",
+		}, sink.Events())
+	})
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/orbs-network/boyarin/boyar"
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/scribe/log"
+)
+
+// Compares the configuration either with another configuration or with the services that are currently running.
+// Running services are only listed, nothing is ever changed. With management config, the configuration
+// it points to is planned, the same one the daemon applies once bootstrapped.
+func GetPlan(ctx context.Context, flags *config.Flags, baseConfigUrl string, logger log.Logger) (*boyar.Plan, error) {
+	if flags.ManagementConfig != "" {
+		managementCfg, err := readManagementConfig(flags)
+		if err != nil {
+			return nil, fmt.Errorf("could not read management config: %s", err)
+		}
+
+		flags = getManagementFlags(flags, managementCfg)
+	}
+
+	cfg, err := config.GetConfiguration(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseConfigUrl != "" {
+		baseFlags := *flags
+		baseFlags.ConfigUrl = baseConfigUrl
+		baseFlags.ConfigSignatureUrl = ""
+
+		baseCfg, err := config.GetConfiguration(&baseFlags)
+		if err != nil {
+			return nil, fmt.Errorf("could not get base configuration: %s", err)
+		}
+
+		return boyar.GetPlanBetween(ctx, baseCfg, cfg, logger)
+	}
+
+	orchestrator, err := adapter.NewOrchestrator(cfg.OrchestratorOptions(), logger)
+	if err != nil {
+		return nil, err
+	}
+	defer orchestrator.Close()
+
+	running, err := orchestrator.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list running services: %s", err)
+	}
+
+	return boyar.GetPlan(ctx, cfg, running, logger)
+}
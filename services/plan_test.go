@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func serveConfigFile(t *testing.T, path string) helpers.HttpServer {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(data)
+	})
+	server.Start()
+
+	return server
+}
+
+func TestGetPlan_WithBaseConfig(t *testing.T) {
+	base := serveConfigFile(t, "../boyar/config/test/configWithActiveVchains.json")
+	defer base.Shutdown()
+
+	target := serveConfigFile(t, "../boyar/config/test/configWithSingleChain.json")
+	defer target.Shutdown()
+
+	plan, err := GetPlan(context.Background(), &config.Flags{
+		ConfigUrl:         target.Url(),
+		KeyPairConfigPath: "../boyar/config/test/fake-key-pair.json",
+	}, base.Url(), helpers.DefaultTestLogger())
+	require.NoError(t, err)
+
+	require.Contains(t, plan.Changes, &adapter.PlannedChange{Action: adapter.PLAN_REMOVE, Name: "chain-1991"})
+	require.Contains(t, plan.String(), "+ create service signer")
+	require.NotEmpty(t, plan.NginxConfigDiff)
+}
+
+func TestGetPlan_WithManagementConfig(t *testing.T) {
+	base := serveConfigFile(t, "../boyar/config/test/configWithActiveVchains.json")
+	defer base.Shutdown()
+
+	target := serveConfigFile(t, "../boyar/config/test/configWithSingleChain.json")
+	defer target.Shutdown()
+
+	managementConfig, err := ioutil.TempFile("", "management-config")
+	require.NoError(t, err)
+	defer os.Remove(managementConfig.Name())
+
+	_, err = managementConfig.WriteString(`{"orchestrator": {"DynamicManagementConfig": {"Url": "` + target.Url() + `"}}}`)
+	require.NoError(t, err)
+	require.NoError(t, managementConfig.Close())
+
+	plan, err := GetPlan(context.Background(), &config.Flags{
+		ManagementConfig:  managementConfig.Name(),
+		KeyPairConfigPath: "../boyar/config/test/fake-key-pair.json",
+	}, base.Url(), helpers.DefaultTestLogger())
+	require.NoError(t, err)
+
+	require.Contains(t, plan.Changes, &adapter.PlannedChange{Action: adapter.PLAN_REMOVE, Name: "chain-1991"})
+}
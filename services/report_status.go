@@ -5,33 +5,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/boyarin/recovery"
+	"github.com/orbs-network/boyarin/snapshot"
 	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/utils"
 	"github.com/orbs-network/boyarin/version"
 	"github.com/orbs-network/govnr"
 	"github.com/orbs-network/scribe/log"
-	"github.com/prometheus/client_golang/prometheus"
 )
 
 const SERVICE_STATUS_REPORT_PERIOD = 30 * time.Second
 const SERVICE_STATUS_REPORT_TIMEOUT = 15 * time.Second
 
-const MAX_CPU_LOAD = 75
-const MAX_MEMORY_USED = 75
-
-func WatchAndReportStatusAndMetrics(ctx context.Context, logger log.Logger, flags *config.Flags) govnr.ShutdownWaiter {
+func WatchAndReportStatusAndMetrics(ctx context.Context, logger log.Logger, flags *config.Flags, state *DaemonState) govnr.ShutdownWaiter {
 	errorHandler := utils.NewLogErrors("service status reporter", logger)
 	startupTimestamp := time.Now()
+	var previousHealth map[string]string
 	return govnr.Forever(ctx, "service status reporter", errorHandler, func() {
 		start := time.Now()
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, SERVICE_STATUS_REPORT_TIMEOUT)
 		defer cancel()
 
-		status, metrics := GetStatusAndMetrics(ctxWithTimeout, logger, flags, startupTimestamp, SERVICE_STATUS_REPORT_PERIOD)
+		status, metrics := GetStatusAndMetrics(ctxWithTimeout, logger, flags, state.Config(), startupTimestamp, SERVICE_STATUS_REPORT_PERIOD)
+		checkConfig(status.Health, state.ConfigReceivedAt(), state.ConfigError(), getHealthThresholds(flags).MaxConfigAge, time.Now())
+		bootstraps := state.Bootstraps().Status()
+		checkBootstraps(status.Health, bootstraps)
+		reportHealth(&status)
+		reportConfigError(&status, state.ConfigError())
+		previousHealth = notifyHealthChanges(state.Notifier(), previousHealth, status.Health)
+		reportConfigSource(&status, state.ConfigFetcher())
+		reportBootstraps(&status, bootstraps)
+		state.SetStatus(status)
+
+		state.Metrics().Update(metrics)
 
 		if flags.StatusFilePath != "" {
 			rawJSON, _ := json.MarshalIndent(status, "  ", "  ")
@@ -41,9 +51,7 @@ func WatchAndReportStatusAndMetrics(ctx context.Context, logger log.Logger, flag
 		}
 
 		if flags.MetricsFilePath != "" {
-			registry := prometheus.NewRegistry()
-			InitializeAndUpdatePrometheusMetrics(registry, metrics)
-			if serializedMetrics, err := GetSerializedMetrics(registry); err != nil {
+			if serializedMetrics, err := GetSerializedMetrics(state.Registry()); err != nil {
 				logger.Error("failed to serialize metrics", log.Error(err))
 			} else {
 				if err := ioutil.WriteFile(flags.MetricsFilePath, []byte(serializedMetrics), 0644); err != nil {
@@ -65,12 +73,13 @@ type StatusResponse struct {
 	Timestamp time.Time
 	Status    string
 	Error     string
+	Health    *HealthReport
 	Payload   map[string]interface{}
 }
 
 func statusResponseWithError(flags *config.Flags, dockerInfo interface{}, err error) StatusResponse {
 	return StatusResponse{
-		Status:    "Failed to query Docker Swarm",
+		Status:    "Failed to query Docker",
 		Timestamp: time.Now(),
 		Error:     err.Error(),
 		Payload: map[string]interface{}{
@@ -81,25 +90,96 @@ func statusResponseWithError(flags *config.Flags, dockerInfo interface{}, err er
 	}
 }
 
+// We really don't need any options here since we're just observing, except for the backend and where it runs.
+// The configuration already includes --orchestrator-options, the flag is only read before the first one is loaded.
+func getObservingOrchestratorOptions(flags *config.Flags, cfg config.NodeConfiguration) *adapter.OrchestratorOptions {
+	options := &adapter.OrchestratorOptions{}
+	if cfg != nil && cfg.OrchestratorOptions() != nil {
+		options = cfg.OrchestratorOptions()
+	} else if flags.OrchestratorOptions != "" {
+		if err := json.Unmarshal([]byte(flags.OrchestratorOptions), options); err != nil {
+			return &adapter.OrchestratorOptions{}
+		}
+	}
+
+	return &adapter.OrchestratorOptions{
+		Backend:    options.Backend,
+		Kubernetes: options.Kubernetes,
+	}
+}
+
+// Rejected configuration is more important than resource usage, so it overrides the status
+func reportConfigError(status *StatusResponse, err error) {
+	if err == nil {
+		return
+	}
+
+	status.Payload["ConfigError"] = err.Error()
+
+	if config.IsConfigSignatureError(err) {
+		status.Status = "Configuration rejected"
+		status.Error = err.Error()
+	}
+}
+
+func reportConfigSource(status *StatusResponse, fetcher *config.ConfigFetcher) {
+	if fetcher == nil {
+		return
+	}
+
+	if sources := fetcher.Status(); len(sources) > 0 {
+		status.Payload["ConfigSource"] = sources
+	}
+}
+
+func reportBootstraps(status *StatusResponse, bootstraps map[string]snapshot.ProgressStatus) {
+	if len(bootstraps) > 0 {
+		status.Payload["Bootstrap"] = bootstraps
+	}
+}
+
+// Resource usage is only a summary of a healthy node, otherwise the problems are
+func reportHealth(status *StatusResponse) {
+	if status.Health.Severity == HEALTH_OK {
+		return
+	}
+
+	problems := strings.Join(status.Health.Problems(), "; ")
+	status.Status = strings.Title(status.Health.Severity) + ": " + problems
+	status.Error = problems
+}
+
 func statusFromMetrics(metrics Metrics) string {
 	return fmt.Sprintf("RAM = %dmb, CPU = %.2f%%, EFSAccess = %dms",
 		int(metrics.MemoryUsedMBytes), metrics.CPULoadPercent, metrics.EFSAccessTimeMs)
 }
 
-func GetStatusAndMetrics(ctx context.Context, logger log.Logger, flags *config.Flags, startupTimestamp time.Time, dockerStatusPeriod time.Duration) (status StatusResponse, metrics Metrics) {
-	// We really don't need any options here since we're just observing
-	orchestrator, err := adapter.NewDockerSwarm(&adapter.OrchestratorOptions{}, logger)
+// Configuration is optional, without it every container that is found is expected to be running
+func GetStatusAndMetrics(ctx context.Context, logger log.Logger, flags *config.Flags, cfg config.NodeConfiguration, startupTimestamp time.Time, dockerStatusPeriod time.Duration) (status StatusResponse, metrics Metrics) {
+	var containerMetrics []ContainerMetric
+	health := NewHealthReport()
+
+	orchestrator, err := adapter.NewOrchestrator(getObservingOrchestratorOptions(flags, cfg), logger)
 	if err != nil {
 		status = statusResponseWithError(flags, nil, err)
+		checkDocker(health, err)
 	} else {
 		defer orchestrator.Close()
 
 		dockerInfo, _ := orchestrator.Info(ctx)
 
 		containerStatus, err := orchestrator.GetStatus(ctx, dockerStatusPeriod)
+		checkDocker(health, err)
 		if err != nil {
 			status = statusResponseWithError(flags, dockerInfo, err)
 		} else {
+			containerStats, err := orchestrator.GetStats(ctx)
+			if err != nil {
+				logger.Error("failed to read container stats", log.Error(err))
+			}
+			containerMetrics = getContainerMetrics(containerStatus, containerStats, time.Now())
+			checkContainers(health, cfg, containerMetrics)
+
 			services := make(map[string][]*adapter.ContainerStatus)
 			for _, s := range containerStatus {
 				services[s.Name] = append(services[s.Name], s)
@@ -129,20 +209,14 @@ func GetStatusAndMetrics(ctx context.Context, logger log.Logger, flags *config.F
 
 	metrics, err = CollectMetrics(ctx, logger, startupTimestamp)
 	if err != nil {
-		status.Status = "Failed to collect metrics"
-		status.Error = err.Error()
-	}
-
-	if metrics.CPULoadPercent >= 75 {
-		status.Status = "CPU usage is too high"
-		status.Error = fmt.Sprintf("CPU usage is higher that %d%% (currently at %f%%)",
-			MAX_CPU_LOAD, metrics.CPULoadPercent)
+		health.Add("metrics", HEALTH_WARNING, err.Error())
 	}
+	metrics.Containers = containerMetrics
+	checkResources(health, metrics, getHealthThresholds(flags))
 
-	if metrics.MemoryUsedPercent >= 75 {
-		status.Status = "Memory usage is too high"
-		status.Error = fmt.Sprintf("Memory usage is higher that %d%% (currently at %f%%)",
-			MAX_MEMORY_USED, metrics.MemoryUsedPercent)
+	if rcvr := recovery.GetInstance(); rcvr != nil {
+		healthy, message := rcvr.Healthy()
+		checkRecovery(health, healthy, message)
 	}
 
 	logger.Info("cpu load", log.Float64("cpuLoad", metrics.CPULoadPercent))
@@ -150,6 +224,8 @@ func GetStatusAndMetrics(ctx context.Context, logger log.Logger, flags *config.F
 
 	status.Payload["Metrics"] = metrics
 	status.Status = statusFromMetrics(metrics)
+	status.Health = health
+	reportHealth(&status)
 
 	return
 }
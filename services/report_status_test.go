@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/strelets/adapter"
 	"github.com/orbs-network/boyarin/test/helpers"
 	"github.com/orbs-network/scribe/log"
 	"github.com/stretchr/testify/require"
@@ -20,7 +21,7 @@ func TestDockerVersion(t *testing.T) {
 
 		status, _ := GetStatusAndMetrics(ctx, logger, &config.Flags{
 			ConfigUrl: "http://some/fake/url",
-		}, time.Now(), 5*time.Second)
+		}, nil, time.Now(), 5*time.Second)
 
 		require.Regexp(t, "RAM.*CPU.*EFSAccess.*", status.Status)
 
@@ -31,3 +32,43 @@ func TestDockerVersion(t *testing.T) {
 		fmt.Println(string(raw))
 	})
 }
+
+func TestReportConfigError(t *testing.T) {
+	status := StatusResponse{Status: "OK", Payload: map[string]interface{}{}}
+	reportConfigError(&status, nil)
+	require.Equal(t, "OK", status.Status)
+	require.NotContains(t, status.Payload, "ConfigError")
+
+	reportConfigError(&status, fmt.Errorf("management config url returned with status 500"))
+	require.Equal(t, "OK", status.Status)
+	require.Equal(t, "management config url returned with status 500", status.Payload["ConfigError"])
+
+	err := &config.ConfigSignatureError{Reason: "signature does not match any of the trusted keys"}
+	reportConfigError(&status, err)
+	require.Equal(t, "Configuration rejected", status.Status)
+	require.Equal(t, err.Error(), status.Error)
+}
+
+func TestReportConfigSource(t *testing.T) {
+	status := StatusResponse{Status: "OK", Payload: map[string]interface{}{}}
+	reportConfigSource(&status, nil)
+	reportConfigSource(&status, config.NewConfigFetcher(""))
+	require.NotContains(t, status.Payload, "ConfigSource")
+}
+
+func TestGetObservingOrchestratorOptions(t *testing.T) {
+	flags := &config.Flags{OrchestratorOptions: `{"backend":"docker"}`}
+	require.EqualValues(t, adapter.DOCKER_BACKEND, getObservingOrchestratorOptions(flags, nil).Backend)
+
+	cfg, err := config.NewStringConfigurationSource(`{"orchestrator":{"backend":"kubernetes","storage-driver":"local","kubernetes":{"namespace":"orbs","render-only":true,"render-path":"/opt/orbs/manifests"}}}`, "", "", false)
+	require.NoError(t, err)
+
+	require.EqualValues(t, &adapter.OrchestratorOptions{
+		Backend: adapter.KUBERNETES_BACKEND,
+		Kubernetes: adapter.KubernetesOptions{
+			Namespace:  "orbs",
+			RenderOnly: true,
+			RenderPath: "/opt/orbs/manifests",
+		},
+	}, getObservingOrchestratorOptions(flags, cfg), "management config should take precedence over the flag")
+}
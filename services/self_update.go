@@ -1,55 +1,263 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/inconshreveable/go-update"
+	"github.com/orbs-network/boyarin/audit"
 	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/boyarin/crypto"
+	"github.com/orbs-network/boyarin/notifications"
 	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/version"
 	"github.com/orbs-network/scribe/log"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
 )
 
-func (coreBoyar *BoyarService) SelfUpdate(targetPath string, image adapter.ExecutableImageOptions) error {
-	checksum, err := hex.DecodeString(image.Sha256)
+const UPDATE_DOWNLOAD_TIMEOUT = 10 * time.Minute
+const UPDATE_VERSION_CHECK_TIMEOUT = 30 * time.Second
+
+const MAX_UPDATE_MANIFEST_SIZE = 1024 * 1024
+const MAX_UPDATE_BINARY_SIZE = 512 * 1024 * 1024
+
+const UPDATE_MANIFEST_SIGNATURE_SUFFIX = ".sig"
+const OLD_BINARY_SUFFIX = ".old"
+const NEW_BINARY_SUFFIX = ".new"
+
+// Signed with a detached signature (same url with .sig suffix) by one of the update trusted keys
+type UpdateManifest struct {
+	Version  string                  `json:"version"`
+	Binaries map[string]UpdateBinary `json:"binaries"` // by GOOS/GOARCH, for example linux/amd64
+}
+
+type UpdateBinary struct {
+	Url    string `json:"url"`
+	Sha256 string `json:"sha256"`
+}
+
+func getPlatform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// Binary for the current platform, either from the signed manifest or straight from the config;
+// version is empty if unknown
+func resolveUpdate(flags *config.Flags, options adapter.ExecutableImageOptions) (binary UpdateBinary, version string, err error) {
+	if options.ManifestUrl == "" {
+		configured, found := options.Binary(getPlatform())
+		if !found {
+			return binary, "", fmt.Errorf("no boyar binary for %s", getPlatform())
+		}
+
+		if len(flags.UpdateTrustedKeys) > 0 {
+			return binary, "", fmt.Errorf("update trusted keys are provided, refusing to update from %s without a signed manifest", configured.Url)
+		}
+
+		return UpdateBinary{Url: configured.Url, Sha256: configured.Sha256}, "", nil
+	}
+
+	manifest, err := downloadUpdateManifest(flags.UpdateTrustedKeys, options.ManifestUrl)
 	if err != nil {
-		return fmt.Errorf("could not decode boyar binary SHA256 checksum \"%s\": %s", image.Sha256, err)
+		return binary, "", err
+	}
+
+	if manifest.Version == "" {
+		return binary, "", fmt.Errorf("update manifest %s has no version", options.ManifestUrl)
+	}
+
+	binary, found := manifest.Binaries[getPlatform()]
+	if !found {
+		return binary, "", fmt.Errorf("update manifest %s has no binary for %s", options.ManifestUrl, getPlatform())
 	}
 
-	coreBoyar.logger.Info("downloading new boyar binary", log.String("url", image.Url))
-	resp, err := http.Get(image.Url)
+	return binary, manifest.Version, nil
+}
+
+func downloadUpdateManifest(trustedKeys []string, url string) (*UpdateManifest, error) {
+	keys, err := crypto.ParsePublicKeys(trustedKeys)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("invalid update trusted keys: %s", err)
+	}
+
+	client := &http.Client{Timeout: UPDATE_DOWNLOAD_TIMEOUT}
+
+	data, err := download(client, url, MAX_UPDATE_MANIFEST_SIZE)
+	if err != nil {
+		return nil, fmt.Errorf("could not download update manifest: %s", err)
+	}
+
+	signatures, err := download(client, url+UPDATE_MANIFEST_SIGNATURE_SUFFIX, MAX_UPDATE_MANIFEST_SIZE)
+	if err != nil {
+		return nil, fmt.Errorf("could not download update manifest signature: %s", err)
+	}
+
+	if _, err := crypto.VerifyDetachedSignature(keys, data, signatures); err != nil {
+		return nil, fmt.Errorf("invalid signature of update manifest %s: %s", url, err)
+	}
+
+	manifest := &UpdateManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("could not parse update manifest: %s", err)
+	}
+
+	return manifest, nil
+}
+
+func download(client *http.Client, url string, maxSize int64) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	return update.Apply(resp.Body, update.Options{
-		TargetPath: targetPath,
-		Checksum:   checksum,
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned with status %s", url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxSize)
+	}
+
+	return data, nil
+}
+
+// The new binary has to report its version before it replaces the current one, which is kept with .old suffix
+func (coreBoyar *BoyarService) SelfUpdate(targetPath string, binary UpdateBinary, version string) error {
+	checksum, err := hex.DecodeString(binary.Sha256)
+	if err != nil {
+		return fmt.Errorf("could not decode boyar binary SHA256 checksum \"%s\": %s", binary.Sha256, err)
+	}
+
+	coreBoyar.logger.Info("downloading new boyar binary", log.String("url", binary.Url))
+	data, err := download(&http.Client{Timeout: UPDATE_DOWNLOAD_TIMEOUT}, binary.Url, MAX_UPDATE_BINARY_SIZE)
+	if err != nil {
+		return err
+	}
+
+	if actual := crypto.CalculateHash(data); actual != binary.Sha256 {
+		return fmt.Errorf("boyar binary checksum %s does not match %s", actual, binary.Sha256)
+	}
+
+	if err := checkBinaryVersion(targetPath+NEW_BINARY_SUFFIX, data, version); err != nil {
+		return err
+	}
+
+	return update.Apply(bytes.NewReader(data), update.Options{
+		TargetPath:  targetPath,
+		Checksum:    checksum,
+		OldSavePath: targetPath + OLD_BINARY_SUFFIX,
 	})
 }
 
-func (coreBoyar *BoyarService) CheckForUpdates(flags *config.Flags, options adapter.ExecutableImageOptions) (shouldExit bool) {
+func checkBinaryVersion(path string, data []byte, version string) error {
+	if err := ioutil.WriteFile(path, data, 0755); err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), UPDATE_VERSION_CHECK_TIMEOUT)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("new boyar binary failed to report its version: %s", err)
+	}
+
+	// the semantic version comes first, followed by the commit
+	reported := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	if version != "" && reported != version {
+		return fmt.Errorf("new boyar binary reported version %q instead of %s", reported, version)
+	}
+
+	return nil
+}
+
+// Signed manifests are only trusted to move forward, otherwise an old manifest could be replayed to downgrade.
+// Versions are unknown for binaries from the config and for builds without a version.
+func checkUpdateVersion(newVersion string, currentVersion string) error {
+	if newVersion == "" || currentVersion == "" {
+		return nil
+	}
+
+	if compareVersions(newVersion, currentVersion) <= 0 {
+		return fmt.Errorf("update version %s is not newer than the current version %s", newVersion, currentVersion)
+	}
+
+	return nil
+}
+
+// Updates are postponed until the rollout policy lets them through, so it has to be called periodically
+func (coreBoyar *BoyarService) CheckForUpdates(flags *config.Flags, nodeAddress string, options adapter.ExecutableImageOptions) (shouldExit bool) {
 	shouldExit = false
 	if flags.AutoUpdate {
+		if options.ManifestUrl == "" && options.Url == "" && len(options.Binaries) == 0 {
+			return
+		}
+
+		binary, newVersion, err := resolveUpdate(flags, options)
+		if err != nil {
+			coreBoyar.logger.Error("failed to check for updates", log.Error(err))
+			return
+		}
+
 		currentHash, err := crypto.CalculateFileHash(flags.BoyarBinaryPath)
 		if err != nil {
 			coreBoyar.logger.Error("failed to calculate boyar binary hash", log.Error(err))
 			return
 		}
 
-		if currentHash == options.Sha256 { // already the correct version
+		if currentHash == binary.Sha256 { // already the correct version
 			return
 		}
 
-		if err := coreBoyar.SelfUpdate(flags.BoyarBinaryPath, options); err != nil {
+		if err := checkUpdateVersion(newVersion, version.GetVersion().Semantic); err != nil {
+			coreBoyar.logger.Error("refusing to update", log.String("sha256", binary.Sha256), log.Error(err))
+			return
+		}
+
+		if isRejectedUpdate(flags.BoyarBinaryPath, binary.Sha256) {
+			coreBoyar.logger.Info("skipping update that was rolled back before", log.String("sha256", binary.Sha256))
+			return
+		}
+
+		if reason := checkUpdatePolicy(options, nodeAddress, version.GetVersion().Semantic, time.Now()); reason != "" {
+			coreBoyar.logger.Info("postponing update", log.String("sha256", binary.Sha256), log.String("reason", reason))
+			return
+		}
+
+		start := time.Now()
+		err = coreBoyar.SelfUpdate(flags.BoyarBinaryPath, binary, newVersion)
+		coreBoyar.auditLog.Record(audit.ACTION_SELF_UPDATE, flags.BoyarBinaryPath, "", strings.TrimSpace(newVersion+" sha256 "+binary.Sha256+" from "+binary.Url), start, err)
+		coreBoyar.metrics.SelfUpdateAttempted(err)
+		if err != nil {
+			coreBoyar.notifier.Notify(notifications.EVENT_SELF_UPDATE, binary.Sha256, notifications.SEVERITY_WARNING, "failed to update boyar binary: "+err.Error())
 			coreBoyar.logger.Error("failed to update self", log.Error(err))
 			return
 		} else {
+			coreBoyar.notifier.Notify(notifications.EVENT_SELF_UPDATE, binary.Sha256, notifications.SEVERITY_INFO, "boyar binary was updated from "+binary.Url)
 			coreBoyar.logger.Info("successfully replaced boyar binary", log.String("path", flags.BoyarBinaryPath))
 		}
 
+		if flags.UpdateRollbackTimeout > 0 {
+			if err := writePendingUpdate(flags.BoyarBinaryPath, &pendingUpdate{Version: newVersion, Sha256: binary.Sha256, UpdatedAt: time.Now()}); err != nil {
+				coreBoyar.logger.Error("failed to save pending update, the binary will not be rolled back", log.Error(err))
+			}
+		}
+
 		return flags.ShutdownAfterUpdate
 	}
 
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/orbs-network/boyarin/audit"
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/crypto"
+	"github.com/orbs-network/boyarin/notifications"
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/scribe/log"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const PENDING_UPDATE_SUFFIX = ".pending"
+const REJECTED_UPDATE_SUFFIX = ".rejected"
+const PENDING_UPDATE_CHECK_INTERVAL = 5 * time.Second
+
+// Written next to the binary after an update and removed once the new binary applies the configuration
+type pendingUpdate struct {
+	Version     string    `json:"version,omitempty"`
+	Sha256      string    `json:"sha256"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	RestartedAt time.Time `json:"restartedAt,omitempty"` // first start of the new binary
+}
+
+func writePendingUpdate(binaryPath string, pending *pendingUpdate) error {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(binaryPath+PENDING_UPDATE_SUFFIX, data, 0600)
+}
+
+// Returns nil if the running binary is not the result of an update that still has to prove itself
+func startPendingUpdate(binaryPath string) (*pendingUpdate, error) {
+	data, err := ioutil.ReadFile(binaryPath + PENDING_UPDATE_SUFFIX)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	pending := &pendingUpdate{}
+	if err := json.Unmarshal(data, pending); err != nil {
+		return nil, fmt.Errorf("could not parse pending update: %s", err)
+	}
+
+	// the binary was replaced by someone else
+	if currentHash, err := crypto.CalculateFileHash(binaryPath); err != nil || currentHash != pending.Sha256 {
+		return nil, os.Remove(binaryPath + PENDING_UPDATE_SUFFIX)
+	}
+
+	if pending.RestartedAt.IsZero() {
+		pending.RestartedAt = time.Now()
+		if err := writePendingUpdate(binaryPath, pending); err != nil {
+			return nil, err
+		}
+	}
+
+	return pending, nil
+}
+
+// Restores the binary kept with .old suffix, the process has to be restarted to run it.
+// The update is remembered as rejected, otherwise the previous binary would install it again.
+func rollbackUpdate(binaryPath string, pending *pendingUpdate, auditLog *audit.Log) error {
+	start := time.Now()
+	err := os.Rename(binaryPath+OLD_BINARY_SUFFIX, binaryPath)
+	if err == nil {
+		err = os.Rename(binaryPath+PENDING_UPDATE_SUFFIX, binaryPath+REJECTED_UPDATE_SUFFIX)
+	}
+
+	auditLog.Record(audit.ACTION_SELF_UPDATE, binaryPath, "", "rollback from sha256 "+pending.Sha256, start, err)
+	return err
+}
+
+func isRejectedUpdate(binaryPath string, sha256 string) bool {
+	data, err := ioutil.ReadFile(binaryPath + REJECTED_UPDATE_SUFFIX)
+	if err != nil {
+		return false
+	}
+
+	rejected := &pendingUpdate{}
+	return json.Unmarshal(data, rejected) == nil && rejected.Sha256 == sha256
+}
+
+// Gives the updated binary until the rollback timeout to apply the configuration,
+// otherwise restores the previous one and calls exit so that the process manager could start it
+func (coreBoyar *BoyarService) WatchUpdatedBinary(ctx context.Context, flags *config.Flags, state *DaemonState, pending *pendingUpdate, exit func()) govnr.ShutdownWaiter {
+	deadline := pending.RestartedAt.Add(flags.UpdateRollbackTimeout)
+	ctxWithCancel, stopWatching := context.WithCancel(ctx)
+
+	return govnr.Forever(ctxWithCancel, "watch updated binary", utils.NewLogErrors("watch updated binary", coreBoyar.logger), func() {
+		select {
+		case <-ctxWithCancel.Done():
+			return
+		case <-time.After(PENDING_UPDATE_CHECK_INTERVAL):
+		}
+
+		if applied, _ := state.ConfigApplied(); applied {
+			coreBoyar.logger.Info("updated boyar binary applied the configuration", log.String("sha256", pending.Sha256))
+			if err := os.Remove(flags.BoyarBinaryPath + PENDING_UPDATE_SUFFIX); err != nil {
+				coreBoyar.logger.Error("failed to remove pending update", log.Error(err))
+			}
+			stopWatching()
+			return
+		}
+
+		if time.Now().Before(deadline) {
+			return
+		}
+
+		message := fmt.Sprintf("updated boyar binary did not apply the configuration within %s, rolling back to the previous one", flags.UpdateRollbackTimeout)
+		coreBoyar.logger.Error(message, log.String("sha256", pending.Sha256))
+		if err := rollbackUpdate(flags.BoyarBinaryPath, pending, coreBoyar.auditLog); err != nil {
+			message += ": " + err.Error()
+			coreBoyar.logger.Error("failed to roll back boyar binary", log.Error(err))
+		}
+
+		coreBoyar.notifier.Notify(notifications.EVENT_SELF_UPDATE, pending.Sha256, notifications.SEVERITY_CRITICAL, message)
+		stopWatching()
+		exit()
+	})
+}
@@ -1,17 +1,27 @@
 package services
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"github.com/orbs-network/boyarin/boyar/config"
 	"github.com/orbs-network/boyarin/crypto"
 	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/orbs-network/boyarin/version"
 	"github.com/orbs-network/scribe/log"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
+const SELF_UPDATE_NODE_ADDRESS = "a328846cd5b4979d68a8c58a9bdfeee657b34de7"
+
 var VALID_UPDATE_OPTIONS = adapter.ExecutableImageOptions{
 	Url:    "https://github.com/orbs-network/boyarin/releases/download/v1.4.0/boyar-v1.4.0.bin",
 	Sha256: "1998cc1f7721acfe1954ab2878cc0ad8062cd6d919cd61fa22401c6750e195fe",
@@ -29,7 +39,7 @@ func TestBoyarService_SelfUpdateHappyFlow(t *testing.T) {
 		ShutdownAfterUpdate: true,
 	}
 
-	shouldExit := coreBoyar.CheckForUpdates(flags, VALID_UPDATE_OPTIONS)
+	shouldExit := coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, VALID_UPDATE_OPTIONS)
 
 	require.True(t, shouldExit)
 	newChecksum, _ := crypto.CalculateFileHash(targetPath)
@@ -62,7 +72,7 @@ func TestBoyarService_SelfUpdateWithDisabledAutoUpdate(t *testing.T) {
 		ShutdownAfterUpdate: true,
 	}
 
-	shouldExit := coreBoyar.CheckForUpdates(flags, VALID_UPDATE_OPTIONS)
+	shouldExit := coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, VALID_UPDATE_OPTIONS)
 
 	// nothing changed
 	require.False(t, shouldExit)
@@ -82,7 +92,7 @@ func TestBoyarService_SelfUpdateWithWrongBinaryPath(t *testing.T) {
 		ShutdownAfterUpdate: true,
 	}
 
-	shouldExit := coreBoyar.CheckForUpdates(flags, VALID_UPDATE_OPTIONS)
+	shouldExit := coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, VALID_UPDATE_OPTIONS)
 
 	// nothing changed
 	require.False(t, shouldExit)
@@ -102,7 +112,7 @@ func TestBoyarService_SelfUpdateWithMalformedUrl(t *testing.T) {
 		ShutdownAfterUpdate: true,
 	}
 
-	shouldExit := coreBoyar.CheckForUpdates(flags, adapter.ExecutableImageOptions{
+	shouldExit := coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, adapter.ExecutableImageOptions{
 		Url:    "http://localhost/does-not-exist",
 		Sha256: VALID_UPDATE_OPTIONS.Sha256,
 	})
@@ -125,7 +135,7 @@ func TestBoyarService_SelfUpdateWithWrongChecksum(t *testing.T) {
 		ShutdownAfterUpdate: true,
 	}
 
-	shouldExit := coreBoyar.CheckForUpdates(flags, adapter.ExecutableImageOptions{
+	shouldExit := coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, adapter.ExecutableImageOptions{
 		Url:    VALID_UPDATE_OPTIONS.Url,
 		Sha256: "0000cc1f7721acfe1954ab2878cc0ad8062cd6d919cd61fa22401c6750e195fe",
 	})
@@ -135,3 +145,185 @@ func TestBoyarService_SelfUpdateWithWrongChecksum(t *testing.T) {
 	newChecksum, _ := crypto.CalculateFileHash(targetPath)
 	require.EqualValues(t, checksum, newChecksum)
 }
+
+const FAKE_UPDATED_BINARY = "#!/bin/sh\necho v1.12.0\n"
+
+// Serves the update manifest for the current platform signed with the private key, and the binary it points to
+func serveUpdateManifest(t *testing.T, privateKey ed25519.PrivateKey) helpers.HttpServer {
+	var manifest, signature []byte
+
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/manifest.json":
+			writer.Write(manifest)
+		case "/manifest.json" + UPDATE_MANIFEST_SIGNATURE_SUFFIX:
+			writer.Write(signature)
+		case "/boyar.bin":
+			writer.Write([]byte(FAKE_UPDATED_BINARY))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	manifest, err := json.Marshal(&UpdateManifest{
+		Version: "v1.12.0",
+		Binaries: map[string]UpdateBinary{
+			getPlatform(): {Url: server.Url() + "boyar.bin", Sha256: crypto.CalculateHash([]byte(FAKE_UPDATED_BINARY))},
+		},
+	})
+	require.NoError(t, err)
+	signature = []byte(hex.EncodeToString(ed25519.Sign(privateKey, manifest)))
+
+	server.Start()
+	return server
+}
+
+func TestBoyarService_SelfUpdateFromSignedManifest(t *testing.T) {
+	targetPath, checksum := prepareSelfUpdateTest(t)
+
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	server := serveUpdateManifest(t, privateKey)
+	defer server.Shutdown()
+
+	coreBoyar := NewCoreBoyarService(log.GetLogger())
+	flags := &config.Flags{
+		AutoUpdate:            true,
+		BoyarBinaryPath:       targetPath,
+		ShutdownAfterUpdate:   true,
+		UpdateTrustedKeys:     []string{"ed25519:" + hex.EncodeToString(publicKey)},
+		UpdateRollbackTimeout: time.Minute,
+	}
+
+	shouldExit := coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, adapter.ExecutableImageOptions{ManifestUrl: server.Url() + "manifest.json"})
+	require.True(t, shouldExit)
+
+	newChecksum, _ := crypto.CalculateFileHash(targetPath)
+	require.EqualValues(t, crypto.CalculateHash([]byte(FAKE_UPDATED_BINARY)), newChecksum)
+
+	oldChecksum, _ := crypto.CalculateFileHash(targetPath + OLD_BINARY_SUFFIX)
+	require.EqualValues(t, checksum, oldChecksum, "previous binary should be kept")
+
+	pending, err := startPendingUpdate(targetPath)
+	require.NoError(t, err)
+	require.Equal(t, "v1.12.0", pending.Version)
+	require.False(t, pending.RestartedAt.IsZero())
+}
+
+func TestBoyarService_SelfUpdateRequiresTrustedManifest(t *testing.T) {
+	targetPath, checksum := prepareSelfUpdateTest(t)
+
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	server := serveUpdateManifest(t, privateKey)
+	defer server.Shutdown()
+
+	coreBoyar := NewCoreBoyarService(log.GetLogger())
+	flags := &config.Flags{
+		AutoUpdate:          true,
+		BoyarBinaryPath:     targetPath,
+		ShutdownAfterUpdate: true,
+		UpdateTrustedKeys:   []string{"ed25519:" + hex.EncodeToString(otherPublicKey)},
+	}
+
+	require.False(t, coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, adapter.ExecutableImageOptions{ManifestUrl: server.Url() + "manifest.json"}))
+
+	// unsigned updates are refused once the keys are provided
+	require.False(t, coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, adapter.ExecutableImageOptions{
+		Url:    server.Url() + "boyar.bin",
+		Sha256: crypto.CalculateHash([]byte(FAKE_UPDATED_BINARY)),
+	}))
+
+	// nothing changed
+	newChecksum, _ := crypto.CalculateFileHash(targetPath)
+	require.EqualValues(t, checksum, newChecksum)
+}
+
+func TestBoyarService_SelfUpdateRollback(t *testing.T) {
+	targetPath, checksum := prepareSelfUpdateTest(t)
+	require.NoError(t, ioutil.WriteFile(targetPath+OLD_BINARY_SUFFIX, []byte("fake binary"), 0755))
+	require.NoError(t, ioutil.WriteFile(targetPath, []byte(FAKE_UPDATED_BINARY), 0755))
+
+	updatedChecksum := crypto.CalculateHash([]byte(FAKE_UPDATED_BINARY))
+	require.NoError(t, writePendingUpdate(targetPath, &pendingUpdate{Sha256: updatedChecksum, UpdatedAt: time.Now()}))
+
+	pending, err := startPendingUpdate(targetPath)
+	require.NoError(t, err)
+	require.NoError(t, rollbackUpdate(targetPath, pending, nil))
+
+	newChecksum, _ := crypto.CalculateFileHash(targetPath)
+	require.EqualValues(t, checksum, newChecksum)
+	require.True(t, isRejectedUpdate(targetPath, updatedChecksum), "rolled back update should not be installed again")
+
+	pending, err = startPendingUpdate(targetPath)
+	require.NoError(t, err)
+	require.Nil(t, pending)
+}
+
+func TestBoyarService_SelfUpdatePicksBinaryForPlatform(t *testing.T) {
+	targetPath, _ := prepareSelfUpdateTest(t)
+
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	server := serveUpdateManifest(t, privateKey)
+	defer server.Shutdown()
+
+	coreBoyar := NewCoreBoyarService(log.GetLogger())
+	flags := &config.Flags{
+		AutoUpdate:          true,
+		BoyarBinaryPath:     targetPath,
+		ShutdownAfterUpdate: true,
+	}
+
+	shouldExit := coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, adapter.ExecutableImageOptions{
+		Url:    "http://localhost/does-not-exist",
+		Sha256: VALID_UPDATE_OPTIONS.Sha256,
+		Binaries: map[string]adapter.ExecutableBinary{
+			getPlatform(): {Url: server.Url() + "boyar.bin", Sha256: crypto.CalculateHash([]byte(FAKE_UPDATED_BINARY))},
+		},
+	})
+	require.True(t, shouldExit)
+
+	newChecksum, _ := crypto.CalculateFileHash(targetPath)
+	require.EqualValues(t, crypto.CalculateHash([]byte(FAKE_UPDATED_BINARY)), newChecksum)
+}
+
+func TestBoyarService_SelfUpdateRefusesDowngrade(t *testing.T) {
+	targetPath, checksum := prepareSelfUpdateTest(t)
+
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	server := serveUpdateManifest(t, privateKey)
+	defer server.Shutdown()
+
+	defer func(current string) { version.SemanticVersion = current }(version.SemanticVersion)
+	version.SemanticVersion = "v1.12.0"
+
+	coreBoyar := NewCoreBoyarService(log.GetLogger())
+	flags := &config.Flags{
+		AutoUpdate:          true,
+		BoyarBinaryPath:     targetPath,
+		ShutdownAfterUpdate: true,
+		UpdateTrustedKeys:   []string{"ed25519:" + hex.EncodeToString(publicKey)},
+	}
+
+	require.False(t, coreBoyar.CheckForUpdates(flags, SELF_UPDATE_NODE_ADDRESS, adapter.ExecutableImageOptions{ManifestUrl: server.Url() + "manifest.json"}))
+
+	// nothing changed
+	newChecksum, _ := crypto.CalculateFileHash(targetPath)
+	require.EqualValues(t, checksum, newChecksum)
+}
+
+func TestCheckUpdateVersion(t *testing.T) {
+	require.NoError(t, checkUpdateVersion("v1.12.0", "v1.10.3"))
+	require.NoError(t, checkUpdateVersion("v1.12.0", ""), "development builds have no version")
+	require.NoError(t, checkUpdateVersion("", "v1.10.3"), "binaries from the config have no version")
+	require.EqualError(t, checkUpdateVersion("v1.12.0", "v1.12.0"), "update version v1.12.0 is not newer than the current version v1.12.0")
+	require.EqualError(t, checkUpdateVersion("v1.9.0", "v1.10.0"), "update version v1.9.0 is not newer than the current version v1.10.0")
+}
+
+func TestCheckBinaryVersion(t *testing.T) {
+	prepareSelfUpdateTest(t)
+	path := filepath.Join("./_tmp", "boyar.bin"+NEW_BINARY_SUFFIX)
+
+	require.NoError(t, checkBinaryVersion(path, []byte("#!/bin/sh\necho v1.10.0\necho 4ad9004\n"), "v1.10.0"))
+	require.EqualError(t, checkBinaryVersion(path, []byte("#!/bin/sh\necho v1.1\n"), "v1.10"), `new boyar binary reported version "v1.1" instead of v1.10`)
+	require.EqualError(t, checkBinaryVersion(path, []byte("#!/bin/sh\necho v1.10.1\n"), "v1.10"), `new boyar binary reported version "v1.10.1" instead of v1.10`)
+}
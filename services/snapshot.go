@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/orbs-network/boyarin/audit"
+	"github.com/orbs-network/boyarin/boyar/config"
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/orbs-network/scribe/log"
+)
+
+// The chain is stopped and not started again, a running boyar notices it is gone and starts it on the next reconciliation
+func SnapshotVirtualChain(ctx context.Context, flags *config.Flags, vcId config.VirtualChainId, archivePath string, logger log.Logger) error {
+	return withVirtualChain(flags, vcId, logger, func(orchestrator adapter.Orchestrator, nodeAddress string, containerName string) error {
+		return orchestrator.SnapshotVirtualChainData(ctx, nodeAddress, uint32(vcId), containerName, archivePath)
+	})
+}
+
+func RestoreVirtualChain(ctx context.Context, flags *config.Flags, vcId config.VirtualChainId, archivePath string, logger log.Logger) error {
+	return withVirtualChain(flags, vcId, logger, func(orchestrator adapter.Orchestrator, nodeAddress string, containerName string) error {
+		return orchestrator.RestoreVirtualChainData(ctx, nodeAddress, uint32(vcId), containerName, archivePath)
+	})
+}
+
+// Resolves the container name and volumes the same way provisioning does
+func withVirtualChain(flags *config.Flags, vcId config.VirtualChainId, logger log.Logger, f func(orchestrator adapter.Orchestrator, nodeAddress string, containerName string) error) error {
+	cfg, err := config.GetConfiguration(flags)
+	if err != nil {
+		return err
+	}
+
+	var chain *config.VirtualChain
+	for _, c := range cfg.Chains() {
+		if c.Id == vcId {
+			chain = c
+		}
+	}
+
+	if chain == nil {
+		return fmt.Errorf("virtual chain %d is not in the configuration", vcId)
+	}
+
+	orchestrator, err := adapter.NewOrchestrator(cfg.OrchestratorOptions(), logger)
+	if err != nil {
+		return err
+	}
+	defer orchestrator.Close()
+
+	auditingOrchestrator := audit.NewAuditingOrchestrator(orchestrator, audit.NewLog(flags.AuditLogPath, audit.DEFAULT_LOG_MAX_SIZE), cfg.Hash())
+	return f(auditingOrchestrator, string(cfg.NodeAddress()), cfg.NamespacedContainerName(chain.GetContainerName()))
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orbs-network/boyarin/strelets/adapter"
+)
+
+// Reports why the update has to wait, empty if it could be applied now.
+// Nodes below the minimum version ignore the rest of the policy.
+func checkUpdatePolicy(options adapter.ExecutableImageOptions, nodeAddress string, currentVersion string, now time.Time) string {
+	if options.MinVersion != "" && currentVersion != "" && compareVersions(currentVersion, options.MinVersion) < 0 {
+		return ""
+	}
+
+	if options.RolloutPercentage > 0 && options.RolloutPercentage < 100 {
+		if bucket := getRolloutBucket(nodeAddress); bucket >= options.RolloutPercentage {
+			return fmt.Sprintf("node is not in the first %d%% of the rollout (bucket %d)", options.RolloutPercentage, bucket)
+		}
+	}
+
+	hours, err := options.MaintenanceHours()
+	if err != nil {
+		return err.Error()
+	}
+
+	if hour := now.UTC().Hour(); !hours[hour] {
+		return fmt.Sprintf("%02d:00 UTC is outside of the maintenance window %s", hour, options.MaintenanceWindow)
+	}
+
+	return ""
+}
+
+// Stable number between 0 and 99 for every node, so that raising the percentage only adds nodes to the rollout
+func getRolloutBucket(nodeAddress string) int {
+	hash := sha256.Sum256([]byte(strings.TrimPrefix(strings.ToLower(nodeAddress), "0x")))
+	return int(binary.BigEndian.Uint64(hash[:8]) % 100)
+}
+
+// Compares versions like v1.12.0 part by part, missing or non-numeric parts count as zero
+func compareVersions(a string, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := getVersionPart(aParts, i), getVersionPart(bParts, i)
+		if aPart < bPart {
+			return -1
+		} else if aPart > bPart {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Suffixes like -rc1 are ignored
+func getVersionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+
+	part := parts[i]
+	if end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+		part = part[:end]
+	}
+
+	value, _ := strconv.Atoi(part)
+	return value
+}
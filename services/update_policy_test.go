@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/orbs-network/boyarin/strelets/adapter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUpdatePolicy(t *testing.T) {
+	nodeAddress := "a328846cd5b4979d68a8c58a9bdfeee657b34de7"
+	bucket := getRolloutBucket(nodeAddress)
+	require.Equal(t, bucket, getRolloutBucket("0xA328846CD5B4979D68A8C58A9BDFEEE657B34DE7"), "bucket should not depend on address format")
+
+	noon := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+
+	require.Empty(t, checkUpdatePolicy(adapter.ExecutableImageOptions{}, nodeAddress, "v1.11.0", noon))
+	require.Empty(t, checkUpdatePolicy(adapter.ExecutableImageOptions{RolloutPercentage: bucket + 1}, nodeAddress, "v1.11.0", noon))
+	require.Equal(t, fmt.Sprintf("node is not in the first %d%% of the rollout (bucket %d)", bucket, bucket),
+		checkUpdatePolicy(adapter.ExecutableImageOptions{RolloutPercentage: bucket}, nodeAddress, "v1.11.0", noon))
+
+	require.Empty(t, checkUpdatePolicy(adapter.ExecutableImageOptions{MaintenanceWindow: "11-13"}, nodeAddress, "v1.11.0", noon))
+	require.Equal(t, "12:00 UTC is outside of the maintenance window 22-4",
+		checkUpdatePolicy(adapter.ExecutableImageOptions{MaintenanceWindow: "22-4"}, nodeAddress, "v1.11.0", noon))
+	require.Empty(t, checkUpdatePolicy(adapter.ExecutableImageOptions{MaintenanceWindow: "22-4"}, nodeAddress, "v1.11.0", noon.Add(11*time.Hour)))
+
+	// nodes below the floor do not wait
+	outsideOfPolicy := adapter.ExecutableImageOptions{RolloutPercentage: bucket, MaintenanceWindow: "22-4", MinVersion: "v1.10.2"}
+	require.Empty(t, checkUpdatePolicy(outsideOfPolicy, nodeAddress, "v1.9.7", noon))
+	require.NotEmpty(t, checkUpdatePolicy(outsideOfPolicy, nodeAddress, "v1.10.2", noon))
+	require.NotEmpty(t, checkUpdatePolicy(outsideOfPolicy, nodeAddress, "", noon), "unknown version should follow the policy")
+}
+
+func TestCompareVersions(t *testing.T) {
+	require.Equal(t, 0, compareVersions("v1.10.0", "1.10"))
+	require.Equal(t, -1, compareVersions("v1.9.7", "v1.10.0"))
+	require.Equal(t, 1, compareVersions("v2.0.0-rc1", "v1.12.3"))
+	require.Equal(t, 0, compareVersions("v1.12.3-rc1", "v1.12.3"))
+}
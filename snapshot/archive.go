@@ -0,0 +1,237 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Written as the last entry of the archive
+const MANIFEST_NAME = "boyar-snapshot-manifest.json"
+const MAX_MANIFEST_SIZE = 64 * 1024 * 1024
+
+// Archive checksum is kept next to the archive so that it could be passed to other nodes
+const CHECKSUM_SUFFIX = ".sha256"
+
+type Manifest struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Files     []File    `json:"files"`
+}
+
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Archives regular files and directories of dir as tar.zst, anything else fails the snapshot
+func Create(dir string, w io.Writer) (*Manifest, error) {
+	encoder, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := tar.NewWriter(encoder)
+	manifest := &Manifest{CreatedAt: time.Now().UTC()}
+
+	if err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(dir, filePath)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		name := filepath.ToSlash(relativePath)
+
+		if info.IsDir() {
+			return archive.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: 0755, ModTime: info.ModTime()})
+		}
+
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", name)
+		}
+
+		if err := archive.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+			return err
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		hash := sha256.New()
+		if _, err := io.CopyN(io.MultiWriter(archive, hash), file, info.Size()); err != nil {
+			return fmt.Errorf("could not archive %s: %s", name, err)
+		}
+
+		manifest.Files = append(manifest.Files, File{Path: name, Size: info.Size(), Sha256: hex.EncodeToString(hash.Sum(nil))})
+		return nil
+	}); err != nil {
+		encoder.Close()
+		return nil, err
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := archive.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: MANIFEST_NAME, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt}); err != nil {
+		return nil, err
+	}
+
+	if _, err := archive.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, encoder.Close()
+}
+
+// Unpacks the archive into dir, which should be empty. Every file has to match the manifest,
+// otherwise dir is left with partial content and has to be discarded.
+func Extract(r io.Reader, dir string) (*Manifest, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	archive := tar.NewReader(decoder)
+	extracted := make(map[string]File)
+	var manifest *Manifest
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read archive: %s", err)
+		}
+
+		if manifest != nil {
+			return nil, fmt.Errorf("unexpected entry %s after the manifest", header.Name)
+		}
+
+		name, err := getSafePath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name == MANIFEST_NAME && header.Typeflag == tar.TypeReg:
+			if manifest, err = readManifest(archive, header.Size); err != nil {
+				return nil, err
+			}
+		case header.Typeflag == tar.TypeDir:
+			if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(name)), 0755); err != nil {
+				return nil, err
+			}
+		case header.Typeflag == tar.TypeReg:
+			file, err := extractFile(archive, filepath.Join(dir, filepath.FromSlash(name)), header.Size)
+			if err != nil {
+				return nil, fmt.Errorf("could not extract %s: %s", name, err)
+			}
+
+			file.Path = name
+			extracted[name] = file
+		default:
+			return nil, fmt.Errorf("unsupported entry %s of type %c", name, header.Typeflag)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no manifest")
+	}
+
+	return manifest, verifyManifest(manifest, extracted)
+}
+
+func getSafePath(name string) (string, error) {
+	cleanName := path.Clean(strings.TrimSuffix(name, "/"))
+	if name == "" || path.IsAbs(cleanName) || cleanName == "." || cleanName == ".." || strings.HasPrefix(cleanName, "../") {
+		return "", fmt.Errorf("unsafe path %q in archive", name)
+	}
+
+	return cleanName, nil
+}
+
+func readManifest(r io.Reader, size int64) (*Manifest, error) {
+	if size > MAX_MANIFEST_SIZE {
+		return nil, fmt.Errorf("manifest is larger than %d bytes", MAX_MANIFEST_SIZE)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("could not parse manifest: %s", err)
+	}
+
+	return manifest, nil
+}
+
+func extractFile(r io.Reader, filePath string, size int64) (File, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return File{}, err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return File{}, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(file, hash), r, size); err != nil {
+		return File{}, err
+	}
+
+	return File{Size: size, Sha256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func verifyManifest(manifest *Manifest, extracted map[string]File) error {
+	var errors []string
+	for _, expected := range manifest.Files {
+		actual, found := extracted[expected.Path]
+		if !found {
+			errors = append(errors, fmt.Sprintf("%s is missing", expected.Path))
+		} else if actual != expected {
+			errors = append(errors, fmt.Sprintf("%s does not match the manifest", expected.Path))
+		}
+		delete(extracted, expected.Path)
+	}
+
+	for name := range extracted {
+		errors = append(errors, fmt.Sprintf("%s is not in the manifest", name))
+	}
+
+	if len(errors) > 0 {
+		sort.Strings(errors)
+		return fmt.Errorf("archive does not match its manifest: %s", strings.Join(errors, "; "))
+	}
+
+	return nil
+}
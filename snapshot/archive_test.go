@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "boyar-snapshot")
+	require.NoError(t, err)
+	return dir
+}
+
+func writeBlocks(t *testing.T, dir string) {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "blocks"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "blocks", "blocks.bin"), []byte("some blocks"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "state"), []byte("some state"), 0644))
+}
+
+// Builds an archive by hand to simulate broken or malicious snapshots
+func buildArchive(t *testing.T, entries map[string]string, manifest string) []byte {
+	buf := &bytes.Buffer{}
+	encoder, err := zstd.NewWriter(buf)
+	require.NoError(t, err)
+
+	archive := tar.NewWriter(encoder)
+	for name, content := range entries {
+		require.NoError(t, archive.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := archive.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, archive.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: MANIFEST_NAME, Mode: 0644, Size: int64(len(manifest))}))
+	_, err = archive.Write([]byte(manifest))
+	require.NoError(t, err)
+
+	require.NoError(t, archive.Close())
+	require.NoError(t, encoder.Close())
+	return buf.Bytes()
+}
+
+func TestSnapshot_WriteAndRestoreFile(t *testing.T) {
+	source := tempDir(t)
+	defer os.RemoveAll(source)
+	target := tempDir(t)
+	defer os.RemoveAll(target)
+
+	writeBlocks(t, source)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(target, "stale"), []byte("old data"), 0644))
+
+	archivePath := filepath.Join(source, "..", filepath.Base(source)+".tar.zst")
+	defer os.Remove(archivePath)
+	defer os.Remove(archivePath + CHECKSUM_SUFFIX)
+
+	manifest, checksum, err := WriteFile(source, archivePath)
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 2)
+	require.Equal(t, "blocks/blocks.bin", manifest.Files[0].Path)
+	require.NoError(t, VerifyFileChecksum(archivePath, checksum))
+
+	restored, err := RestoreFile(archivePath, target)
+	require.NoError(t, err)
+	require.Equal(t, manifest.Files, restored.Files)
+
+	data, err := ioutil.ReadFile(filepath.Join(target, "blocks", "blocks.bin"))
+	require.NoError(t, err)
+	require.Equal(t, "some blocks", string(data))
+
+	_, err = os.Stat(filepath.Join(target, "stale"))
+	require.True(t, os.IsNotExist(err), "restore should replace existing data")
+
+	_, err = os.Stat(target + RESTORE_SUFFIX)
+	require.True(t, os.IsNotExist(err), "staging directory should be removed")
+
+	require.NoError(t, ioutil.WriteFile(archivePath+CHECKSUM_SUFFIX, []byte("0000"), 0644))
+	_, err = RestoreFile(archivePath, target)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match 0000")
+}
+
+func TestSnapshot_RejectsArchivesThatDoNotMatchManifest(t *testing.T) {
+	manifest := `{"files":[{"path":"blocks","size":11,"sha256":"8f3d7b6e1ec0b2b8ff5ddd14dab9f0cc63c1b1d2dfa1d8b3b48dd4f1aa1d3a8e"}]}`
+
+	for name, archive := range map[string][]byte{
+		"tampered file": buildArchive(t, map[string]string{"blocks": "other block"}, manifest),
+		"extra file":    buildArchive(t, map[string]string{"extra": "data"}, `{"files":[]}`),
+		"missing file":  buildArchive(t, nil, manifest),
+	} {
+		target := tempDir(t)
+		_, err := Extract(bytes.NewReader(archive), target)
+		os.RemoveAll(target)
+
+		require.Error(t, err, name)
+		require.Contains(t, err.Error(), "archive does not match its manifest", name)
+	}
+}
+
+func TestSnapshot_RejectsUnsafePaths(t *testing.T) {
+	target := tempDir(t)
+	defer os.RemoveAll(target)
+
+	for _, name := range []string{"../escape", "/etc/passwd", "blocks/../../escape"} {
+		_, err := Extract(bytes.NewReader(buildArchive(t, map[string]string{name: "data"}, `{"files":[]}`)), target)
+		require.Error(t, err, name)
+		require.Contains(t, err.Error(), "unsafe path", name)
+	}
+
+	_, err := Restore(bytes.NewReader(buildArchive(t, map[string]string{"../escape": "data"}, `{"files":[]}`)), target)
+	require.Error(t, err)
+
+	_, err = os.Stat(filepath.Join(target, "..", "escape"))
+	require.True(t, os.IsNotExist(err))
+}
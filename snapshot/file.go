@@ -0,0 +1,153 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/orbs-network/boyarin/utils"
+)
+
+const TEMP_SUFFIX = ".tmp"
+const RESTORE_SUFFIX = ".restore"
+
+// Archives dir to archivePath and writes the archive checksum next to it.
+// The archive only appears under its name once it is complete.
+func WriteFile(dir string, archivePath string) (manifest *Manifest, checksum string, err error) {
+	file, err := os.OpenFile(archivePath+TEMP_SUFFIX, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(archivePath + TEMP_SUFFIX)
+
+	hash := sha256.New()
+	manifest, err = Create(dir, io.MultiWriter(file, hash))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("could not archive %s: %s", dir, err)
+	}
+
+	checksum = hex.EncodeToString(hash.Sum(nil))
+	if err := ioutil.WriteFile(archivePath+CHECKSUM_SUFFIX, []byte(checksum+"  "+filepath.Base(archivePath)+"\n"), 0644); err != nil {
+		return nil, "", err
+	}
+
+	return manifest, checksum, os.Rename(archivePath+TEMP_SUFFIX, archivePath)
+}
+
+// Verifies the archive against the checksum file next to it, if there is one, and replaces the content of dir with it
+func RestoreFile(archivePath string, dir string) (*Manifest, error) {
+	if data, err := ioutil.ReadFile(archivePath + CHECKSUM_SUFFIX); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%s is empty", archivePath+CHECKSUM_SUFFIX)
+		}
+
+		if err := VerifyFileChecksum(archivePath, fields[0]); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Restore(file, dir)
+}
+
+func VerifyFileChecksum(path string, expected string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum %s of %s does not match %s", actual, path, expected)
+	}
+
+	return nil
+}
+
+// Unpacks next to dir first, so that a broken archive never touches the existing data
+func Restore(r io.Reader, dir string) (*Manifest, error) {
+	staging := strings.TrimSuffix(dir, string(filepath.Separator)) + RESTORE_SUFFIX
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := Extract(r, staging)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := removeContent(dir); err != nil {
+		return nil, fmt.Errorf("could not remove existing data: %s", err)
+	}
+
+	fileInfos, err := ioutil.ReadDir(staging)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fileInfo := range fileInfos {
+		if err := os.Rename(filepath.Join(staging, fileInfo.Name()), filepath.Join(dir, fileInfo.Name())); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// Keeps dir itself because it could be a mount point
+func removeContent(dir string) error {
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var errors []error
+	for _, fileInfo := range fileInfos {
+		if err := os.RemoveAll(filepath.Join(dir, fileInfo.Name())); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return utils.AggregateErrors(errors)
+}
+
+// Missing directories are empty as well
+func IsEmpty(dir string) (bool, error) {
+	fileInfos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return len(fileInfos) == 0, nil
+}
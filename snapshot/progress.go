@@ -0,0 +1,153 @@
+package snapshot
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	PROGRESS_PENDING     = "pending"
+	PROGRESS_DOWNLOADING = "downloading"
+	PROGRESS_VERIFYING   = "verifying"
+	PROGRESS_UNPACKING   = "unpacking"
+	PROGRESS_DONE        = "done"
+	PROGRESS_SKIPPED     = "skipped"
+	PROGRESS_FAILED      = "failed"
+)
+
+type ProgressStatus struct {
+	Source     string
+	State      string
+	Bytes      int64
+	TotalBytes int64   `json:",omitempty"`
+	Percent    float64 `json:",omitempty"`
+	Error      string  `json:",omitempty"`
+	UpdatedAt  time.Time
+}
+
+func (s ProgressStatus) InProgress() bool {
+	return s.State == PROGRESS_DOWNLOADING || s.State == PROGRESS_VERIFYING || s.State == PROGRESS_UNPACKING
+}
+
+// Written by the bootstrap and read by status reporting, all methods are safe to call on nil
+type Progress struct {
+	mutex  sync.Mutex
+	status ProgressStatus
+}
+
+func (p *Progress) update(f func(status *ProgressStatus)) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	f(&p.status)
+	p.status.UpdatedAt = time.Now()
+}
+
+// Total is -1 if unknown
+func (p *Progress) Start(total int64) {
+	p.update(func(status *ProgressStatus) {
+		status.State = PROGRESS_DOWNLOADING
+		status.Bytes = 0
+		status.TotalBytes = total
+		status.Error = ""
+	})
+}
+
+func (p *Progress) Add(bytes int64) {
+	p.update(func(status *ProgressStatus) {
+		status.Bytes += bytes
+	})
+}
+
+func (p *Progress) SetState(state string) {
+	p.update(func(status *ProgressStatus) {
+		status.State = state
+	})
+}
+
+// The data was already there
+func (p *Progress) Skip() {
+	p.update(func(status *ProgressStatus) {
+		status.State = PROGRESS_SKIPPED
+	})
+}
+
+func (p *Progress) Finish(err error) {
+	p.update(func(status *ProgressStatus) {
+		if err != nil {
+			status.State = PROGRESS_FAILED
+			status.Error = err.Error()
+		} else {
+			status.State = PROGRESS_DONE
+		}
+	})
+}
+
+func (p *Progress) Status() ProgressStatus {
+	if p == nil {
+		return ProgressStatus{}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	status := p.status
+	if status.TotalBytes > 0 {
+		status.Percent = float64(status.Bytes*10000/status.TotalBytes) / 100
+	} else {
+		status.TotalBytes = 0
+	}
+
+	return status
+}
+
+// Progress of every bootstrap by name, kept for the lifetime of the process
+type Tracker struct {
+	mutex    sync.Mutex
+	progress map[string]*Progress
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{progress: make(map[string]*Progress)}
+}
+
+// Starts over if the source changed, but only once the previous bootstrap is over
+func (t *Tracker) Get(name string, source string) *Progress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if progress, found := t.progress[name]; found {
+		if status := progress.Status(); status.Source == source || status.InProgress() {
+			return progress
+		}
+	}
+
+	progress := &Progress{status: ProgressStatus{Source: source, State: PROGRESS_PENDING, UpdatedAt: time.Now()}}
+	t.progress[name] = progress
+	return progress
+}
+
+// Returns nil if nothing was ever bootstrapped
+func (t *Tracker) Status() map[string]ProgressStatus {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.progress) == 0 {
+		return nil
+	}
+
+	result := make(map[string]ProgressStatus)
+	for name, progress := range t.progress {
+		result[name] = progress.Status()
+	}
+
+	return result
+}
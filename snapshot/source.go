@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const DOWNLOAD_SUFFIX = ".download"
+
+// Large archives are expected, the bootstrap has its own deadline on top of it
+const DOWNLOAD_TIMEOUT = 2 * time.Hour
+
+// Archive made by Create, either an http(s) url or a local path
+type Source struct {
+	Location string
+	Sha256   string
+}
+
+func (s *Source) IsUrl() bool {
+	return strings.HasPrefix(s.Location, "http://") || strings.HasPrefix(s.Location, "https://")
+}
+
+// Downloads urls to path, resuming whatever is already there; local archives are used where they are
+func (s *Source) Fetch(ctx context.Context, path string, progress *Progress) (string, error) {
+	if !s.IsUrl() {
+		info, err := os.Stat(s.Location)
+		if err != nil {
+			return "", err
+		}
+
+		progress.Start(info.Size())
+		progress.Add(info.Size())
+		return s.Location, nil
+	}
+
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	request, err := http.NewRequest(http.MethodGet, s.Location, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	client := &http.Client{Timeout: DOWNLOAD_TIMEOUT}
+	resp, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// already complete, the checksum tells if it is not
+		progress.Start(offset)
+		progress.Add(offset)
+		return path, nil
+	case resp.StatusCode == http.StatusPartialContent && strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		// the server does not support ranges
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return "", fmt.Errorf("%s returned with status %s", s.Location, resp.Status)
+	}
+
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	progress.Start(total)
+	progress.Add(offset)
+	_, err = io.Copy(file, &progressReader{resp.Body, progress})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return path, err
+}
+
+// Downloads the archive next to dir and checks it before anything is unpacked,
+// nothing is replaced unless the whole archive matches the checksum.
+// Interrupted downloads are resumed by the next call with the same checksum.
+func RestoreFromSource(ctx context.Context, source *Source, dir string, progress *Progress) (*Manifest, error) {
+	downloadPath, err := prepareDownload(dir, source.Sha256)
+	if err != nil {
+		return nil, err
+	}
+
+	archivePath, err := source.Fetch(ctx, downloadPath, progress)
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %s", source.Location, err)
+	}
+
+	progress.SetState(PROGRESS_VERIFYING)
+	if err := VerifyFileChecksum(archivePath, source.Sha256); err != nil {
+		os.Remove(downloadPath)
+		return nil, err
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	progress.SetState(PROGRESS_UNPACKING)
+	manifest, err := Restore(file, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	os.Remove(downloadPath)
+	return manifest, nil
+}
+
+// Downloads of other archives are never resumed, so they are removed
+func prepareDownload(dir string, sha256 string) (string, error) {
+	prefix := strings.TrimSuffix(dir, string(filepath.Separator)) + "-"
+	downloadPath := prefix + strings.ToLower(sha256) + DOWNLOAD_SUFFIX
+
+	stale, err := filepath.Glob(prefix + "*" + DOWNLOAD_SUFFIX)
+	if err != nil {
+		return "", err
+	}
+
+	for _, path := range stale {
+		if path != downloadPath {
+			os.Remove(path)
+		}
+	}
+
+	return downloadPath, nil
+}
+
+type progressReader struct {
+	io.Reader
+	progress *Progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.progress.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,160 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreFromSource(t *testing.T) {
+	source := tempDir(t)
+	defer os.RemoveAll(source)
+	writeBlocks(t, source)
+
+	archivePath := filepath.Join(tempDir(t), "chain.tar.zst")
+	defer os.RemoveAll(filepath.Dir(archivePath))
+
+	_, checksum, err := WriteFile(source, archivePath)
+	require.NoError(t, err)
+
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		http.ServeFile(writer, request, archivePath)
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	info, err := os.Stat(archivePath)
+	require.NoError(t, err)
+
+	for _, location := range []string{archivePath, server.Url() + "chain.tar.zst"} {
+		target := tempDir(t)
+		progress := NewTracker().Get("chain-42", location)
+
+		manifest, err := RestoreFromSource(context.Background(), &Source{Location: location, Sha256: checksum}, target, progress)
+		require.NoError(t, err, location)
+		require.Len(t, manifest.Files, 2)
+
+		data, err := ioutil.ReadFile(filepath.Join(target, "state"))
+		require.NoError(t, err)
+		require.Equal(t, "some state", string(data))
+
+		status := progress.Status()
+		require.Equal(t, info.Size(), status.Bytes, location)
+		require.Equal(t, float64(100), status.Percent, location)
+		os.RemoveAll(target)
+	}
+}
+
+func TestRestoreFromSource_ResumesDownload(t *testing.T) {
+	source := tempDir(t)
+	defer os.RemoveAll(source)
+	writeBlocks(t, source)
+
+	archivePath := filepath.Join(tempDir(t), "chain.tar.zst")
+	defer os.RemoveAll(filepath.Dir(archivePath))
+
+	_, checksum, err := WriteFile(source, archivePath)
+	require.NoError(t, err)
+
+	archive, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+
+	var ranges []string
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		ranges = append(ranges, request.Header.Get("Range"))
+		http.ServeFile(writer, request, archivePath)
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	target := tempDir(t)
+	defer os.RemoveAll(target)
+
+	downloadPath, err := prepareDownload(target, checksum)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(downloadPath, archive[:len(archive)/2], 0600))
+
+	staleDownload := target + "-0000" + DOWNLOAD_SUFFIX
+	require.NoError(t, ioutil.WriteFile(staleDownload, []byte("other archive"), 0600))
+
+	progress := NewTracker().Get("chain-42", server.Url())
+	_, err = RestoreFromSource(context.Background(), &Source{Location: server.Url() + "chain.tar.zst", Sha256: checksum}, target, progress)
+	require.NoError(t, err)
+	require.Equal(t, []string{fmt.Sprintf("bytes=%d-", len(archive)/2)}, ranges)
+	require.Equal(t, int64(len(archive)), progress.Status().Bytes)
+
+	data, err := ioutil.ReadFile(filepath.Join(target, "state"))
+	require.NoError(t, err)
+	require.Equal(t, "some state", string(data))
+
+	for _, path := range []string{downloadPath, staleDownload} {
+		_, err = os.Stat(path)
+		require.True(t, os.IsNotExist(err), "download should be removed")
+	}
+}
+
+func TestRestoreFromSource_KeepsDataIfChecksumDoesNotMatch(t *testing.T) {
+	source := tempDir(t)
+	defer os.RemoveAll(source)
+	writeBlocks(t, source)
+
+	archivePath := filepath.Join(tempDir(t), "chain.tar.zst")
+	defer os.RemoveAll(filepath.Dir(archivePath))
+
+	_, _, err := WriteFile(source, archivePath)
+	require.NoError(t, err)
+
+	server := helpers.CreateHttpServer("/", 0, func(writer http.ResponseWriter, request *http.Request) {
+		http.ServeFile(writer, request, archivePath)
+	})
+	server.Start()
+	defer server.Shutdown()
+
+	for _, location := range []string{archivePath, server.Url() + "chain.tar.zst"} {
+		target := tempDir(t)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(target, "existing"), []byte("data"), 0644))
+
+		_, err = RestoreFromSource(context.Background(), &Source{Location: location, Sha256: "0000"}, target, nil)
+		require.Error(t, err, location)
+		require.Contains(t, err.Error(), "does not match 0000", location)
+
+		empty, err := IsEmpty(target)
+		require.NoError(t, err)
+		require.False(t, empty, "existing data should be kept")
+
+		_, err = os.Stat(filepath.Join(target, "state"))
+		require.True(t, os.IsNotExist(err), "archive should not be unpacked")
+
+		_, err = os.Stat(target + RESTORE_SUFFIX)
+		require.True(t, os.IsNotExist(err), "archive should not be unpacked")
+
+		_, err = os.Stat(target + "-0000" + DOWNLOAD_SUFFIX)
+		require.True(t, os.IsNotExist(err), "broken download should not be resumed")
+		os.RemoveAll(target)
+	}
+}
+
+func TestTracker_StartsOverIfSourceChanged(t *testing.T) {
+	tracker := NewTracker()
+	require.Nil(t, tracker.Status())
+
+	progress := tracker.Get("chain-42", "/backups/a.tar.zst")
+	progress.Start(-1)
+	progress.Add(1024)
+	require.Equal(t, progress, tracker.Get("chain-42", "/backups/a.tar.zst"))
+	require.Equal(t, PROGRESS_DOWNLOADING, tracker.Status()["chain-42"].State)
+	require.Zero(t, tracker.Status()["chain-42"].TotalBytes)
+
+	// the running bootstrap is not abandoned
+	require.Equal(t, progress, tracker.Get("chain-42", "/backups/b.tar.zst"))
+
+	progress.Finish(nil)
+	require.Equal(t, PROGRESS_PENDING, tracker.Get("chain-42", "/backups/b.tar.zst").Status().State)
+}
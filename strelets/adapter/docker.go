@@ -0,0 +1,152 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+// Runs the same workloads as dockerSwarmOrchestrator but on a single Docker Engine without Swarm:
+// services become plain containers, secrets become read-only files and overlay networks become bridge networks
+type dockerEngineOrchestrator struct {
+	client  *client.Client
+	options *OrchestratorOptions
+	logger  log.Logger
+}
+
+type dockerContainerSpec struct {
+	Name       string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	Networks   []string
+}
+
+func NewDockerEngine(options *OrchestratorOptions, logger log.Logger) (Orchestrator, error) {
+	if options == nil {
+		return nil, fmt.Errorf("orchestration options are empty, can't instantiate Docker Engine orchestrator")
+	}
+
+	client, err := client.NewClientWithOpts(client.WithVersion(DOCKER_API_VERSION))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dockerEngineOrchestrator{client: client, options: options, logger: logger}, nil
+}
+
+func (d *dockerEngineOrchestrator) volumes() *dockerVolumes {
+	return &dockerVolumes{client: d.client, options: d.options}
+}
+
+func (d *dockerEngineOrchestrator) PullImage(ctx context.Context, imageName string) error {
+	return pullImage(ctx, d.client, imageName)
+}
+
+// unlike swarm, the engine does not pull missing images on create
+func (d *dockerEngineOrchestrator) ensureImage(ctx context.Context, imageName string) error {
+	if _, _, err := d.client.ImageInspectWithRaw(ctx, imageName); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return fmt.Errorf("could not inspect image %s: %s", imageName, err)
+	}
+
+	return pullImage(ctx, d.client, imageName)
+}
+
+func (d *dockerEngineOrchestrator) create(ctx context.Context, spec *dockerContainerSpec) error {
+	if err := d.ensureImage(ctx, spec.Config.Image); err != nil {
+		return errors.Wrap(err, "failed pulling image")
+	}
+
+	// only one network can be attached on creation, the rest are connected before the start
+	var networkingConfig *network.NetworkingConfig
+	if len(spec.Networks) > 0 {
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				spec.Networks[0]: {},
+			},
+		}
+	}
+
+	response, err := d.client.ContainerCreate(ctx, spec.Config, spec.HostConfig, networkingConfig, spec.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed creating container")
+	}
+
+	if len(spec.Networks) > 1 {
+		for _, networkId := range spec.Networks[1:] {
+			if err := d.client.NetworkConnect(ctx, networkId, response.ID, &network.EndpointSettings{}); err != nil {
+				return errors.Wrap(err, "failed connecting container to network")
+			}
+		}
+	}
+
+	return errors.Wrap(d.client.ContainerStart(ctx, response.ID, types.ContainerStartOptions{}), "failed starting container")
+}
+
+func (d *dockerEngineOrchestrator) RemoveService(ctx context.Context, containerName string) error {
+	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.KeyValuePair{Key: "name", Value: "^/" + containerName + "$"}),
+	})
+	if err != nil {
+		return fmt.Errorf("could not list containers: %s \n %v", containerName, err)
+	}
+	if len(containers) == 0 {
+		d.logger.Info(fmt.Sprintf("no container found for removal: %s", containerName))
+		return nil
+	}
+	for _, c := range containers {
+		if err := d.client.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			return fmt.Errorf("failed to remove container %s with id %s", containerName, c.ID)
+		} else {
+			d.logger.Info(fmt.Sprintf("successfully removed container %s with id %s", containerName, c.ID))
+		}
+	}
+	return nil
+}
+
+func (d *dockerEngineOrchestrator) GetOverlayNetwork(ctx context.Context, name string) (string, error) {
+	return getOrCreateNetwork(ctx, d.client, name, types.NetworkCreate{
+		Driver:         "bridge",
+		CheckDuplicate: true,
+	})
+}
+
+func (d *dockerEngineOrchestrator) PurgeServiceData(ctx context.Context, containerName string) error {
+	return d.volumes().purgeServiceData(ctx, containerName)
+}
+
+func (d *dockerEngineOrchestrator) PurgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error {
+	return d.volumes().purgeVirtualChainData(ctx, nodeAddress, vcId, containerName)
+}
+
+func (d *dockerEngineOrchestrator) ListServices(ctx context.Context) (results []*RunningService, err error) {
+	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("could not list containers: %s", err)
+	}
+
+	for _, c := range containers {
+		results = append(results, &RunningService{
+			Name:       getDockerContainerName(c.Names),
+			ConfigHash: c.Labels[CONFIG_HASH_LABEL],
+		})
+	}
+
+	return
+}
+
+func (d *dockerEngineOrchestrator) Close() error {
+	return d.client.Close()
+}
+
+func (d *dockerEngineOrchestrator) Info(ctx context.Context) (interface{}, error) {
+	return d.client.Info(ctx)
+}
@@ -0,0 +1,143 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"strings"
+	"time"
+)
+
+func (d *dockerEngineOrchestrator) GetStatus(ctx context.Context, since time.Duration) (results []*ContainerStatus, err error) {
+	if containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{All: true}); err != nil {
+		return nil, fmt.Errorf("failed to retrieve container list: %s", err)
+	} else {
+		for _, c := range containers {
+			logs, _ := d.getLogs(ctx, c.ID, since) // FIXME handle more errors
+
+			status := &ContainerStatus{
+				Name:      getDockerContainerName(c.Names),
+				State:     c.Status,
+				CreatedAt: time.Unix(c.Created, 0),
+				Logs:      logs,
+			}
+
+			if containerJSON, err := d.client.ContainerInspect(ctx, c.ID); err == nil {
+				status.Debug.ContainerState = containerJSON.State
+				status.Error = getDockerContainerError(containerJSON.State)
+				status.RestartCount = containerJSON.RestartCount
+				status.TaskState, status.StateChangedAt = getDockerContainerState(containerJSON.State)
+				status.ImageDigest = d.getImageDigest(ctx, c.Image, containerJSON.Image)
+			}
+
+			results = append(results, status)
+		}
+	}
+
+	return
+}
+
+func (d *dockerEngineOrchestrator) GetStats(ctx context.Context) ([]*ContainerStats, error) {
+	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve container list: %s", err)
+	}
+
+	containerNames := make(map[string]string)
+	for _, c := range containers {
+		containerNames[c.ID] = getDockerContainerName(c.Names)
+	}
+
+	return getDockerContainerStats(ctx, d.client, containerNames)
+}
+
+// Containers created by tag only know the image id, the digest comes from the registry the image was pulled from
+func (d *dockerEngineOrchestrator) getImageDigest(ctx context.Context, imageName string, imageId string) string {
+	if digest := GetImageDigest(imageName); digest != "" {
+		return digest
+	}
+
+	image, _, err := d.client.ImageInspectWithRaw(ctx, imageId)
+	if err != nil {
+		return ""
+	}
+
+	for _, repoDigest := range image.RepoDigests {
+		if digest := GetImageDigest(repoDigest); digest != "" {
+			return digest
+		}
+	}
+
+	return ""
+}
+
+// State changes either when the container starts or when it stops
+func getDockerContainerState(state *types.ContainerState) (string, time.Time) {
+	if state == nil {
+		return "", time.Time{}
+	}
+
+	startedAt, _ := time.Parse(time.RFC3339Nano, state.StartedAt)
+	finishedAt, _ := time.Parse(time.RFC3339Nano, state.FinishedAt)
+	if finishedAt.After(startedAt) {
+		return state.Status, finishedAt
+	}
+
+	return state.Status, startedAt
+}
+
+func getDockerContainerName(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	return strings.TrimPrefix(names[0], "/")
+}
+
+// mimics the errors reported by swarm tasks
+func getDockerContainerError(state *types.ContainerState) string {
+	if state == nil {
+		return ""
+	}
+
+	if state.Error != "" {
+		return state.Error
+	}
+
+	if !state.Running && state.ExitCode != 0 {
+		if state.OOMKilled {
+			return fmt.Sprintf("non-zero exit (%d): out of memory", state.ExitCode)
+		}
+
+		return fmt.Sprintf("non-zero exit (%d)", state.ExitCode)
+	}
+
+	if state.Health != nil && state.Health.Status == types.Unhealthy {
+		return "unhealthy container"
+	}
+
+	return ""
+}
+
+func (d *dockerEngineOrchestrator) getLogs(ctx context.Context, containerId string, since time.Duration) (string, error) {
+	io, err := d.client.ContainerLogs(ctx, containerId, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Since:      (since + ERROR_LOGS_OVERLAP_MARGIN).String(),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve container logs: %s", err)
+	}
+	defer io.Close()
+
+	data := new(bytes.Buffer)
+	if _, err := stdcopy.StdCopy(data, data, io); err != nil {
+		return "", fmt.Errorf("failed to read container logs: %s", err)
+	}
+
+	return data.String(), nil
+}
@@ -0,0 +1,241 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"strconv"
+)
+
+func (d *dockerEngineOrchestrator) RunVirtualChain(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
+	if err := d.RemoveService(ctx, serviceConfig.ContainerName); err != nil {
+		return err
+	}
+
+	networks, err := d.getNetworks(ctx, serviceConfig)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := storeDockerSecretFiles(DOCKER_SECRETS_PATH, serviceConfig.ContainerName, getVirtualChainSecretFiles(appConfig))
+	if err != nil {
+		return err
+	}
+
+	mounts, err := d.volumes().provisionServiceVolumes(ctx, serviceConfig.ContainerName, nil)
+	if err != nil {
+		return err
+	}
+
+	blocksMount, err := d.volumes().provisionVchainVolume(ctx, serviceConfig.NodeAddress, serviceConfig.Id)
+	if err != nil {
+		return fmt.Errorf("failed to provision volumes: %s", err)
+	} else {
+		mounts = append(mounts, blocksMount)
+	}
+
+	spec := getDockerVirtualChainSpec(serviceConfig, secrets, mounts, networks)
+	spec.Config.Labels = getServiceLabels(serviceConfig.ConfigHash)
+
+	return d.create(ctx, spec)
+}
+
+func (d *dockerEngineOrchestrator) RunService(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
+	if err := d.RemoveService(ctx, serviceConfig.ContainerName); err != nil {
+		return err
+	}
+
+	networks, err := d.getNetworks(ctx, serviceConfig)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := storeDockerSecretFiles(DOCKER_SECRETS_PATH, serviceConfig.ContainerName, getServiceSecretFiles(appConfig))
+	if err != nil {
+		return err
+	}
+
+	mounts, err := d.volumes().provisionServiceVolumes(ctx, serviceConfig.ContainerName, serviceConfig.LogsMountPointNames)
+	if err != nil {
+		return err
+	}
+
+	spec := getDockerServiceSpec(serviceConfig, secrets, mounts, networks)
+	spec.Config.Labels = getServiceLabels(serviceConfig.ConfigHash)
+
+	return d.create(ctx, spec)
+}
+
+func (d *dockerEngineOrchestrator) RunReverseProxy(ctx context.Context, config *ReverseProxyConfig) error {
+	if err := d.RemoveService(ctx, config.ContainerName); err != nil {
+		return err
+	}
+
+	secrets, err := storeDockerSecretFiles(DOCKER_SECRETS_PATH, config.ContainerName, getNginxSecretFiles(config))
+	if err != nil {
+		return err
+	}
+
+	httpPort := DEFAULT_HTTP_PORT
+	if config.HTTPPort != 0 {
+		httpPort = config.HTTPPort
+	}
+
+	sslPort := DEFAULT_SSL_PORT
+	if config.SSLPort != 0 {
+		sslPort = config.SSLPort
+	}
+
+	proxyNetwork, err := d.GetOverlayNetwork(ctx, SHARED_PROXY_NETWORK)
+	if err != nil {
+		return err
+	}
+
+	var mounts []mount.Mount
+	for _, nodeService := range config.Services {
+		if statusMount, err := d.volumes().provisionStatusVolume(ctx, nodeService.ServiceName, GetNginxStatusMountPath(nodeService.Name)); err != nil {
+			return err
+		} else {
+			mounts = append(mounts, statusMount)
+		}
+
+		if logsMount, err := d.volumes().provisionLogsVolume(ctx, nodeService.ServiceName, GetNestedLogsMountPath(nodeService.Name)); err != nil {
+			return fmt.Errorf("failed to provision volumes: %s", err)
+		} else {
+			mounts = append(mounts, logsMount)
+		}
+	}
+
+	sslEnabled := config.SSLCertificate != nil && config.SSLPrivateKey != nil
+	spec := getDockerNginxSpec(config.ContainerName, httpPort, sslPort, sslEnabled, secrets, mounts, []string{proxyNetwork})
+	spec.Config.Labels = getServiceLabels(config.ConfigHash)
+
+	return d.create(ctx, spec)
+}
+
+func (d *dockerEngineOrchestrator) getNetworks(ctx context.Context, serviceConfig *ServiceConfig) (networks []string, err error) {
+	var names []string
+	if serviceConfig.AllowAccessToSigner {
+		names = append(names, SHARED_SIGNER_NETWORK)
+	}
+
+	if serviceConfig.HTTPProxyNetworkEnabled {
+		names = append(names, SHARED_PROXY_NETWORK)
+	}
+
+	if serviceConfig.AllowAccessToServices {
+		names = append(names, SHARED_SERVICES_NETWORK)
+	}
+
+	for _, name := range names {
+		networkId, err := d.GetOverlayNetwork(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, networkId)
+	}
+
+	return
+}
+
+func getDockerVirtualChainSpec(serviceConfig *ServiceConfig, secrets []mount.Mount, mounts []mount.Mount, networks []string) *dockerContainerSpec {
+	spec := getDockerContainerSpec(serviceConfig, secrets, mounts, networks)
+	// the engine does not support restart delays, unlike swarm
+	spec.HostConfig.RestartPolicy = container.RestartPolicy{Name: "on-failure"}
+
+	return spec
+}
+
+func getDockerServiceSpec(serviceConfig *ServiceConfig, secrets []mount.Mount, mounts []mount.Mount, networks []string) *dockerContainerSpec {
+	spec := getDockerContainerSpec(serviceConfig, secrets, mounts, networks)
+	spec.HostConfig.RestartPolicy = container.RestartPolicy{Name: "unless-stopped"}
+
+	return spec
+}
+
+func getDockerContainerSpec(serviceConfig *ServiceConfig, secrets []mount.Mount, mounts []mount.Mount, networks []string) *dockerContainerSpec {
+	exposedPorts, portBindings := getDockerPorts(map[uint32]uint32{})
+	if serviceConfig.ExternalPort != 0 {
+		exposedPorts, portBindings = getDockerPorts(map[uint32]uint32{
+			uint32(serviceConfig.InternalPort): uint32(serviceConfig.ExternalPort),
+		})
+	}
+
+	return &dockerContainerSpec{
+		Name: serviceConfig.ContainerName,
+		Config: &container.Config{
+			Image:        serviceConfig.ImageName,
+			Cmd:          getServiceCommand(serviceConfig.ExecutablePath, getDockerSecretFilenames(secrets)),
+			ExposedPorts: exposedPorts,
+		},
+		HostConfig: &container.HostConfig{
+			Mounts:       append(secrets, mounts...),
+			PortBindings: portBindings,
+			Sysctls:      GetSysctls(),
+			Resources: getDockerResources(serviceConfig.LimitedMemory, serviceConfig.LimitedCPU,
+				serviceConfig.ReservedMemory),
+		},
+		Networks: networks,
+	}
+}
+
+func getDockerNginxSpec(containerName string, httpPort uint32, sslPort uint32, sslEnabled bool, secrets []mount.Mount, mounts []mount.Mount, networks []string) *dockerContainerSpec {
+	ports := map[uint32]uint32{
+		DEFAULT_HTTP_PORT: httpPort,
+	}
+
+	if sslEnabled {
+		ports[DEFAULT_SSL_PORT] = sslPort
+	}
+
+	exposedPorts, portBindings := getDockerPorts(ports)
+
+	return &dockerContainerSpec{
+		Name: containerName,
+		Config: &container.Config{
+			Image: "nginx:latest",
+			Cmd: []string{
+				"nginx", "-c", "/var/run/secrets/nginx.conf",
+			},
+			ExposedPorts: exposedPorts,
+		},
+		HostConfig: &container.HostConfig{
+			Mounts:        append(secrets, mounts...),
+			PortBindings:  portBindings,
+			Sysctls:       GetSysctls(),
+			Resources:     getDockerResources(512, 1, 0), // 512 mb, 1 cpu max
+			RestartPolicy: container.RestartPolicy{Name: "unless-stopped"},
+		},
+		Networks: networks,
+	}
+}
+
+// CPU reservations have no equivalent outside of swarm
+func getDockerResources(limitMemory int64, limitCPU float64, reserveMemory int64) container.Resources {
+	requirements := getResourceRequirements(limitMemory, limitCPU, reserveMemory, 0)
+
+	return container.Resources{
+		Memory:            requirements.Limits.MemoryBytes,
+		NanoCPUs:          requirements.Limits.NanoCPUs,
+		MemoryReservation: requirements.Reservations.MemoryBytes,
+	}
+}
+
+// maps container ports to host ports
+func getDockerPorts(ports map[uint32]uint32) (nat.PortSet, nat.PortMap) {
+	exposedPorts := make(nat.PortSet)
+	portBindings := make(nat.PortMap)
+
+	for containerPort, hostPort := range ports {
+		port := nat.Port(strconv.FormatUint(uint64(containerPort), 10) + "/tcp")
+		exposedPorts[port] = struct{}{}
+		portBindings[port] = []nat.PortBinding{
+			{HostPort: strconv.FormatUint(uint64(hostPort), 10)},
+		}
+	}
+
+	return exposedPorts, portBindings
+}
@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_getDockerVirtualChainSpec(t *testing.T) {
+	containerName := "node1-vchain-42"
+	secrets := []mount.Mount{
+		getDockerSecretMount("/var/lib/boyar/secrets/node1-vchain-42/config.json", "config.json"),
+	}
+	mounts := []mount.Mount{
+		{Source: "vol1"},
+		{Source: "vol2"},
+	}
+
+	serviceConfig := &ServiceConfig{
+		ImageName:     "orbsnetwork/node:experimental",
+		ContainerName: containerName,
+		InternalPort:  8800,
+		ExternalPort:  16160,
+	}
+
+	spec := getDockerVirtualChainSpec(serviceConfig, secrets, mounts, []string{"signer", "proxy"})
+
+	require.EqualValues(t, containerName, spec.Name)
+	require.EqualValues(t, []string{"signer", "proxy"}, spec.Networks)
+
+	require.EqualValues(t, &container.Config{
+		Image: "orbsnetwork/node:experimental",
+		Cmd: []string{
+			"/opt/orbs/service",
+			"--config", "/run/secrets/config.json",
+		},
+		ExposedPorts: nat.PortSet{
+			"8800/tcp": struct{}{},
+		},
+	}, spec.Config)
+
+	require.EqualValues(t, &container.HostConfig{
+		Mounts: []mount.Mount{
+			{
+				Type:     mount.TypeBind,
+				Source:   "/var/lib/boyar/secrets/node1-vchain-42/config.json",
+				Target:   "/run/secrets/config.json",
+				ReadOnly: true,
+			},
+			{Source: "vol1"},
+			{Source: "vol2"},
+		},
+		PortBindings: nat.PortMap{
+			"8800/tcp": []nat.PortBinding{{HostPort: "16160"}},
+		},
+		Sysctls: GetSysctls(),
+		Resources: container.Resources{
+			Memory:   3145728000,
+			NanoCPUs: 1000000000,
+		},
+		RestartPolicy: container.RestartPolicy{Name: "on-failure"},
+	}, spec.HostConfig)
+}
+
+func Test_getDockerServiceSpecWithoutExternalPort(t *testing.T) {
+	serviceConfig := &ServiceConfig{
+		ImageName:      "orbs:signer",
+		ContainerName:  "signer",
+		ExecutablePath: "/opt/orbs/orbs-signer",
+		InternalPort:   7777,
+		LimitedMemory:  1024,
+		ReservedMemory: 512,
+	}
+
+	spec := getDockerServiceSpec(serviceConfig, nil, nil, nil)
+
+	require.EqualValues(t, []string{"/opt/orbs/orbs-signer"}, spec.Config.Cmd)
+	require.Empty(t, spec.Config.ExposedPorts)
+	require.Empty(t, spec.HostConfig.PortBindings)
+	require.EqualValues(t, "unless-stopped", spec.HostConfig.RestartPolicy.Name)
+	require.EqualValues(t, 1024*MEGABYTE, spec.HostConfig.Resources.Memory)
+	require.EqualValues(t, 512*MEGABYTE, spec.HostConfig.Resources.MemoryReservation)
+}
+
+func Test_getDockerNginxSpec(t *testing.T) {
+	spec := getDockerNginxSpec("node123-proxy", 8080, 8443, false, nil, nil, []string{"proxy"})
+
+	require.EqualValues(t, "node123-proxy", spec.Name)
+	require.EqualValues(t, "nginx:latest", spec.Config.Image)
+	require.EqualValues(t, nat.PortMap{
+		"80/tcp": []nat.PortBinding{{HostPort: "8080"}},
+	}, spec.HostConfig.PortBindings)
+
+	sslSpec := getDockerNginxSpec("node123-proxy", 8080, 8443, true, nil, nil, []string{"proxy"})
+	require.EqualValues(t, nat.PortMap{
+		"80/tcp":  []nat.PortBinding{{HostPort: "8080"}},
+		"443/tcp": []nat.PortBinding{{HostPort: "8443"}},
+	}, sslSpec.HostConfig.PortBindings)
+}
+
+func Test_getDockerContainerError(t *testing.T) {
+	require.Empty(t, getDockerContainerError(nil))
+	require.Empty(t, getDockerContainerError(&types.ContainerState{Running: true}))
+	require.EqualValues(t, "non-zero exit (1)", getDockerContainerError(&types.ContainerState{ExitCode: 1}))
+	require.EqualValues(t, "non-zero exit (137): out of memory", getDockerContainerError(&types.ContainerState{ExitCode: 137, OOMKilled: true}))
+	require.EqualValues(t, "executable file not found", getDockerContainerError(&types.ContainerState{ExitCode: 127, Error: "executable file not found"}))
+}
+
+func Test_getDockerContainerState(t *testing.T) {
+	state, changedAt := getDockerContainerState(&types.ContainerState{
+		Status:     "exited",
+		StartedAt:  "2020-05-01T10:00:00.000000000Z",
+		FinishedAt: "2020-05-01T10:05:00.000000000Z",
+	})
+	require.Equal(t, "exited", state)
+	require.Equal(t, time.Date(2020, 5, 1, 10, 5, 0, 0, time.UTC), changedAt)
+
+	state, changedAt = getDockerContainerState(&types.ContainerState{
+		Status:     "running",
+		StartedAt:  "2020-05-01T10:10:00.000000000Z",
+		FinishedAt: "0001-01-01T00:00:00Z",
+	})
+	require.Equal(t, "running", state)
+	require.Equal(t, time.Date(2020, 5, 1, 10, 10, 0, 0, time.UTC), changedAt)
+}
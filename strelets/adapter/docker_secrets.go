@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"fmt"
+	"github.com/docker/docker/api/types/mount"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+const DOCKER_SECRETS_PATH = "/var/lib/boyar/secrets"
+const DOCKER_SECRETS_TARGET = "/run/secrets"
+
+type secretFile struct {
+	filename string
+	content  []byte
+}
+
+// Secrets are stored on the host in a directory only accessible by root and mounted read-only,
+// so the containers see them at the same paths as swarm secrets
+func storeDockerSecretFiles(secretsPath string, containerName string, files []secretFile) (mounts []mount.Mount, err error) {
+	dir := filepath.Join(secretsPath, containerName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create secrets directory %s: %s", dir, err)
+	}
+
+	for _, file := range files {
+		source := filepath.Join(dir, file.filename)
+		if err := ioutil.WriteFile(source, file.content, 0444); err != nil {
+			return nil, fmt.Errorf("could not store secret %s: %s", file.filename, err)
+		}
+
+		mounts = append(mounts, getDockerSecretMount(source, file.filename))
+	}
+
+	return mounts, nil
+}
+
+func getDockerSecretMount(source string, filename string) mount.Mount {
+	return mount.Mount{
+		Type:     mount.TypeBind,
+		Source:   source,
+		Target:   path.Join(DOCKER_SECRETS_TARGET, filename),
+		ReadOnly: true,
+	}
+}
+
+func getDockerSecretFilenames(secrets []mount.Mount) (filenames []string) {
+	for _, secret := range secrets {
+		filenames = append(filenames, path.Base(secret.Target))
+	}
+
+	return
+}
+
+func getVirtualChainSecretFiles(appConfig *AppConfig) []secretFile {
+	return []secretFile{
+		{"config.json", appConfig.Config},
+		{"keys.json", appConfig.KeyPair},
+		{"network.json", appConfig.Network},
+	}
+}
+
+func getServiceSecretFiles(appConfig *AppConfig) []secretFile {
+	files := []secretFile{
+		{"config.json", appConfig.Config},
+	}
+
+	if appConfig.KeyPair != nil {
+		files = append(files, secretFile{"keys.json", appConfig.KeyPair})
+	}
+
+	return files
+}
+
+func getNginxSecretFiles(config *ReverseProxyConfig) []secretFile {
+	files := []secretFile{
+		{NGINX_CONF, []byte(DEFAULT_NGINX_CONFIG)},
+		{VCHAINS_CONF, []byte(config.NginxConfig)},
+	}
+
+	if config.SSLCertificate != nil {
+		files = append(files, secretFile{SSL_CERT, config.SSLCertificate})
+	}
+
+	if config.SSLPrivateKey != nil {
+		files = append(files, secretFile{SSL_KEY, config.SSLPrivateKey})
+	}
+
+	return files
+}
+
+func getSecretFilenames(files []secretFile) (filenames []string) {
+	for _, file := range files {
+		filenames = append(filenames, file.filename)
+	}
+
+	return
+}
@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_storeDockerSecretFiles(t *testing.T) {
+	secretsPath, err := ioutil.TempDir("", "boyar-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(secretsPath)
+
+	mounts, err := storeDockerSecretFiles(secretsPath, "signer", getServiceSecretFiles(&AppConfig{
+		Config:  []byte(`{"api":"v1"}`),
+		KeyPair: []byte(`{"node-address":"a328846cd5b4979d68a8c58a9bdfeee657b34de7"}`),
+	}))
+	require.NoError(t, err)
+	require.Len(t, mounts, 2)
+
+	require.EqualValues(t, filepath.Join(secretsPath, "signer", "config.json"), mounts[0].Source)
+	require.EqualValues(t, "/run/secrets/config.json", mounts[0].Target)
+	require.True(t, mounts[0].ReadOnly)
+
+	require.EqualValues(t, []string{"config.json", "keys.json"}, getDockerSecretFilenames(mounts))
+
+	data, err := ioutil.ReadFile(mounts[1].Source)
+	require.NoError(t, err)
+	require.EqualValues(t, `{"node-address":"a328846cd5b4979d68a8c58a9bdfeee657b34de7"}`, string(data))
+
+	info, err := os.Stat(filepath.Join(secretsPath, "signer"))
+	require.NoError(t, err)
+	require.EqualValues(t, os.FileMode(0700), info.Mode().Perm())
+}
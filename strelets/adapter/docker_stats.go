@@ -0,0 +1,97 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// Docker takes two samples to calculate cpu usage, so the containers are queried in parallel
+func getDockerContainerStats(ctx context.Context, client *client.Client, containerNames map[string]string) (results []*ContainerStats, err error) {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var errors []error
+
+	for containerId, name := range containerNames {
+		wg.Add(1)
+		go func(containerId string, name string) {
+			defer wg.Done()
+
+			stats, statsErr := readDockerContainerStats(ctx, client, containerId)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if statsErr != nil {
+				errors = append(errors, fmt.Errorf("could not read stats of %s: %s", name, statsErr))
+			} else {
+				results = append(results, calculateContainerStats(name, stats))
+			}
+		}(containerId, name)
+	}
+
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	// partial results are still useful
+	if len(results) == 0 && len(errors) > 0 {
+		return nil, errors[0]
+	}
+
+	return results, nil
+}
+
+func readDockerContainerStats(ctx context.Context, client *client.Client, containerId string) (*types.StatsJSON, error) {
+	response, err := client.ContainerStats(ctx, containerId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	stats := &types.StatsJSON{}
+	if err := json.NewDecoder(response.Body).Decode(stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Same calculation as `docker stats`: cpu usage is relative to a single core, memory usage does not include page cache
+func calculateContainerStats(name string, stats *types.StatsJSON) *ContainerStats {
+	result := &ContainerStats{
+		Name:             name,
+		MemoryUsedBytes:  stats.MemoryStats.Usage,
+		MemoryLimitBytes: stats.MemoryStats.Limit,
+	}
+
+	if cache := stats.MemoryStats.Stats["cache"]; cache < result.MemoryUsedBytes {
+		result.MemoryUsedBytes -= cache
+	}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	if cpuDelta > 0 && systemDelta > 0 {
+		result.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	for _, network := range stats.Networks {
+		result.NetworkRxBytes += network.RxBytes
+		result.NetworkTxBytes += network.TxBytes
+	}
+
+	return result
+}
@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_calculateContainerStats(t *testing.T) {
+	stats := &types.StatsJSON{
+		Stats: types.Stats{
+			CPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 3000},
+				SystemUsage: 20000,
+				OnlineCPUs:  4,
+			},
+			PreCPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 1000},
+				SystemUsage: 10000,
+			},
+			MemoryStats: types.MemoryStats{
+				Usage: 300,
+				Limit: 1000,
+				Stats: map[string]uint64{"cache": 100},
+			},
+		},
+		Networks: map[string]types.NetworkStats{
+			"eth0": {RxBytes: 10, TxBytes: 20},
+			"eth1": {RxBytes: 1, TxBytes: 2},
+		},
+	}
+
+	require.Equal(t, &ContainerStats{
+		Name:             "chain-42",
+		CPUPercent:       80,
+		MemoryUsedBytes:  200,
+		MemoryLimitBytes: 1000,
+		NetworkRxBytes:   11,
+		NetworkTxBytes:   22,
+	}, calculateContainerStats("chain-42", stats))
+}
+
+func Test_calculateContainerStatsFallsBackToPerCPUUsage(t *testing.T) {
+	stats := &types.StatsJSON{
+		Stats: types.Stats{
+			CPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 3000, PercpuUsage: []uint64{1500, 1500}},
+				SystemUsage: 20000,
+			},
+			PreCPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 1000},
+				SystemUsage: 10000,
+			},
+		},
+	}
+
+	require.EqualValues(t, 40, calculateContainerStats("signer", stats).CPUPercent)
+}
@@ -0,0 +1,242 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/boyarin/utils"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"time"
+)
+
+type KubernetesOptions struct {
+	Namespace    string `json:"namespace"`
+	StorageClass string `json:"storage-class"`
+
+	// Writes manifests to RenderPath instead of applying them to the cluster
+	RenderOnly bool   `json:"render-only"`
+	RenderPath string `json:"render-path"`
+}
+
+type kubernetesOrchestrator struct {
+	client    kubernetes.Interface // nil if the objects are only rendered
+	namespace string
+	options   *OrchestratorOptions
+	logger    log.Logger
+}
+
+func NewKubernetes(options *OrchestratorOptions, logger log.Logger) (Orchestrator, error) {
+	if options == nil {
+		return nil, fmt.Errorf("orchestration options are empty, can't instantiate Kubernetes orchestrator")
+	}
+
+	if options.Kubernetes.RenderOnly {
+		if options.Kubernetes.RenderPath == "" {
+			return nil, fmt.Errorf("render path is required to render Kubernetes manifests")
+		}
+
+		return newKubernetesOrchestrator(nil, options.Kubernetes.Namespace, options, logger), nil
+	}
+
+	client, namespace, err := newKubernetesClient(options.Kubernetes.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKubernetesOrchestrator(client, namespace, options, logger), nil
+}
+
+func newKubernetesOrchestrator(client kubernetes.Interface, namespace string, options *OrchestratorOptions, logger log.Logger) *kubernetesOrchestrator {
+	return &kubernetesOrchestrator{client: client, namespace: namespace, options: options, logger: logger}
+}
+
+// images are pulled by the kubelet
+func (k *kubernetesOrchestrator) PullImage(ctx context.Context, imageName string) error {
+	return nil
+}
+
+func (k *kubernetesOrchestrator) apply(ctx context.Context, objects []kubernetesObject) error {
+	for _, object := range objects {
+		if err := k.applyObject(ctx, object); err != nil {
+			return errors.Wrapf(err, "failed applying %s %s", object.GetObjectKind().GroupVersionKind().Kind, object.GetName())
+		}
+	}
+
+	return nil
+}
+
+func (k *kubernetesOrchestrator) RunVirtualChain(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
+	workload := getKubernetesVirtualChainWorkload(serviceConfig, appConfig, k.options.Kubernetes.StorageClass)
+	return k.apply(ctx, getKubernetesWorkloadObjects(k.namespace, workload))
+}
+
+func (k *kubernetesOrchestrator) RunService(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
+	workload := getKubernetesServiceWorkload(serviceConfig, appConfig, k.options.Kubernetes.StorageClass)
+	return k.apply(ctx, getKubernetesWorkloadObjects(k.namespace, workload))
+}
+
+func (k *kubernetesOrchestrator) RunReverseProxy(ctx context.Context, config *ReverseProxyConfig) error {
+	httpPort := DEFAULT_HTTP_PORT
+	if config.HTTPPort != 0 {
+		httpPort = config.HTTPPort
+	}
+
+	sslPort := DEFAULT_SSL_PORT
+	if config.SSLPort != 0 {
+		sslPort = config.SSLPort
+	}
+
+	workload := getKubernetesReverseProxyWorkload(config, httpPort, sslPort, k.options.Kubernetes.StorageClass)
+	return k.apply(ctx, getKubernetesWorkloadObjects(k.namespace, workload))
+}
+
+// volumes are preserved, same as with other orchestrators
+func (k *kubernetesOrchestrator) RemoveService(ctx context.Context, containerName string) error {
+	var errors []error
+	for _, object := range []struct{ kind, name string }{
+		{"Deployment", getKubernetesName(containerName)},
+		{"Service", getKubernetesName(containerName)},
+		{"NetworkPolicy", getKubernetesPublicPolicyName(containerName)},
+		{"Secret", getKubernetesSecretName(containerName)},
+	} {
+		if err := k.deleteObject(ctx, object.kind, object.name); err != nil {
+			errors = append(errors, fmt.Errorf("failed to remove %s %s: %s", object.kind, object.name, err))
+		}
+	}
+
+	if len(errors) == 0 {
+		k.logger.Info(fmt.Sprintf("successfully removed deployment %s", containerName))
+	}
+
+	return utils.AggregateErrors(errors)
+}
+
+// Overlay networks are replaced with network policies that only allow traffic between pods with the same network label
+func (k *kubernetesOrchestrator) GetOverlayNetwork(ctx context.Context, name string) (string, error) {
+	if err := k.applyObject(ctx, getKubernetesNetworkPolicy(k.namespace, name)); err != nil {
+		return "", fmt.Errorf("could not create network policy %s: %s", name, err)
+	}
+
+	return name, nil
+}
+
+func (k *kubernetesOrchestrator) deleteClaims(ctx context.Context, claims []kubernetesClaim) error {
+	var errors []error
+	for _, claim := range claims {
+		if err := k.deleteObject(ctx, "PersistentVolumeClaim", getKubernetesName(claim.Name)); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return utils.AggregateErrors(errors)
+}
+
+// Deleting the claim releases the volume, and the data is destroyed if the storage class reclaim policy is Delete
+func (k *kubernetesOrchestrator) PurgeServiceData(ctx context.Context, containerName string) error {
+	return k.deleteClaims(ctx, getKubernetesServiceClaims(containerName, nil))
+}
+
+func (k *kubernetesOrchestrator) PurgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error {
+	claims := append(getKubernetesServiceClaims(containerName, nil), kubernetesClaim{Name: getVchainVolumeName(nodeAddress, vcId, "blocks")})
+	return k.deleteClaims(ctx, claims)
+}
+
+func (k *kubernetesOrchestrator) ListServices(ctx context.Context) (results []*RunningService, err error) {
+	deployments, err := k.listDeployments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve deployment list: %s", err)
+	}
+
+	for _, deployment := range deployments {
+		name := deployment.Name
+		if containerName := deployment.Annotations[KUBERNETES_CONTAINER_NAME_ANNOTATION]; containerName != "" {
+			name = containerName
+		}
+
+		results = append(results, &RunningService{
+			Name:       name,
+			ConfigHash: deployment.Annotations[KUBERNETES_CONFIG_HASH_ANNOTATION],
+		})
+	}
+
+	return
+}
+
+func (k *kubernetesOrchestrator) GetStatus(ctx context.Context, since time.Duration) (results []*ContainerStatus, err error) {
+	pods, err := k.listPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pod list: %s", err)
+	}
+
+	for _, pod := range pods {
+		logs, _ := k.getLogs(ctx, pod.Name, since+ERROR_LOGS_OVERLAP_MARGIN) // FIXME handle more errors
+
+		results = append(results, &ContainerStatus{
+			Name:      pod.Labels[KUBERNETES_NAME_LABEL],
+			NodeID:    pod.Spec.NodeName,
+			State:     string(pod.Status.Phase),
+			Error:     getKubernetesPodError(pod),
+			CreatedAt: pod.CreationTimestamp.Time,
+			Logs:      logs,
+
+			TaskState:    string(pod.Status.Phase),
+			RestartCount: getKubernetesPodRestartCount(pod),
+			ImageDigest:  getKubernetesPodImageDigest(pod),
+		})
+	}
+
+	return
+}
+
+func getKubernetesPodRestartCount(pod corev1.Pod) (restartCount int) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		restartCount += int(containerStatus.RestartCount)
+	}
+
+	return
+}
+
+func getKubernetesPodImageDigest(pod corev1.Pod) string {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if digest := GetImageDigest(containerStatus.ImageID); digest != "" {
+			return digest
+		}
+	}
+
+	return ""
+}
+
+// Resource usage requires metrics server, which is not always installed
+func (k *kubernetesOrchestrator) GetStats(ctx context.Context) ([]*ContainerStats, error) {
+	return nil, nil
+}
+
+func getKubernetesPodError(pod corev1.Pod) string {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if waiting := containerStatus.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "ContainerCreating" {
+			return fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+		}
+
+		if terminated := containerStatus.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return fmt.Sprintf("non-zero exit (%d): %s", terminated.ExitCode, terminated.Reason)
+		}
+	}
+
+	return pod.Status.Message
+}
+
+func (k *kubernetesOrchestrator) Info(ctx context.Context) (interface{}, error) {
+	if k.client == nil {
+		return map[string]interface{}{
+			"RenderPath": k.options.Kubernetes.RenderPath,
+		}, nil
+	}
+
+	return k.client.Discovery().ServerVersion()
+}
+
+func (k *kubernetesOrchestrator) Close() error {
+	return nil
+}
@@ -0,0 +1,249 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+const KUBERNETES_MANAGED_BY_SELECTOR = KUBERNETES_MANAGED_BY_LABEL + "=" + KUBERNETES_MANAGED_BY
+
+// Same configuration kubectl would use: $KUBECONFIG, ~/.kube/config or the service account of the pod boyar runs in
+func newKubernetesClient(namespace string) (kubernetes.Interface, string, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("could not load Kubernetes client configuration: %s", err)
+	}
+
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, "", fmt.Errorf("could not read Kubernetes namespace: %s", err)
+		}
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client, namespace, nil
+}
+
+// Existing objects are replaced, except for claims: their spec can not be changed once they are bound
+func (k *kubernetesOrchestrator) applyObject(ctx context.Context, object kubernetesObject) error {
+	if k.client == nil {
+		return renderKubernetesObject(k.options.Kubernetes.RenderPath, object)
+	}
+
+	switch o := object.(type) {
+	case *corev1.Secret:
+		secrets := k.client.CoreV1().Secrets(k.namespace)
+		return createOrUpdate(o, func() (metav1.Object, error) {
+			return secrets.Get(ctx, o.Name, metav1.GetOptions{})
+		}, func() (err error) {
+			_, err = secrets.Create(ctx, o, metav1.CreateOptions{})
+			return
+		}, func() (err error) {
+			_, err = secrets.Update(ctx, o, metav1.UpdateOptions{})
+			return
+		})
+	case *corev1.PersistentVolumeClaim:
+		claims := k.client.CoreV1().PersistentVolumeClaims(k.namespace)
+		return createOrUpdate(o, func() (metav1.Object, error) {
+			return claims.Get(ctx, o.Name, metav1.GetOptions{})
+		}, func() (err error) {
+			_, err = claims.Create(ctx, o, metav1.CreateOptions{})
+			return
+		}, nil)
+	case *appsv1.Deployment:
+		deployments := k.client.AppsV1().Deployments(k.namespace)
+		return createOrUpdate(o, func() (metav1.Object, error) {
+			return deployments.Get(ctx, o.Name, metav1.GetOptions{})
+		}, func() (err error) {
+			_, err = deployments.Create(ctx, o, metav1.CreateOptions{})
+			return
+		}, func() (err error) {
+			_, err = deployments.Update(ctx, o, metav1.UpdateOptions{})
+			return
+		})
+	case *corev1.Service:
+		services := k.client.CoreV1().Services(k.namespace)
+		return createOrUpdate(o, func() (metav1.Object, error) {
+			return services.Get(ctx, o.Name, metav1.GetOptions{})
+		}, func() (err error) {
+			_, err = services.Create(ctx, o, metav1.CreateOptions{})
+			return
+		}, func() (err error) {
+			_, err = services.Update(ctx, o, metav1.UpdateOptions{})
+			return
+		})
+	case *networkingv1.NetworkPolicy:
+		policies := k.client.NetworkingV1().NetworkPolicies(k.namespace)
+		return createOrUpdate(o, func() (metav1.Object, error) {
+			return policies.Get(ctx, o.Name, metav1.GetOptions{})
+		}, func() (err error) {
+			_, err = policies.Create(ctx, o, metav1.CreateOptions{})
+			return
+		}, func() (err error) {
+			_, err = policies.Update(ctx, o, metav1.UpdateOptions{})
+			return
+		})
+	}
+
+	return fmt.Errorf("unsupported kind %s", object.GetObjectKind().GroupVersionKind().Kind)
+}
+
+// Objects that already exist are kept as they are if update is nil
+func createOrUpdate(object metav1.Object, get func() (metav1.Object, error), create func() error, update func() error) error {
+	existing, err := get()
+	if apierrors.IsNotFound(err) {
+		return create()
+	} else if err != nil {
+		return err
+	}
+
+	if update == nil {
+		return nil
+	}
+
+	object.SetResourceVersion(existing.GetResourceVersion())
+	return update()
+}
+
+// Objects that are already gone are not an error
+func (k *kubernetesOrchestrator) deleteObject(ctx context.Context, kind string, name string) error {
+	if k.client == nil {
+		return removeRenderedKubernetesObject(k.options.Kubernetes.RenderPath, kind, name)
+	}
+
+	options := metav1.DeleteOptions{}
+
+	var err error
+	switch kind {
+	case "Secret":
+		err = k.client.CoreV1().Secrets(k.namespace).Delete(ctx, name, options)
+	case "PersistentVolumeClaim":
+		err = k.client.CoreV1().PersistentVolumeClaims(k.namespace).Delete(ctx, name, options)
+	case "Deployment":
+		err = k.client.AppsV1().Deployments(k.namespace).Delete(ctx, name, options)
+	case "Service":
+		err = k.client.CoreV1().Services(k.namespace).Delete(ctx, name, options)
+	case "NetworkPolicy":
+		err = k.client.NetworkingV1().NetworkPolicies(k.namespace).Delete(ctx, name, options)
+	default:
+		return fmt.Errorf("unsupported kind %s", kind)
+	}
+
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Rendered deployments are treated as running, otherwise they would be rendered again on every reconciliation
+func (k *kubernetesOrchestrator) listDeployments(ctx context.Context) ([]appsv1.Deployment, error) {
+	if k.client == nil {
+		return readRenderedKubernetesDeployments(k.options.Kubernetes.RenderPath)
+	}
+
+	list, err := k.client.AppsV1().Deployments(k.namespace).List(ctx, metav1.ListOptions{LabelSelector: KUBERNETES_MANAGED_BY_SELECTOR})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// Nothing runs if the objects are only rendered
+func (k *kubernetesOrchestrator) listPods(ctx context.Context) ([]corev1.Pod, error) {
+	if k.client == nil {
+		return nil, nil
+	}
+
+	list, err := k.client.CoreV1().Pods(k.namespace).List(ctx, metav1.ListOptions{LabelSelector: KUBERNETES_MANAGED_BY_SELECTOR})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+func (k *kubernetesOrchestrator) getLogs(ctx context.Context, podName string, since time.Duration) (string, error) {
+	if k.client == nil {
+		return "", nil
+	}
+
+	sinceSeconds := int64(since.Seconds())
+	logs, err := k.client.CoreV1().Pods(k.namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Timestamps:   true,
+		SinceSeconds: &sinceSeconds,
+	}).DoRaw(ctx)
+
+	return string(logs), err
+}
+
+func getRenderedKubernetesObjectPath(path string, kind string, name string) string {
+	return filepath.Join(path, strings.ToLower(kind)+"-"+name+".yaml")
+}
+
+// Same objects that would be applied to the cluster, written as manifests for kubectl apply or gitops
+func renderKubernetesObject(path string, object kubernetesObject) error {
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return fmt.Errorf("could not create render directory %s: %s", path, err)
+	}
+
+	return ioutil.WriteFile(getRenderedKubernetesObjectPath(path, object.GetObjectKind().GroupVersionKind().Kind, object.GetName()), data, 0600)
+}
+
+func removeRenderedKubernetesObject(path string, kind string, name string) error {
+	if err := os.Remove(getRenderedKubernetesObjectPath(path, kind, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func readRenderedKubernetesDeployments(path string) ([]appsv1.Deployment, error) {
+	files, err := filepath.Glob(getRenderedKubernetesObjectPath(path, "Deployment", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var deployments []appsv1.Deployment
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		deployment := appsv1.Deployment{}
+		if err := yaml.Unmarshal(data, &deployment); err != nil {
+			return nil, fmt.Errorf("could not parse rendered manifest %s: %s", file, err)
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, nil
+}
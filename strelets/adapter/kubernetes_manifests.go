@@ -0,0 +1,417 @@
+package adapter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const KUBERNETES_MANAGED_BY_LABEL = "app.kubernetes.io/managed-by"
+const KUBERNETES_NAME_LABEL = "app.kubernetes.io/name"
+const KUBERNETES_NETWORK_LABEL_PREFIX = "boyar.orbs.network/"
+const KUBERNETES_MANAGED_BY = "boyar"
+
+// Label values are limited to 63 characters, so the hash and the original container name are stored as annotations
+const KUBERNETES_CONFIG_HASH_ANNOTATION = "boyar.orbs.network/config-hash"
+const KUBERNETES_CONTAINER_NAME_ANNOTATION = "boyar.orbs.network/container-name"
+
+// In Gb, same defaults as DockerVolumes
+const KUBERNETES_BLOCKS_VOLUME_SIZE = "100Gi"
+const KUBERNETES_LOGS_VOLUME_SIZE = "2Gi"
+const KUBERNETES_SMALL_VOLUME_SIZE = "1Gi"
+
+// Typed object with its kind set, so that it is rendered the same way it is applied
+type kubernetesObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// Intermediate representation of a single container deployment shared by vchains, services and the reverse proxy
+type kubernetesWorkload struct {
+	Name         string
+	Image        string
+	Command      []string
+	Ports        []corev1.ContainerPort
+	Resources    corev1.ResourceRequirements
+	Networks     []string
+	Strategy     appsv1.DeploymentStrategyType
+	SecretsPath  string
+	Secrets      []secretFile
+	Claims       []kubernetesClaim
+	StorageClass string
+	ConfigHash   string
+}
+
+type kubernetesClaim struct {
+	Name       string
+	MountPath  string
+	AccessMode corev1.PersistentVolumeAccessMode
+	Size       string
+}
+
+var invalidKubernetesNameCharacters = regexp.MustCompile("[^a-z0-9-]+")
+
+func getKubernetesName(name string) string {
+	return strings.Trim(invalidKubernetesNameCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func getKubernetesLabels(name string) map[string]string {
+	return map[string]string{
+		KUBERNETES_NAME_LABEL:       getKubernetesName(name),
+		KUBERNETES_MANAGED_BY_LABEL: KUBERNETES_MANAGED_BY,
+	}
+}
+
+func getKubernetesNetworkLabel(network string) string {
+	return KUBERNETES_NETWORK_LABEL_PREFIX + network
+}
+
+func getKubernetesObjectMeta(namespace string, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      getKubernetesName(name),
+		Namespace: namespace,
+		Labels:    getKubernetesLabels(name),
+	}
+}
+
+func getKubernetesSecretName(name string) string {
+	return getKubernetesName(name) + "-secrets"
+}
+
+func getKubernetesPublicPolicyName(name string) string {
+	return getKubernetesName(name) + "-public"
+}
+
+func getKubernetesSecret(namespace string, name string, files []secretFile) *corev1.Secret {
+	data := make(map[string][]byte)
+	for _, file := range files {
+		data[file.filename] = file.content
+	}
+
+	return &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: getKubernetesObjectMeta(namespace, getKubernetesSecretName(name)),
+		Type:       corev1.SecretTypeOpaque,
+		Data:       data,
+	}
+}
+
+func getKubernetesPersistentVolumeClaim(namespace string, claim kubernetesClaim, storageClass string) *corev1.PersistentVolumeClaim {
+	var storageClassName *string
+	if storageClass != "" {
+		storageClassName = &storageClass
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		ObjectMeta: getKubernetesObjectMeta(namespace, claim.Name),
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{claim.AccessMode},
+			StorageClassName: storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(claim.Size),
+				},
+			},
+		},
+	}
+}
+
+func getKubernetesDeployment(namespace string, workload *kubernetesWorkload) *appsv1.Deployment {
+	podLabels := getKubernetesLabels(workload.Name)
+	for _, network := range workload.Networks {
+		podLabels[getKubernetesNetworkLabel(network)] = "true"
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name: "secrets",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: getKubernetesSecretName(workload.Name)},
+			},
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{Name: "secrets", MountPath: workload.SecretsPath, ReadOnly: true},
+	}
+
+	for i, claim := range workload.Claims {
+		volumeName := fmt.Sprintf("volume-%d", i)
+		volumes = append(volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: getKubernetesName(claim.Name)},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: volumeName, MountPath: claim.MountPath})
+	}
+
+	metadata := getKubernetesObjectMeta(namespace, workload.Name)
+	metadata.Annotations = map[string]string{
+		KUBERNETES_CONFIG_HASH_ANNOTATION:    workload.ConfigHash,
+		KUBERNETES_CONTAINER_NAME_ANNOTATION: workload.Name,
+	}
+
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metadata,
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: appsv1.DeploymentStrategy{Type: workload.Strategy},
+			Selector: &metav1.LabelSelector{MatchLabels: getKubernetesLabels(workload.Name)},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					// sysctls are not set because net.core.somaxconn is considered unsafe by the kubelet
+					Containers: []corev1.Container{
+						{
+							Name:         getKubernetesName(workload.Name),
+							Image:        workload.Image,
+							Command:      workload.Command,
+							Ports:        workload.Ports,
+							Resources:    workload.Resources,
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// Headless service gives the pod the same DNS name the container has inside the swarm overlay networks
+func getKubernetesService(namespace string, name string) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: getKubernetesObjectMeta(namespace, name),
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  getKubernetesLabels(name),
+		},
+	}
+}
+
+// Replaces a shared overlay network: only pods attached to the network can reach each other
+func getKubernetesNetworkPolicy(namespace string, network string) *networkingv1.NetworkPolicy {
+	selector := metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			getKubernetesNetworkLabel(network): "true",
+		},
+	}
+
+	return &networkingv1.NetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+		ObjectMeta: getKubernetesObjectMeta(namespace, network),
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: selector,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &selector}}},
+			},
+		},
+	}
+}
+
+// Replaces published ports: anyone can reach the ports exposed on the host
+func getKubernetesPublicNetworkPolicy(namespace string, name string, ports []corev1.ContainerPort) *networkingv1.NetworkPolicy {
+	var policyPorts []networkingv1.NetworkPolicyPort
+	for _, port := range ports {
+		if port.HostPort != 0 {
+			protocol, containerPort := port.Protocol, intstr.FromInt(int(port.ContainerPort))
+			policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &containerPort})
+		}
+	}
+
+	if len(policyPorts) == 0 {
+		return nil
+	}
+
+	return &networkingv1.NetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+		ObjectMeta: getKubernetesObjectMeta(namespace, getKubernetesPublicPolicyName(name)),
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: getKubernetesLabels(name)},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{Ports: policyPorts},
+			},
+		},
+	}
+}
+
+// Policies of the shared networks come first, the pod is isolated as soon as it starts
+func getKubernetesWorkloadObjects(namespace string, workload *kubernetesWorkload) (objects []kubernetesObject) {
+	for _, network := range workload.Networks {
+		objects = append(objects, getKubernetesNetworkPolicy(namespace, network))
+	}
+
+	objects = append(objects, getKubernetesSecret(namespace, workload.Name, workload.Secrets))
+
+	for _, claim := range workload.Claims {
+		objects = append(objects, getKubernetesPersistentVolumeClaim(namespace, claim, workload.StorageClass))
+	}
+
+	objects = append(objects, getKubernetesDeployment(namespace, workload), getKubernetesService(namespace, workload.Name))
+
+	if policy := getKubernetesPublicNetworkPolicy(namespace, workload.Name, workload.Ports); policy != nil {
+		objects = append(objects, policy)
+	}
+
+	return
+}
+
+func getKubernetesResources(limitMemory int64, limitCPU float64, reserveMemory int64, reserveCPU float64) corev1.ResourceRequirements {
+	requirements := getResourceRequirements(limitMemory, limitCPU, reserveMemory, reserveCPU)
+
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: *resource.NewQuantity(requirements.Limits.MemoryBytes, resource.BinarySI),
+			corev1.ResourceCPU:    *resource.NewMilliQuantity(requirements.Limits.NanoCPUs/1000000, resource.DecimalSI),
+		},
+	}
+
+	if requirements.Reservations.MemoryBytes != 0 || requirements.Reservations.NanoCPUs != 0 {
+		resources.Requests = make(corev1.ResourceList)
+	}
+
+	if requirements.Reservations.MemoryBytes != 0 {
+		resources.Requests[corev1.ResourceMemory] = *resource.NewQuantity(requirements.Reservations.MemoryBytes, resource.BinarySI)
+	}
+
+	if requirements.Reservations.NanoCPUs != 0 {
+		resources.Requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(requirements.Reservations.NanoCPUs/1000000, resource.DecimalSI)
+	}
+
+	return resources
+}
+
+func getKubernetesPorts(internalPort int, externalPort int) []corev1.ContainerPort {
+	if externalPort == 0 {
+		return nil
+	}
+
+	return []corev1.ContainerPort{
+		{ContainerPort: int32(internalPort), HostPort: int32(externalPort), Protocol: corev1.ProtocolTCP},
+	}
+}
+
+func getKubernetesServiceClaims(containerName string, logsMountPointNames map[string]string) []kubernetesClaim {
+	claims := []kubernetesClaim{
+		{getServiceVolumeName(containerName, "status"), ORBS_STATUS_TARGET, corev1.ReadWriteMany, KUBERNETES_SMALL_VOLUME_SIZE},
+		{getServiceVolumeName(containerName, "cache"), ORBS_CACHE_TARGET, corev1.ReadWriteOnce, KUBERNETES_SMALL_VOLUME_SIZE},
+	}
+
+	if len(logsMountPointNames) == 0 {
+		claims = append(claims, kubernetesClaim{getServiceVolumeName(containerName, "logs"), ORBS_LOGS_TARGET, corev1.ReadWriteMany, KUBERNETES_LOGS_VOLUME_SIZE})
+	} else {
+		for simpleName, namespacedName := range logsMountPointNames {
+			claims = append(claims, kubernetesClaim{getServiceVolumeName(namespacedName, "logs"), GetNestedLogsMountPath(simpleName), corev1.ReadWriteMany, KUBERNETES_LOGS_VOLUME_SIZE})
+		}
+	}
+
+	return claims
+}
+
+func getKubernetesServiceNetworks(serviceConfig *ServiceConfig) (networks []string) {
+	if serviceConfig.AllowAccessToSigner {
+		networks = append(networks, SHARED_SIGNER_NETWORK)
+	}
+
+	if serviceConfig.HTTPProxyNetworkEnabled {
+		networks = append(networks, SHARED_PROXY_NETWORK)
+	}
+
+	if serviceConfig.AllowAccessToServices {
+		networks = append(networks, SHARED_SERVICES_NETWORK)
+	}
+
+	return
+}
+
+func getKubernetesVirtualChainWorkload(serviceConfig *ServiceConfig, appConfig *AppConfig, storageClass string) *kubernetesWorkload {
+	secrets := getVirtualChainSecretFiles(appConfig)
+	claims := append(getKubernetesServiceClaims(serviceConfig.ContainerName, nil), kubernetesClaim{
+		getVchainVolumeName(serviceConfig.NodeAddress, serviceConfig.Id, "blocks"), ORBS_BLOCKS_TARGET, corev1.ReadWriteOnce, KUBERNETES_BLOCKS_VOLUME_SIZE,
+	})
+
+	return &kubernetesWorkload{
+		Name:    serviceConfig.ContainerName,
+		Image:   serviceConfig.ImageName,
+		Command: getServiceCommand(serviceConfig.ExecutablePath, getSecretFilenames(secrets)),
+		Ports:   getKubernetesPorts(serviceConfig.InternalPort, serviceConfig.ExternalPort),
+		Resources: getKubernetesResources(serviceConfig.LimitedMemory, serviceConfig.LimitedCPU,
+			serviceConfig.ReservedMemory, serviceConfig.ReservedCPU),
+		Networks:     getKubernetesServiceNetworks(serviceConfig),
+		Strategy:     appsv1.RecreateDeploymentStrategyType, // neither the blocks volume nor the host port can be shared between two pods
+		SecretsPath:  DOCKER_SECRETS_TARGET,
+		Secrets:      secrets,
+		Claims:       claims,
+		StorageClass: storageClass,
+		ConfigHash:   serviceConfig.ConfigHash,
+	}
+}
+
+func getKubernetesServiceWorkload(serviceConfig *ServiceConfig, appConfig *AppConfig, storageClass string) *kubernetesWorkload {
+	secrets := getServiceSecretFiles(appConfig)
+
+	return &kubernetesWorkload{
+		Name:    serviceConfig.ContainerName,
+		Image:   serviceConfig.ImageName,
+		Command: getServiceCommand(serviceConfig.ExecutablePath, getSecretFilenames(secrets)),
+		Ports:   getKubernetesPorts(serviceConfig.InternalPort, serviceConfig.ExternalPort),
+		Resources: getKubernetesResources(serviceConfig.LimitedMemory, serviceConfig.LimitedCPU,
+			serviceConfig.ReservedMemory, serviceConfig.ReservedCPU),
+		Networks:     getKubernetesServiceNetworks(serviceConfig),
+		Strategy:     appsv1.RecreateDeploymentStrategyType, // host port can not be bound by two pods on the same node
+		SecretsPath:  DOCKER_SECRETS_TARGET,
+		Secrets:      secrets,
+		Claims:       getKubernetesServiceClaims(serviceConfig.ContainerName, serviceConfig.LogsMountPointNames),
+		StorageClass: storageClass,
+		ConfigHash:   serviceConfig.ConfigHash,
+	}
+}
+
+func getKubernetesReverseProxyWorkload(config *ReverseProxyConfig, httpPort uint32, sslPort uint32, storageClass string) *kubernetesWorkload {
+	ports := []corev1.ContainerPort{
+		{ContainerPort: int32(DEFAULT_HTTP_PORT), HostPort: int32(httpPort), Protocol: corev1.ProtocolTCP},
+	}
+
+	if config.SSLCertificate != nil && config.SSLPrivateKey != nil {
+		ports = append(ports, corev1.ContainerPort{ContainerPort: int32(DEFAULT_SSL_PORT), HostPort: int32(sslPort), Protocol: corev1.ProtocolTCP})
+	}
+
+	var claims []kubernetesClaim
+	for _, nodeService := range config.Services {
+		claims = append(claims,
+			kubernetesClaim{getServiceVolumeName(nodeService.ServiceName, "status"), GetNginxStatusMountPath(nodeService.Name), corev1.ReadWriteMany, KUBERNETES_SMALL_VOLUME_SIZE},
+			kubernetesClaim{getServiceVolumeName(nodeService.ServiceName, "logs"), GetNestedLogsMountPath(nodeService.Name), corev1.ReadWriteMany, KUBERNETES_LOGS_VOLUME_SIZE},
+		)
+	}
+
+	return &kubernetesWorkload{
+		Name:  config.ContainerName,
+		Image: "nginx:latest",
+		Command: []string{
+			"nginx", "-c", "/var/run/secrets/nginx.conf",
+		},
+		Ports:        ports,
+		Resources:    getKubernetesResources(512, 1, 0, 0), // 512 mb, 1 cpu max
+		Networks:     []string{SHARED_PROXY_NETWORK},
+		Strategy:     appsv1.RecreateDeploymentStrategyType, // a new pod would never bind the host ports while the old one holds them
+		SecretsPath:  "/var/run/secrets",
+		Secrets:      getNginxSecretFiles(config),
+		Claims:       claims,
+		StorageClass: storageClass,
+		ConfigHash:   config.ConfigHash,
+	}
+}
@@ -0,0 +1,126 @@
+package adapter
+
+import (
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"testing"
+)
+
+func Test_getKubernetesName(t *testing.T) {
+	require.EqualValues(t, "orbs-network-chain-42-logs", getKubernetesName("orbs-network-chain-42-logs"))
+	require.EqualValues(t, "a1b2c3-chain-42", getKubernetesName("A1B2C3_chain.42"))
+	require.EqualValues(t, "http-api-reverse-proxy", getKubernetesName("/http-api-reverse-proxy-"))
+}
+
+func Test_getKubernetesVirtualChainWorkload(t *testing.T) {
+	serviceConfig := &ServiceConfig{
+		Id:                  42,
+		NodeAddress:         "a1b2c3",
+		ImageName:           "orbsnetwork/node:experimental",
+		ContainerName:       "a1b2c3-chain-42",
+		InternalPort:        8080,
+		ExternalPort:        16160,
+		LimitedMemory:       1024,
+		LimitedCPU:          1,
+		AllowAccessToSigner: true,
+	}
+
+	appConfig := &AppConfig{
+		KeyPair: []byte("keys"),
+		Config:  []byte("config"),
+		Network: []byte("network"),
+	}
+
+	workload := getKubernetesVirtualChainWorkload(serviceConfig, appConfig, "ssd")
+	objects := getKubernetesWorkloadObjects("orbs", workload)
+
+	var kinds []string
+	for _, object := range objects {
+		require.EqualValues(t, "orbs", object.GetNamespace())
+		kinds = append(kinds, object.GetObjectKind().GroupVersionKind().Kind)
+	}
+	require.EqualValues(t, []string{"NetworkPolicy", "Secret",
+		"PersistentVolumeClaim", "PersistentVolumeClaim", "PersistentVolumeClaim", "PersistentVolumeClaim",
+		"Deployment", "Service", "NetworkPolicy"}, kinds)
+
+	require.EqualValues(t, "signer-overlay", objects[0].GetName())
+
+	secret := objects[1].(*corev1.Secret)
+	require.EqualValues(t, "a1b2c3-chain-42-secrets", secret.Name)
+	require.EqualValues(t, map[string][]byte{
+		"keys.json":    []byte("keys"),
+		"config.json":  []byte("config"),
+		"network.json": []byte("network"),
+	}, secret.Data)
+
+	blocks := objects[5].(*corev1.PersistentVolumeClaim)
+	require.EqualValues(t, "a1b2c3-42-blocks", blocks.Name)
+	require.EqualValues(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, blocks.Spec.AccessModes)
+	require.EqualValues(t, "ssd", *blocks.Spec.StorageClassName)
+	require.EqualValues(t, resource.MustParse(KUBERNETES_BLOCKS_VOLUME_SIZE), blocks.Spec.Resources.Requests[corev1.ResourceStorage])
+
+	deployment := objects[6].(*appsv1.Deployment)
+	require.EqualValues(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	require.EqualValues(t, "true", deployment.Spec.Template.Labels["boyar.orbs.network/signer-overlay"])
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	require.EqualValues(t, []string{
+		"/opt/orbs/service",
+		"--config", "/run/secrets/config.json",
+		"--config", "/run/secrets/keys.json",
+		"--config", "/run/secrets/network.json",
+	}, container.Command)
+	require.EqualValues(t, []corev1.ContainerPort{{ContainerPort: 8080, HostPort: 16160, Protocol: corev1.ProtocolTCP}}, container.Ports)
+	require.EqualValues(t, "1Gi", container.Resources.Limits.Memory().String())
+	require.EqualValues(t, "1", container.Resources.Limits.Cpu().String())
+	require.EqualValues(t, corev1.VolumeMount{Name: "secrets", MountPath: "/run/secrets", ReadOnly: true}, container.VolumeMounts[0])
+	require.EqualValues(t, corev1.VolumeMount{Name: "volume-3", MountPath: ORBS_BLOCKS_TARGET}, container.VolumeMounts[4])
+
+	public := objects[8].(*networkingv1.NetworkPolicy)
+	require.EqualValues(t, "a1b2c3-chain-42-public", public.Name)
+
+	protocol, port := corev1.ProtocolTCP, intstr.FromInt(8080)
+	require.EqualValues(t, []networkingv1.NetworkPolicyPort{{Protocol: &protocol, Port: &port}}, public.Spec.Ingress[0].Ports)
+}
+
+func Test_getKubernetesWorkloadObjectsWithoutPublicPorts(t *testing.T) {
+	serviceConfig := &ServiceConfig{
+		ImageName:               "orbsnetwork/signer:experimental",
+		ContainerName:           "signer-service",
+		InternalPort:            7777,
+		HTTPProxyNetworkEnabled: true,
+	}
+
+	workload := getKubernetesServiceWorkload(serviceConfig, &AppConfig{Config: []byte("{}")}, "")
+	objects := getKubernetesWorkloadObjects("", workload)
+
+	require.IsType(t, &corev1.Service{}, objects[len(objects)-1])
+	require.EqualValues(t, []string{SHARED_PROXY_NETWORK}, workload.Networks)
+	require.Nil(t, objects[2].(*corev1.PersistentVolumeClaim).Spec.StorageClassName)
+}
+
+func Test_getKubernetesNetworkPolicy(t *testing.T) {
+	policy := getKubernetesNetworkPolicy("orbs", SHARED_SIGNER_NETWORK)
+
+	selector := metav1.LabelSelector{MatchLabels: map[string]string{"boyar.orbs.network/signer-overlay": "true"}}
+	require.EqualValues(t, networkingv1.NetworkPolicySpec{
+		PodSelector: selector,
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{
+			{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &selector}}},
+		},
+	}, policy.Spec)
+}
+
+func Test_getKubernetesReverseProxyWorkloadIsRecreated(t *testing.T) {
+	workload := getKubernetesReverseProxyWorkload(&ReverseProxyConfig{ContainerName: "http-api-reverse-proxy"}, DEFAULT_HTTP_PORT, DEFAULT_SSL_PORT, "")
+	deployment := getKubernetesDeployment("orbs", workload)
+
+	require.EqualValues(t, int32(80), deployment.Spec.Template.Spec.Containers[0].Ports[0].HostPort)
+	require.EqualValues(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+}
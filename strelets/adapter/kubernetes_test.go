@@ -0,0 +1,273 @@
+package adapter
+
+import (
+	"context"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// verb, kind and name of every call that changed the cluster
+func getKubernetesChanges(client *fake.Clientset) (changes []string) {
+	for _, action := range client.Actions() {
+		resource := action.GetResource().Resource
+		switch action.GetVerb() {
+		case "create", "update":
+			object := action.(k8stesting.CreateAction).GetObject().(kubernetesObject)
+			changes = append(changes, action.GetVerb()+" "+resource+"/"+object.GetName())
+		case "delete":
+			changes = append(changes, "delete "+resource+"/"+action.(k8stesting.DeleteAction).GetName())
+		}
+	}
+
+	return
+}
+
+func TestKubernetesOrchestrator_RunReverseProxy(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	orchestrator := newKubernetesOrchestrator(client, "orbs", &OrchestratorOptions{}, log.GetLogger())
+
+	config := &ReverseProxyConfig{
+		ContainerName: "http-api-reverse-proxy",
+		NginxConfig:   "server { listen 80; }",
+	}
+	require.NoError(t, orchestrator.RunReverseProxy(context.Background(), config))
+
+	require.EqualValues(t, []string{
+		"create networkpolicies/http-proxy-overlay",
+		"create secrets/http-api-reverse-proxy-secrets",
+		"create deployments/http-api-reverse-proxy",
+		"create services/http-api-reverse-proxy",
+		"create networkpolicies/http-api-reverse-proxy-public",
+	}, getKubernetesChanges(client))
+
+	client.ClearActions()
+	config.NginxConfig = "server { listen 8080; }"
+	require.NoError(t, orchestrator.RunReverseProxy(context.Background(), config))
+	require.Contains(t, getKubernetesChanges(client), "update secrets/http-api-reverse-proxy-secrets")
+
+	secret, err := client.CoreV1().Secrets("orbs").Get(context.Background(), "http-api-reverse-proxy-secrets", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.EqualValues(t, "server { listen 8080; }", secret.Data["vchains.conf"])
+}
+
+func TestKubernetesOrchestrator_KeepsExistingClaims(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	orchestrator := newKubernetesOrchestrator(client, "orbs", &OrchestratorOptions{}, log.GetLogger())
+
+	serviceConfig := &ServiceConfig{
+		ImageName:     "orbsnetwork/signer:experimental",
+		ContainerName: "signer-service",
+		InternalPort:  7777,
+		ConfigHash:    "a1b2c3",
+	}
+	require.NoError(t, orchestrator.RunService(context.Background(), serviceConfig, &AppConfig{Config: []byte("{}")}))
+
+	client.ClearActions()
+	serviceConfig.ConfigHash = "d4e5f6"
+	require.NoError(t, orchestrator.RunService(context.Background(), serviceConfig, &AppConfig{Config: []byte("{}")}))
+
+	require.EqualValues(t, []string{
+		"update secrets/signer-service-secrets",
+		"update deployments/signer-service",
+		"update services/signer-service",
+	}, getKubernetesChanges(client))
+
+	services, err := orchestrator.ListServices(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, []*RunningService{{Name: "signer-service", ConfigHash: "d4e5f6"}}, services)
+}
+
+func TestKubernetesOrchestrator_RemoveServiceAndPurge(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	orchestrator := newKubernetesOrchestrator(client, "orbs", &OrchestratorOptions{}, log.GetLogger())
+
+	require.NoError(t, orchestrator.RemoveService(context.Background(), "a1b2c3-chain-42"))
+	require.EqualValues(t, []string{
+		"delete deployments/a1b2c3-chain-42",
+		"delete services/a1b2c3-chain-42",
+		"delete networkpolicies/a1b2c3-chain-42-public",
+		"delete secrets/a1b2c3-chain-42-secrets",
+	}, getKubernetesChanges(client))
+
+	client.ClearActions()
+	require.NoError(t, orchestrator.PurgeVirtualChainData(context.Background(), "a1b2c3", 42, "a1b2c3-chain-42"))
+	require.EqualValues(t, []string{
+		"delete persistentvolumeclaims/a1b2c3-chain-42-status",
+		"delete persistentvolumeclaims/a1b2c3-chain-42-cache",
+		"delete persistentvolumeclaims/a1b2c3-chain-42-logs",
+		"delete persistentvolumeclaims/a1b2c3-42-blocks",
+	}, getKubernetesChanges(client))
+}
+
+func TestKubernetesOrchestrator_GetStatus(t *testing.T) {
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "signer-service-5d8f7",
+			Namespace: "orbs",
+			Labels:    map[string]string{KUBERNETES_NAME_LABEL: "signer-service", KUBERNETES_MANAGED_BY_LABEL: KUBERNETES_MANAGED_BY},
+		},
+		Spec:   corev1.PodSpec{NodeName: "node1"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	crashing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "a1b2c3-chain-42-7c9d1",
+			Namespace: "orbs",
+			Labels:    map[string]string{KUBERNETES_NAME_LABEL: "a1b2c3-chain-42", KUBERNETES_MANAGED_BY_LABEL: KUBERNETES_MANAGED_BY},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					RestartCount: 3,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{
+							Reason:  "CrashLoopBackOff",
+							Message: "back-off 5m0s restarting failed container",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	unmanaged := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress-controller", Namespace: "orbs"},
+	}
+
+	client := fake.NewSimpleClientset(running, crashing, unmanaged)
+	orchestrator := newKubernetesOrchestrator(client, "orbs", &OrchestratorOptions{}, log.GetLogger())
+
+	status, err := orchestrator.GetStatus(context.Background(), time.Minute)
+	require.NoError(t, err)
+	require.Len(t, status, 2)
+
+	require.EqualValues(t, "a1b2c3-chain-42", status[0].Name)
+	require.EqualValues(t, "CrashLoopBackOff: back-off 5m0s restarting failed container", status[0].Error)
+	require.EqualValues(t, 3, status[0].RestartCount)
+
+	require.EqualValues(t, "signer-service", status[1].Name)
+	require.EqualValues(t, "node1", status[1].NodeID)
+	require.EqualValues(t, "Running", status[1].State)
+	require.Empty(t, status[1].Error)
+	require.EqualValues(t, "fake logs", status[1].Logs)
+}
+
+func TestKubernetesOrchestrator_RenderOnly(t *testing.T) {
+	renderPath, err := ioutil.TempDir("", "kubernetes")
+	require.NoError(t, err)
+	defer os.RemoveAll(renderPath)
+
+	orchestrator, err := NewOrchestrator(&OrchestratorOptions{
+		Backend: KUBERNETES_BACKEND,
+		Kubernetes: KubernetesOptions{
+			Namespace:  "orbs",
+			RenderOnly: true,
+			RenderPath: renderPath,
+		},
+	}, log.GetLogger())
+	require.NoError(t, err)
+
+	err = orchestrator.RunService(context.Background(), &ServiceConfig{
+		ImageName:     "orbsnetwork/signer:experimental",
+		ContainerName: "signer-service",
+		InternalPort:  7777,
+		ConfigHash:    "a1b2c3",
+	}, &AppConfig{Config: []byte("{}")})
+	require.NoError(t, err)
+
+	services, err := orchestrator.ListServices(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, []*RunningService{{Name: "signer-service", ConfigHash: "a1b2c3"}}, services)
+
+	deployment, err := ioutil.ReadFile(filepath.Join(renderPath, "deployment-signer-service.yaml"))
+	require.NoError(t, err)
+	require.Contains(t, string(deployment), "kind: Deployment")
+	require.Contains(t, string(deployment), "namespace: orbs")
+	require.Contains(t, string(deployment), "image: orbsnetwork/signer:experimental")
+
+	// the rendered manifest is the object that would be applied
+	client := fake.NewSimpleClientset()
+	require.NoError(t, newKubernetesOrchestrator(client, "orbs", &OrchestratorOptions{}, log.GetLogger()).RunService(context.Background(), &ServiceConfig{
+		ImageName:     "orbsnetwork/signer:experimental",
+		ContainerName: "signer-service",
+		InternalPort:  7777,
+		ConfigHash:    "a1b2c3",
+	}, &AppConfig{Config: []byte("{}")}))
+
+	rendered, err := readRenderedKubernetesDeployments(renderPath)
+	require.NoError(t, err)
+	applied, err := client.AppsV1().Deployments("orbs").Get(context.Background(), "signer-service", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, rendered, 1)
+	require.True(t, equality.Semantic.DeepEqual(*applied, rendered[0]), "rendered deployment should be the one applied")
+
+	require.NoError(t, orchestrator.RemoveService(context.Background(), "signer-service"))
+	_, err = os.Stat(filepath.Join(renderPath, "deployment-signer-service.yaml"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestKubernetesOrchestrator_RenderOnlyAppliesPoliciesOfEveryNetwork(t *testing.T) {
+	renderPath, err := ioutil.TempDir("", "kubernetes")
+	require.NoError(t, err)
+	defer os.RemoveAll(renderPath)
+
+	orchestrator, err := NewKubernetes(&OrchestratorOptions{
+		Kubernetes: KubernetesOptions{RenderOnly: true, RenderPath: renderPath},
+	}, log.GetLogger())
+	require.NoError(t, err)
+
+	err = orchestrator.RunVirtualChain(context.Background(), &ServiceConfig{
+		Id:                    42,
+		NodeAddress:           "a1b2c3",
+		ImageName:             "orbsnetwork/node:experimental",
+		ContainerName:         "a1b2c3-chain-42",
+		AllowAccessToSigner:   true,
+		AllowAccessToServices: true,
+	}, &AppConfig{Config: []byte("{}")})
+	require.NoError(t, err)
+
+	err = orchestrator.RunService(context.Background(), &ServiceConfig{
+		ImageName:               "orbsnetwork/management-service:experimental",
+		ContainerName:           "management-service",
+		HTTPProxyNetworkEnabled: true,
+	}, &AppConfig{Config: []byte("{}")})
+	require.NoError(t, err)
+
+	policies, err := filepath.Glob(getRenderedKubernetesObjectPath(renderPath, "NetworkPolicy", "*"))
+	require.NoError(t, err)
+
+	var names []string
+	for _, policy := range policies {
+		names = append(names, filepath.Base(policy))
+	}
+	require.ElementsMatch(t, []string{
+		"networkpolicy-signer-overlay.yaml",
+		"networkpolicy-services-overlay.yaml",
+		"networkpolicy-http-proxy-overlay.yaml",
+	}, names)
+
+	data, err := ioutil.ReadFile(filepath.Join(renderPath, "networkpolicy-services-overlay.yaml"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "boyar.orbs.network/services-overlay: \"true\"")
+
+	deployment, err := ioutil.ReadFile(filepath.Join(renderPath, "deployment-a1b2c3-chain-42.yaml"))
+	require.NoError(t, err)
+	require.Contains(t, string(deployment), "boyar.orbs.network/services-overlay: \"true\"")
+}
+
+func TestNewKubernetesRequiresRenderPath(t *testing.T) {
+	_, err := NewKubernetes(&OrchestratorOptions{Kubernetes: KubernetesOptions{RenderOnly: true}}, log.GetLogger())
+	require.EqualError(t, err, "render path is required to render Kubernetes manifests")
+}
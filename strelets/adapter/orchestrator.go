@@ -2,9 +2,14 @@ package adapter
 
 import (
 	"context"
+	"fmt"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/orbs-network/boyarin/snapshot"
+	"github.com/orbs-network/scribe/log"
 	"io"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,6 +17,13 @@ const DOCKER_API_VERSION = "1.40"
 
 const PROXY_CONTAINER_NAME = "http-api-reverse-proxy"
 
+const SWARM_BACKEND = "swarm"
+const DOCKER_BACKEND = "docker"
+const KUBERNETES_BACKEND = "kubernetes"
+
+// Hash of the configuration the service was started with, used to reconcile running services with the desired state
+const CONFIG_HASH_LABEL = "network.orbs.boyar.config-hash"
+
 type AppConfig struct {
 	KeyPair []byte
 	Network []byte
@@ -43,6 +55,8 @@ type ServiceConfig struct {
 
 	// logs service only
 	LogsMountPointNames map[string]string // simple name -> namespaced name
+
+	ConfigHash string
 }
 
 type ContainerDebugStatus struct {
@@ -60,6 +74,36 @@ type ContainerStatus struct {
 	Debug ContainerDebugStatus
 
 	CreatedAt time.Time
+
+	TaskState      string    // running, failed, exited, etc
+	StateChangedAt time.Time // zero if unknown
+	RestartCount   int       // on swarm, failed tasks still kept in the task history
+
+	ImageDigest string // sha256:<hex> the container is running, empty if unknown
+}
+
+// Resource usage of a running container, only available for containers on the same machine
+type ContainerStats struct {
+	Name             string
+	CPUPercent       float64
+	MemoryUsedBytes  uint64
+	MemoryLimitBytes uint64
+	NetworkRxBytes   uint64
+	NetworkTxBytes   uint64
+}
+
+type RunningService struct {
+	Name       string
+	ConfigHash string // empty if the service was not started by boyar
+}
+
+// Returns the digest part of image references like name:tag@sha256:<hex> or docker-pullable://name@sha256:<hex>
+func GetImageDigest(imageReference string) string {
+	if i := strings.LastIndex(imageReference, "@"); i >= 0 && strings.HasPrefix(imageReference[i+1:], "sha256:") {
+		return imageReference[i+1:]
+	}
+
+	return ""
 }
 
 type Orchestrator interface {
@@ -74,34 +118,122 @@ type Orchestrator interface {
 	GetOverlayNetwork(ctx context.Context, name string) (string, error)
 
 	GetStatus(ctx context.Context, since time.Duration) ([]*ContainerStatus, error)
+	GetStats(ctx context.Context) ([]*ContainerStats, error)
+
+	ListServices(ctx context.Context) ([]*RunningService, error)
 
 	PurgeServiceData(ctx context.Context, containerName string) error
 	PurgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error
 
+	// Both stop the virtual chain first, provisioning starts it again
+	SnapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error
+	RestoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error
+
+	// Starts unpacking the archive into an empty blocks volume in the background and reports the outcome to progress,
+	// returns false if there was data already
+	BootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, source *snapshot.Source, progress *snapshot.Progress) (bool, error)
+
 	Info(ctx context.Context) (interface{}, error)
 
 	io.Closer
 }
 
 type DynamicManagementConfig struct {
-	Url string
+	Url          string
+	SignatureUrl string
+	TrustedKeys  []string
+}
+
+type ExecutableBinary struct {
+	Url    string
+	Sha256 string
 }
 
 type ExecutableImageOptions struct {
 	Url    string
 	Sha256 string
+
+	// By GOOS/GOARCH (for example linux/arm64), replaces Url and Sha256 if set: platforms that are not listed are not updated
+	Binaries map[string]ExecutableBinary
+
+	// Signed update manifest, takes precedence over Url and Sha256
+	ManifestUrl string
+
+	// Share of nodes (percent) that take the update, chosen deterministically by node address; all nodes if zero
+	RolloutPercentage int
+	// UTC hours the update could be applied in, for example "2-5,14" (ranges could wrap around midnight); any time if empty
+	MaintenanceWindow string
+	// Nodes running an older version update right away, regardless of rollout percentage and maintenance window
+	MinVersion string
+}
+
+// Binary for the platform, false if there is none; Url and Sha256 might not be built for it, so they are only used without Binaries
+func (o ExecutableImageOptions) Binary(platform string) (ExecutableBinary, bool) {
+	if len(o.Binaries) > 0 {
+		binary, found := o.Binaries[platform]
+		return binary, found
+	}
+
+	return ExecutableBinary{Url: o.Url, Sha256: o.Sha256}, o.Url != ""
+}
+
+// Hours of the day (UTC) the update is allowed in, all of them if the maintenance window is empty
+func (o ExecutableImageOptions) MaintenanceHours() (hours [24]bool, err error) {
+	if strings.TrimSpace(o.MaintenanceWindow) == "" || strings.TrimSpace(o.MaintenanceWindow) == "*" {
+		for hour := range hours {
+			hours[hour] = true
+		}
+		return hours, nil
+	}
+
+	for _, part := range strings.Split(o.MaintenanceWindow, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		from, err := parseHour(bounds[0])
+		if err != nil {
+			return hours, err
+		}
+
+		to := from
+		if len(bounds) == 2 {
+			if to, err = parseHour(bounds[1]); err != nil {
+				return hours, err
+			}
+		}
+
+		for hour := from; ; hour = (hour + 1) % 24 {
+			hours[hour] = true
+			if hour == to {
+				break
+			}
+		}
+	}
+
+	return hours, nil
+}
+
+func parseHour(value string) (int, error) {
+	hour, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("invalid hour %q, should be between 0 and 23", value)
+	}
+
+	return hour, nil
 }
 
 type OrchestratorOptions struct {
+	Backend                string            `json:"backend"`
 	StorageDriver          string            `json:"storage-driver"`
 	StorageMountType       string            `json:"storage-mount-type"`
 	StorageOptions         map[string]string `json:"storage-options"`
 	MaxReloadTimedDelayStr string            `json:"max-reload-time-delay"`
+	UpdateMonitorWindowStr string            `json:"update-monitor-window"`
 
 	DynamicManagementConfig DynamicManagementConfig
 
 	ExecutableImage ExecutableImageOptions
 
+	Kubernetes KubernetesOptions `json:"kubernetes"`
+
 	// Testing purposes
 	HTTPPort uint32 `json:"http-port"`
 	SSLPort  uint32 `json:"ssl-port"`
@@ -124,3 +256,37 @@ func (s OrchestratorOptions) MaxReloadTimedDelay() time.Duration {
 	d, _ := time.ParseDuration(s.MaxReloadTimedDelayStr)
 	return d
 }
+
+const DEFAULT_UPDATE_MONITOR_WINDOW = 1 * time.Minute
+
+// How long an updated task should keep running before the update is considered successful
+func (s OrchestratorOptions) UpdateMonitorWindow() time.Duration {
+	if d, err := time.ParseDuration(s.UpdateMonitorWindowStr); err == nil && d > 0 {
+		return d
+	}
+
+	return DEFAULT_UPDATE_MONITOR_WINDOW
+}
+
+func getServiceLabels(configHash string) map[string]string {
+	return map[string]string{
+		CONFIG_HASH_LABEL: configHash,
+	}
+}
+
+func NewOrchestrator(options *OrchestratorOptions, logger log.Logger) (Orchestrator, error) {
+	if options == nil {
+		return nil, fmt.Errorf("orchestration options are empty, can't instantiate orchestrator")
+	}
+
+	switch options.Backend {
+	case "", SWARM_BACKEND:
+		return NewDockerSwarm(options, logger)
+	case DOCKER_BACKEND:
+		return NewDockerEngine(options, logger)
+	case KUBERNETES_BACKEND:
+		return NewKubernetes(options, logger)
+	default:
+		return nil, fmt.Errorf("unknown orchestrator backend %s", options.Backend)
+	}
+}
@@ -3,6 +3,7 @@ package adapter
 import (
 	"context"
 	"github.com/docker/docker/api/types"
+	"github.com/orbs-network/boyarin/snapshot"
 	"github.com/stretchr/testify/mock"
 	"time"
 )
@@ -41,6 +42,16 @@ func (a *OrchestratorMock) GetStatus(ctx context.Context, since time.Duration) (
 	return res.Get(0).([]*ContainerStatus), res.Error(1)
 }
 
+func (a *OrchestratorMock) GetStats(ctx context.Context) ([]*ContainerStats, error) {
+	res := a.MethodCalled("GetStats", ctx)
+	return res.Get(0).([]*ContainerStats), res.Error(1)
+}
+
+func (a *OrchestratorMock) ListServices(ctx context.Context) ([]*RunningService, error) {
+	res := a.MethodCalled("ListServices", ctx)
+	return res.Get(0).([]*RunningService), res.Error(1)
+}
+
 func (a *OrchestratorMock) RunService(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
 	res := a.MethodCalled("RunService", ctx, serviceConfig, appConfig)
 	return res.Error(0)
@@ -61,6 +72,21 @@ func (a *OrchestratorMock) PurgeVirtualChainData(ctx context.Context, nodeAddres
 	return res.Error(1)
 }
 
+func (a *OrchestratorMock) SnapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	res := a.MethodCalled("SnapshotVirtualChainData", ctx, nodeAddress, vcId, containerName, archivePath)
+	return res.Error(0)
+}
+
+func (a *OrchestratorMock) RestoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	res := a.MethodCalled("RestoreVirtualChainData", ctx, nodeAddress, vcId, containerName, archivePath)
+	return res.Error(0)
+}
+
+func (a *OrchestratorMock) BootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, source *snapshot.Source, progress *snapshot.Progress) (bool, error) {
+	res := a.MethodCalled("BootstrapVirtualChainData", ctx, nodeAddress, vcId, containerName, source, progress)
+	return res.Bool(0), res.Error(1)
+}
+
 func (a *OrchestratorMock) Info(ctx context.Context) (interface{}, error) {
 	return types.Info{
 		ServerVersion: "Mock",
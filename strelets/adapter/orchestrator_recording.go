@@ -0,0 +1,240 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/orbs-network/boyarin/snapshot"
+)
+
+const (
+	PLAN_CREATE = "create"
+	PLAN_UPDATE = "update"
+	PLAN_REMOVE = "remove"
+	PLAN_PURGE  = "purge"
+	PLAN_PULL   = "pull"
+
+	PLAN_SNAPSHOT  = "snapshot"
+	PLAN_RESTORE   = "restore"
+	PLAN_BOOTSTRAP = "bootstrap"
+)
+
+const (
+	PLAN_KIND_VIRTUAL_CHAIN = "vchain"
+	PLAN_KIND_SERVICE       = "service"
+	PLAN_KIND_PROXY         = "proxy"
+	PLAN_KIND_IMAGE         = "image"
+)
+
+type PlannedChange struct {
+	Action     string `json:"action"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name"`
+	Image      string `json:"image,omitempty"`
+	ConfigHash string `json:"configHash,omitempty"`
+}
+
+// Records what provisioning would do instead of doing it, never talks to the container engine.
+// Changes are calculated against the list of running services it was created with.
+type RecordingOrchestrator struct {
+	running map[string]string
+	changes []*PlannedChange
+	pulls   []string
+
+	reverseProxyConfig *ReverseProxyConfig
+}
+
+func NewRecordingOrchestrator(running []*RunningService) *RecordingOrchestrator {
+	r := &RecordingOrchestrator{
+		running: make(map[string]string),
+	}
+
+	for _, service := range running {
+		r.running[service.Name] = service.ConfigHash
+	}
+
+	return r
+}
+
+func (r *RecordingOrchestrator) PullImage(ctx context.Context, imageName string) error {
+	r.pulls = append(r.pulls, imageName)
+	return nil
+}
+
+func (r *RecordingOrchestrator) recordRun(kind string, name string, imageName string, configHash string) {
+	runningHash, isRunning := r.running[name]
+	if isRunning && runningHash == configHash {
+		return
+	}
+
+	action := PLAN_CREATE
+	if isRunning {
+		action = PLAN_UPDATE
+	}
+
+	r.record(&PlannedChange{
+		Action:     action,
+		Kind:       kind,
+		Name:       name,
+		Image:      imageName,
+		ConfigHash: configHash,
+	})
+}
+
+func (r *RecordingOrchestrator) record(change *PlannedChange) {
+	for _, existing := range r.changes {
+		if existing.Action == change.Action && existing.Name == change.Name {
+			return
+		}
+	}
+
+	r.changes = append(r.changes, change)
+}
+
+func (r *RecordingOrchestrator) RunVirtualChain(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
+	r.recordRun(PLAN_KIND_VIRTUAL_CHAIN, serviceConfig.ContainerName, serviceConfig.ImageName, serviceConfig.ConfigHash)
+	return nil
+}
+
+func (r *RecordingOrchestrator) RunReverseProxy(ctx context.Context, config *ReverseProxyConfig) error {
+	r.recordRun(PLAN_KIND_PROXY, config.ContainerName, "", config.ConfigHash)
+	r.reverseProxyConfig = config
+	return nil
+}
+
+func (r *RecordingOrchestrator) RunService(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
+	r.recordRun(PLAN_KIND_SERVICE, serviceConfig.ContainerName, serviceConfig.ImageName, serviceConfig.ConfigHash)
+	return nil
+}
+
+func (r *RecordingOrchestrator) RemoveService(ctx context.Context, containerName string) error {
+	if _, isRunning := r.running[containerName]; isRunning {
+		r.record(&PlannedChange{
+			Action: PLAN_REMOVE,
+			Name:   containerName,
+		})
+	}
+
+	return nil
+}
+
+func (r *RecordingOrchestrator) GetOverlayNetwork(ctx context.Context, name string) (string, error) {
+	return name, nil
+}
+
+func (r *RecordingOrchestrator) GetStatus(ctx context.Context, since time.Duration) ([]*ContainerStatus, error) {
+	return nil, nil
+}
+
+func (r *RecordingOrchestrator) GetStats(ctx context.Context) ([]*ContainerStats, error) {
+	return nil, nil
+}
+
+func (r *RecordingOrchestrator) ListServices(ctx context.Context) ([]*RunningService, error) {
+	var services []*RunningService
+	for name, configHash := range r.running {
+		services = append(services, &RunningService{
+			Name:       name,
+			ConfigHash: configHash,
+		})
+	}
+
+	return services, nil
+}
+
+// Data could outlive the service, so purging is always recorded
+func (r *RecordingOrchestrator) PurgeServiceData(ctx context.Context, containerName string) error {
+	r.record(&PlannedChange{
+		Action: PLAN_PURGE,
+		Kind:   PLAN_KIND_SERVICE,
+		Name:   containerName,
+	})
+	return nil
+}
+
+func (r *RecordingOrchestrator) PurgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error {
+	r.record(&PlannedChange{
+		Action: PLAN_PURGE,
+		Kind:   PLAN_KIND_VIRTUAL_CHAIN,
+		Name:   containerName,
+	})
+	return nil
+}
+
+// The chain is stopped, so running it afterwards is recorded as creating it
+func (r *RecordingOrchestrator) SnapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	r.record(&PlannedChange{
+		Action: PLAN_SNAPSHOT,
+		Kind:   PLAN_KIND_VIRTUAL_CHAIN,
+		Name:   containerName,
+	})
+	delete(r.running, containerName)
+	return nil
+}
+
+func (r *RecordingOrchestrator) RestoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	r.record(&PlannedChange{
+		Action: PLAN_RESTORE,
+		Kind:   PLAN_KIND_VIRTUAL_CHAIN,
+		Name:   containerName,
+	})
+	delete(r.running, containerName)
+	return nil
+}
+
+// Volumes are not inspected, so the bootstrap is recorded even if it would be skipped; the plan assumes it succeeds
+func (r *RecordingOrchestrator) BootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, source *snapshot.Source, progress *snapshot.Progress) (bool, error) {
+	r.record(&PlannedChange{
+		Action: PLAN_BOOTSTRAP,
+		Kind:   PLAN_KIND_VIRTUAL_CHAIN,
+		Name:   containerName,
+	})
+	progress.Finish(nil)
+	return true, nil
+}
+
+func (r *RecordingOrchestrator) Info(ctx context.Context) (interface{}, error) {
+	return map[string]interface{}{
+		"Backend": "plan",
+	}, nil
+}
+
+func (r *RecordingOrchestrator) Close() error {
+	return nil
+}
+
+// Images are only pulled for services that are going to be created or updated
+func (r *RecordingOrchestrator) Changes() []*PlannedChange {
+	var changes []*PlannedChange
+	pulled := make(map[string]bool)
+
+	for _, imageName := range r.pulls {
+		if pulled[imageName] || !r.isImageUsed(imageName) {
+			continue
+		}
+
+		pulled[imageName] = true
+		changes = append(changes, &PlannedChange{
+			Action: PLAN_PULL,
+			Kind:   PLAN_KIND_IMAGE,
+			Name:   imageName,
+		})
+	}
+
+	return append(changes, r.changes...)
+}
+
+func (r *RecordingOrchestrator) isImageUsed(imageName string) bool {
+	for _, change := range r.changes {
+		if change.Image == imageName && (change.Action == PLAN_CREATE || change.Action == PLAN_UPDATE) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns nil if the reverse proxy was not provisioned
+func (r *RecordingOrchestrator) ReverseProxyConfig() *ReverseProxyConfig {
+	return r.reverseProxyConfig
+}
@@ -0,0 +1,49 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingOrchestrator_Changes(t *testing.T) {
+	ctx := context.Background()
+	orchestrator := NewRecordingOrchestrator([]*RunningService{
+		{Name: "chain-42", ConfigHash: "old"},
+		{Name: "signer", ConfigHash: "same"},
+		{Name: "stale", ConfigHash: "stale"},
+	})
+
+	require.NoError(t, orchestrator.PullImage(ctx, "orbsnetwork/node:v2"))
+	require.NoError(t, orchestrator.PullImage(ctx, "orbsnetwork/signer:v1"))
+	require.NoError(t, orchestrator.RunVirtualChain(ctx, &ServiceConfig{ContainerName: "chain-42", ImageName: "orbsnetwork/node:v2", ConfigHash: "new"}, nil))
+	require.NoError(t, orchestrator.RunVirtualChain(ctx, &ServiceConfig{ContainerName: "chain-1991", ImageName: "orbsnetwork/node:v2", ConfigHash: "new"}, nil))
+	require.NoError(t, orchestrator.RunService(ctx, &ServiceConfig{ContainerName: "signer", ImageName: "orbsnetwork/signer:v1", ConfigHash: "same"}, nil))
+	require.NoError(t, orchestrator.RemoveService(ctx, "stale"))
+	require.NoError(t, orchestrator.RemoveService(ctx, "never-existed"))
+	require.NoError(t, orchestrator.PurgeServiceData(ctx, "stale"))
+
+	require.Equal(t, []*PlannedChange{
+		{Action: PLAN_PULL, Kind: PLAN_KIND_IMAGE, Name: "orbsnetwork/node:v2"},
+		{Action: PLAN_UPDATE, Kind: PLAN_KIND_VIRTUAL_CHAIN, Name: "chain-42", Image: "orbsnetwork/node:v2", ConfigHash: "new"},
+		{Action: PLAN_CREATE, Kind: PLAN_KIND_VIRTUAL_CHAIN, Name: "chain-1991", Image: "orbsnetwork/node:v2", ConfigHash: "new"},
+		{Action: PLAN_REMOVE, Name: "stale"},
+		{Action: PLAN_PURGE, Kind: PLAN_KIND_SERVICE, Name: "stale"},
+	}, orchestrator.Changes())
+
+	require.Nil(t, orchestrator.ReverseProxyConfig())
+}
+
+func TestRecordingOrchestrator_SnapshotStopsChain(t *testing.T) {
+	ctx := context.Background()
+	orchestrator := NewRecordingOrchestrator([]*RunningService{{Name: "chain-42", ConfigHash: "same"}})
+
+	require.NoError(t, orchestrator.SnapshotVirtualChainData(ctx, "ADDR", 42, "chain-42", "/backups/chain-42.tar.zst"))
+	require.NoError(t, orchestrator.RunVirtualChain(ctx, &ServiceConfig{ContainerName: "chain-42", ImageName: "orbsnetwork/node:v2", ConfigHash: "same"}, nil))
+
+	require.Equal(t, []*PlannedChange{
+		{Action: PLAN_SNAPSHOT, Kind: PLAN_KIND_VIRTUAL_CHAIN, Name: "chain-42"},
+		{Action: PLAN_CREATE, Kind: PLAN_KIND_VIRTUAL_CHAIN, Name: "chain-42", Image: "orbsnetwork/node:v2", ConfigHash: "same"},
+	}, orchestrator.Changes())
+}
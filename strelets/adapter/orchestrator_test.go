@@ -0,0 +1,114 @@
+package adapter
+
+import (
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    namespace: orbs
+current-context: test
+`
+
+func TestNewOrchestratorSelectsBackend(t *testing.T) {
+	swarm, err := NewOrchestrator(&OrchestratorOptions{}, log.GetLogger())
+	require.NoError(t, err)
+	require.IsType(t, &dockerSwarmOrchestrator{}, swarm)
+
+	docker, err := NewOrchestrator(&OrchestratorOptions{Backend: DOCKER_BACKEND}, log.GetLogger())
+	require.NoError(t, err)
+	require.IsType(t, &dockerEngineOrchestrator{}, docker)
+
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	require.NoError(t, err)
+	defer os.Remove(kubeconfig.Name())
+	_, err = kubeconfig.WriteString(testKubeconfig)
+	require.NoError(t, err)
+	kubeconfig.Close()
+
+	os.Setenv("KUBECONFIG", kubeconfig.Name())
+	defer os.Unsetenv("KUBECONFIG")
+
+	kubernetes, err := NewOrchestrator(&OrchestratorOptions{Backend: KUBERNETES_BACKEND}, log.GetLogger())
+	require.NoError(t, err)
+	require.IsType(t, &kubernetesOrchestrator{}, kubernetes)
+	require.Equal(t, "orbs", kubernetes.(*kubernetesOrchestrator).namespace, "namespace should default to the current context")
+
+	_, err = NewOrchestrator(&OrchestratorOptions{Backend: "nomad"}, log.GetLogger())
+	require.EqualError(t, err, "unknown orchestrator backend nomad")
+
+	_, err = NewOrchestrator(nil, log.GetLogger())
+	require.Error(t, err)
+}
+
+func TestOrchestratorOptions_UpdateMonitorWindow(t *testing.T) {
+	require.EqualValues(t, DEFAULT_UPDATE_MONITOR_WINDOW, OrchestratorOptions{}.UpdateMonitorWindow())
+	require.EqualValues(t, DEFAULT_UPDATE_MONITOR_WINDOW, OrchestratorOptions{UpdateMonitorWindowStr: "forever"}.UpdateMonitorWindow())
+	require.EqualValues(t, 5*time.Minute, OrchestratorOptions{UpdateMonitorWindowStr: "5m"}.UpdateMonitorWindow())
+}
+
+func TestGetImageDigest(t *testing.T) {
+	digest := "sha256:0e5b2e2b2a4c1c6f7cf3c1b0b2c4c2bd2b2a0f0e0d0c0b0a090807060504030201"
+
+	require.Equal(t, digest, GetImageDigest("orbsnetwork/node:v2.0.0@"+digest))
+	require.Equal(t, digest, GetImageDigest("docker-pullable://orbsnetwork/node@"+digest))
+	require.Empty(t, GetImageDigest("orbsnetwork/node:v2.0.0"))
+	require.Empty(t, GetImageDigest("docker://sha256:0e5b2e2b"))
+}
+
+func TestExecutableImageOptions_MaintenanceHours(t *testing.T) {
+	all, err := ExecutableImageOptions{}.MaintenanceHours()
+	require.NoError(t, err)
+	require.True(t, all[0] && all[12] && all[23])
+
+	hours, err := ExecutableImageOptions{MaintenanceWindow: "22-1, 13"}.MaintenanceHours()
+	require.NoError(t, err)
+
+	var allowed []int
+	for hour, ok := range hours {
+		if ok {
+			allowed = append(allowed, hour)
+		}
+	}
+	require.Equal(t, []int{0, 1, 13, 22, 23}, allowed)
+
+	_, err = ExecutableImageOptions{MaintenanceWindow: "2-24"}.MaintenanceHours()
+	require.EqualError(t, err, `invalid hour "24", should be between 0 and 23`)
+}
+
+func TestExecutableImageOptions_Binary(t *testing.T) {
+	options := ExecutableImageOptions{
+		Url:    "https://github.com/orbs-network/boyarin/releases/download/v1.12.0/boyar-v1.12.0.bin",
+		Sha256: "1998cc1f7721acfe1954ab2878cc0ad8062cd6d919cd61fa22401c6750e195fe",
+		Binaries: map[string]ExecutableBinary{
+			"linux/arm64": {Url: "https://github.com/orbs-network/boyarin/releases/download/v1.12.0/boyar-v1.12.0-arm64.bin"},
+		},
+	}
+
+	arm, found := options.Binary("linux/arm64")
+	require.True(t, found)
+	require.Equal(t, options.Binaries["linux/arm64"], arm)
+
+	_, found = options.Binary("linux/amd64")
+	require.False(t, found, "listed binaries replace the url")
+
+	amd, found := ExecutableImageOptions{Url: options.Url, Sha256: options.Sha256}.Binary("linux/amd64")
+	require.True(t, found)
+	require.Equal(t, options.Url, amd.Url)
+
+	_, found = ExecutableImageOptions{}.Binary("linux/amd64")
+	require.False(t, found)
+}
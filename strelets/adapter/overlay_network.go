@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
 )
 
 const SHARED_SIGNER_NETWORK = "signer-overlay"
@@ -12,7 +13,15 @@ const SHARED_PROXY_NETWORK = "http-proxy-overlay"
 const SHARED_SERVICES_NETWORK = "services-overlay"
 
 func (d *dockerSwarmOrchestrator) GetOverlayNetwork(ctx context.Context, name string) (string, error) {
-	networks, err := d.client.NetworkList(ctx, types.NetworkListOptions{
+	return getOrCreateNetwork(ctx, d.client, name, types.NetworkCreate{
+		Driver:         "overlay",
+		Attachable:     true,
+		CheckDuplicate: true,
+	})
+}
+
+func getOrCreateNetwork(ctx context.Context, client *client.Client, name string, options types.NetworkCreate) (string, error) {
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{
 		Filters: filters.NewArgs(filters.Arg("name", name)),
 	})
 
@@ -21,14 +30,10 @@ func (d *dockerSwarmOrchestrator) GetOverlayNetwork(ctx context.Context, name st
 	}
 
 	if len(networks) == 0 {
-		response, err := d.client.NetworkCreate(ctx, name, types.NetworkCreate{
-			Driver:         "overlay",
-			Attachable:     true,
-			CheckDuplicate: true,
-		})
+		response, err := client.NetworkCreate(ctx, name, options)
 
 		if err != nil {
-			return "", fmt.Errorf("could not create overlay network %s: %s", name, err)
+			return "", fmt.Errorf("could not create %s network %s: %s", options.Driver, name, err)
 		}
 
 		return response.ID, nil
@@ -11,15 +11,15 @@ import (
 )
 
 // Only works for EFS volumes because they are shared
-func (d *dockerSwarmOrchestrator) canPurgeData(containerName string) error {
-	if d.options.StorageDriver != LOCAL_DRIVER && d.options.StorageMountType != "bind" {
+func canPurgeData(options *OrchestratorOptions, containerName string) error {
+	if options.StorageDriver != LOCAL_DRIVER && options.StorageMountType != "bind" {
 		return fmt.Errorf("purging data for service %s not supported by the storage driver", containerName)
 	}
 
 	return nil
 }
 
-func (d *dockerSwarmOrchestrator) purgeMounts(mounts []mount.Mount) error {
+func purgeMounts(mounts []mount.Mount) error {
 	var errors []error
 	for _, m := range mounts {
 		volumeName := m.Source
@@ -38,34 +38,42 @@ func (d *dockerSwarmOrchestrator) purgeMounts(mounts []mount.Mount) error {
 	return utils.AggregateErrors(errors)
 }
 
-func (d *dockerSwarmOrchestrator) PurgeServiceData(ctx context.Context, containerName string) error {
-	if err := d.canPurgeData(containerName); err != nil {
+func (v *dockerVolumes) purgeServiceData(ctx context.Context, containerName string) error {
+	if err := canPurgeData(v.options, containerName); err != nil {
 		return err
 	}
 
-	mounts, err := d.provisionServiceVolumes(ctx, containerName, nil)
+	mounts, err := v.provisionServiceVolumes(ctx, containerName, nil)
 	if err != nil {
 		return err
 	}
 
-	return d.purgeMounts(mounts)
+	return purgeMounts(mounts)
 }
 
-func (d *dockerSwarmOrchestrator) PurgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error {
-	if err := d.canPurgeData(containerName); err != nil {
+func (v *dockerVolumes) purgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error {
+	if err := canPurgeData(v.options, containerName); err != nil {
 		return err
 	}
 
-	mounts, err := d.provisionServiceVolumes(ctx, containerName, nil)
+	mounts, err := v.provisionServiceVolumes(ctx, containerName, nil)
 	if err != nil {
 		return err
 	}
 
-	if blocksMount, err := d.provisionVchainVolume(ctx, nodeAddress, vcId); err != nil {
+	if blocksMount, err := v.provisionVchainVolume(ctx, nodeAddress, vcId); err != nil {
 		return fmt.Errorf("failed to access volumes: %s", err)
 	} else {
 		mounts = append(mounts, blocksMount)
 	}
 
-	return d.purgeMounts(mounts)
+	return purgeMounts(mounts)
+}
+
+func (d *dockerSwarmOrchestrator) PurgeServiceData(ctx context.Context, containerName string) error {
+	return d.volumes().purgeServiceData(ctx, containerName)
+}
+
+func (d *dockerSwarmOrchestrator) PurgeVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string) error {
+	return d.volumes().purgeVirtualChainData(ctx, nodeAddress, vcId, containerName)
 }
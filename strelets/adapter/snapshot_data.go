@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/orbs-network/boyarin/snapshot"
+	"time"
+)
+
+const STOP_CONTAINERS_TIMEOUT = 2 * time.Minute
+const STOP_CONTAINERS_CHECK_INTERVAL = time.Second
+
+// Downloads are resumed by the next attempt if they hit it
+const BOOTSTRAP_TIMEOUT = 6 * time.Hour
+
+const SWARM_SERVICE_NAME_LABEL = "com.docker.swarm.service.name"
+
+// Blocks volume as seen from the host, only works when boyar runs on the same machine as the chain
+func (v *dockerVolumes) getVchainDataPath(ctx context.Context, nodeAddress string, vcId uint32) (string, error) {
+	blocksMount, err := v.provisionVchainVolume(ctx, nodeAddress, vcId)
+	if err != nil {
+		return "", fmt.Errorf("failed to access volumes: %s", err)
+	}
+
+	switch blocksMount.Type {
+	case mount.TypeBind:
+		return blocksMount.Source, nil
+	case mount.TypeVolume:
+		info, err := v.client.VolumeInspect(ctx, blocksMount.Source)
+		if err != nil {
+			return "", fmt.Errorf("could not inspect volume %s: %s", blocksMount.Source, err)
+		}
+
+		if info.Mountpoint == "" {
+			return "", fmt.Errorf("volume %s has no mount point", blocksMount.Source)
+		}
+
+		return info.Mountpoint, nil
+	}
+
+	return "", fmt.Errorf("%s mounts do not keep data on the host", blocksMount.Type)
+}
+
+func (v *dockerVolumes) snapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, archivePath string) error {
+	dir, err := v.getVchainDataPath(ctx, nodeAddress, vcId)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = snapshot.WriteFile(dir, archivePath)
+	return err
+}
+
+func (v *dockerVolumes) restoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, archivePath string) error {
+	dir, err := v.getVchainDataPath(ctx, nodeAddress, vcId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := snapshot.RestoreFile(archivePath, dir); err != nil {
+		return fmt.Errorf("could not restore %s: %s", archivePath, err)
+	}
+
+	return nil
+}
+
+// Runs in the background with its own deadline, provisioning could take much less than the download
+func (v *dockerVolumes) bootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, source *snapshot.Source, progress *snapshot.Progress) (bool, error) {
+	dir, err := v.getVchainDataPath(ctx, nodeAddress, vcId)
+	if err != nil {
+		return false, err
+	}
+
+	if empty, err := snapshot.IsEmpty(dir); err != nil {
+		return false, err
+	} else if !empty {
+		progress.Skip()
+		return false, nil
+	}
+
+	progress.Start(-1)
+	go func() {
+		ctxWithTimeout, cancel := context.WithTimeout(context.Background(), BOOTSTRAP_TIMEOUT)
+		defer cancel()
+
+		if _, err := snapshot.RestoreFromSource(ctxWithTimeout, source, dir, progress); err != nil {
+			progress.Finish(fmt.Errorf("could not bootstrap from %s: %s", source.Location, err))
+		} else {
+			progress.Finish(nil)
+		}
+	}()
+
+	return true, nil
+}
+
+func (v *dockerVolumes) waitForContainersToStop(ctx context.Context, filter filters.Args) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, STOP_CONTAINERS_TIMEOUT)
+	defer cancel()
+
+	for {
+		containers, err := v.client.ContainerList(ctxWithTimeout, types.ContainerListOptions{Filters: filter})
+		if err != nil {
+			return fmt.Errorf("could not list containers: %s", err)
+		}
+
+		if len(containers) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctxWithTimeout.Done():
+			return fmt.Errorf("%d containers are still running after %s", len(containers), STOP_CONTAINERS_TIMEOUT)
+		case <-time.After(STOP_CONTAINERS_CHECK_INTERVAL):
+		}
+	}
+}
+
+// Swarm stops the containers of a removed service in the background
+func (d *dockerSwarmOrchestrator) stopVirtualChain(ctx context.Context, containerName string) error {
+	if err := d.RemoveService(ctx, containerName); err != nil {
+		return err
+	}
+
+	return d.volumes().waitForContainersToStop(ctx, filters.NewArgs(filters.KeyValuePair{Key: "label", Value: SWARM_SERVICE_NAME_LABEL + "=" + containerName}))
+}
+
+func (d *dockerSwarmOrchestrator) SnapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	if err := d.stopVirtualChain(ctx, containerName); err != nil {
+		return fmt.Errorf("could not stop %s: %s", containerName, err)
+	}
+
+	return d.volumes().snapshotVirtualChainData(ctx, nodeAddress, vcId, archivePath)
+}
+
+func (d *dockerSwarmOrchestrator) RestoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	if err := d.stopVirtualChain(ctx, containerName); err != nil {
+		return fmt.Errorf("could not stop %s: %s", containerName, err)
+	}
+
+	return d.volumes().restoreVirtualChainData(ctx, nodeAddress, vcId, archivePath)
+}
+
+func (d *dockerEngineOrchestrator) SnapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	if err := d.RemoveService(ctx, containerName); err != nil {
+		return fmt.Errorf("could not stop %s: %s", containerName, err)
+	}
+
+	return d.volumes().snapshotVirtualChainData(ctx, nodeAddress, vcId, archivePath)
+}
+
+func (d *dockerEngineOrchestrator) RestoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	if err := d.RemoveService(ctx, containerName); err != nil {
+		return fmt.Errorf("could not stop %s: %s", containerName, err)
+	}
+
+	return d.volumes().restoreVirtualChainData(ctx, nodeAddress, vcId, archivePath)
+}
+
+func (d *dockerSwarmOrchestrator) BootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, source *snapshot.Source, progress *snapshot.Progress) (bool, error) {
+	return d.volumes().bootstrapVirtualChainData(ctx, nodeAddress, vcId, source, progress)
+}
+
+func (d *dockerEngineOrchestrator) BootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, source *snapshot.Source, progress *snapshot.Progress) (bool, error) {
+	return d.volumes().bootstrapVirtualChainData(ctx, nodeAddress, vcId, source, progress)
+}
+
+// Claims are not reachable from the host, volume snapshots of the storage class should be used instead
+func (k *kubernetesOrchestrator) SnapshotVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	return fmt.Errorf("snapshots of %s data are not supported by %s backend", containerName, KUBERNETES_BACKEND)
+}
+
+func (k *kubernetesOrchestrator) RestoreVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, archivePath string) error {
+	return fmt.Errorf("restoring %s data is not supported by %s backend", containerName, KUBERNETES_BACKEND)
+}
+
+func (k *kubernetesOrchestrator) BootstrapVirtualChainData(ctx context.Context, nodeAddress string, vcId uint32, containerName string, source *snapshot.Source, progress *snapshot.Progress) (bool, error) {
+	return false, fmt.Errorf("bootstrapping %s data is not supported by %s backend", containerName, KUBERNETES_BACKEND)
+}
@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"context"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/orbs-network/boyarin/test/helpers"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSnapshotAndRestoreVirtualChainData(t *testing.T) {
+	helpers.SkipUnlessSwarmIsEnabled(t)
+
+	helpers.WithContext(func(ctx context.Context) {
+		helpers.InitSwarmEnvironment(t, ctx)
+
+		orchestrator := &dockerSwarmOrchestrator{
+			client: helpers.DockerClient(t),
+			options: &OrchestratorOptions{
+				StorageDriver:    LOCAL_DRIVER,
+				StorageMountType: "bind",
+			},
+			logger: log.GetLogger()}
+
+		containerName := "diamond-dogs-chain-96"
+		nodeAddress := "ADDR"
+		vcId := uint32(1975)
+
+		blocksMount, err := orchestrator.provisionVchainVolume(ctx, nodeAddress, vcId)
+		require.NoError(t, err)
+		createFilesPerMount(t, []mount.Mount{blocksMount})
+
+		dir, err := ioutil.TempDir("", "boyar-snapshot")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		archivePath := path.Join(dir, "chain.tar.zst")
+		require.NoError(t, orchestrator.SnapshotVirtualChainData(ctx, nodeAddress, vcId, containerName, archivePath))
+
+		require.NoError(t, orchestrator.PurgeVirtualChainData(ctx, nodeAddress, vcId, containerName))
+		require.False(t, helpers.VerifyFilesExist(t, blocksMount.Source))
+
+		require.NoError(t, orchestrator.RestoreVirtualChainData(ctx, nodeAddress, vcId, containerName, archivePath))
+		require.True(t, helpers.VerifyFilesExist(t, blocksMount.Source))
+
+		data, err := ioutil.ReadFile(path.Join(blocksMount.Source, "some-dir", "file-in-dir"))
+		require.NoError(t, err)
+		require.Equal(t, "file-in-dir", string(data))
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/orbs-network/scribe/log"
 	"github.com/pkg/errors"
 	"os"
+	"time"
 )
 
 type dockerSwarmOrchestrator struct {
@@ -18,17 +19,22 @@ type dockerSwarmOrchestrator struct {
 	logger  log.Logger
 }
 
+type dockerSwarmSecret struct {
+	id   string
+	name string
+}
+
 type dockerSwarmSecretsConfig struct {
-	configSecretId  string
-	networkSecretId string
-	keysSecretId    string
+	configSecret  *dockerSwarmSecret
+	networkSecret *dockerSwarmSecret
+	keysSecret    *dockerSwarmSecret
 }
 
 type dockerSwarmNginxSecretsConfig struct {
-	nginxConfId      string
-	vchainConfId     string
-	sslCertificateId string
-	sslPrivateKeyId  string
+	nginxConf      *dockerSwarmSecret
+	vchainConf     *dockerSwarmSecret
+	sslCertificate *dockerSwarmSecret
+	sslPrivateKey  *dockerSwarmSecret
 }
 
 func NewDockerSwarm(options *OrchestratorOptions, logger log.Logger) (Orchestrator, error) {
@@ -48,7 +54,26 @@ func (d *dockerSwarmOrchestrator) PullImage(ctx context.Context, imageName strin
 	return pullImage(ctx, d.client, imageName)
 }
 
-func (d *dockerSwarmOrchestrator) create(ctx context.Context, spec swarm.ServiceSpec, imageName string) error {
+func (d *dockerSwarmOrchestrator) getService(ctx context.Context, serviceName string) (*swarm.Service, error) {
+	services, err := d.client.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{Key: "name", Value: serviceName}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list swarm services: %s \n %v", serviceName, err)
+	}
+
+	// name filter matches prefixes
+	for _, service := range services {
+		if service.Spec.Name == serviceName {
+			return &service, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Updates the service in place if it exists, carrying over its version index, otherwise creates it
+func (d *dockerSwarmOrchestrator) createOrUpdate(ctx context.Context, service *swarm.Service, spec swarm.ServiceSpec, imageName string) error {
 	var registryAuth string
 	if username, password, err := getAuthForRepository(os.Getenv("HOME"), imageName); err != nil {
 		// Ignore
@@ -59,6 +84,23 @@ func (d *dockerSwarmOrchestrator) create(ctx context.Context, spec swarm.Service
 			ServerAddress: getRepoName(imageName),
 		})
 	}
+
+	if service != nil {
+		response, err := d.client.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{
+			QueryRegistry:       true,
+			EncodedRegistryAuth: registryAuth,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed updating service")
+		}
+
+		for _, warning := range response.Warnings {
+			d.logger.Info(fmt.Sprintf("service %s update warning: %s", spec.Name, warning))
+		}
+
+		return nil
+	}
+
 	_, err := d.client.ServiceCreate(ctx, spec, types.ServiceCreateOptions{
 		QueryRegistry:       true,
 		EncodedRegistryAuth: registryAuth,
@@ -67,6 +109,25 @@ func (d *dockerSwarmOrchestrator) create(ctx context.Context, spec swarm.Service
 	return errors.Wrap(err, "failed creating service")
 }
 
+// Failed updates are rolled back to the previous spec by swarm itself
+func getUpdateConfig(order string, monitor time.Duration) (*swarm.UpdateConfig, *swarm.UpdateConfig) {
+	update := &swarm.UpdateConfig{
+		Parallelism:   1,
+		Order:         order,
+		FailureAction: swarm.UpdateFailureActionRollback,
+		Monitor:       monitor,
+	}
+
+	rollback := &swarm.UpdateConfig{
+		Parallelism:   1,
+		Order:         order,
+		FailureAction: swarm.UpdateFailureActionPause,
+		Monitor:       monitor,
+	}
+
+	return update, rollback
+}
+
 func (d *dockerSwarmOrchestrator) RemoveService(ctx context.Context, serviceName string) error {
 	services, err := d.client.ServiceList(ctx, types.ServiceListOptions{
 		Filters: filters.NewArgs(filters.KeyValuePair{Key: "name", Value: serviceName}),
@@ -88,6 +149,22 @@ func (d *dockerSwarmOrchestrator) RemoveService(ctx context.Context, serviceName
 	return nil
 }
 
+func (d *dockerSwarmOrchestrator) ListServices(ctx context.Context) (results []*RunningService, err error) {
+	services, err := d.client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list swarm services: %s", err)
+	}
+
+	for _, service := range services {
+		results = append(results, &RunningService{
+			Name:       service.Spec.Name,
+			ConfigHash: service.Spec.Labels[CONFIG_HASH_LABEL],
+		})
+	}
+
+	return
+}
+
 func (d *dockerSwarmOrchestrator) Close() error {
 	return d.client.Close()
 }
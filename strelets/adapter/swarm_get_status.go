@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
 	"io/ioutil"
 	"time"
 )
@@ -13,6 +14,15 @@ func (d *dockerSwarmOrchestrator) GetStatus(ctx context.Context, since time.Dura
 	if tasks, err := d.client.TaskList(ctx, types.TaskListOptions{}); err != nil {
 		return nil, fmt.Errorf("failed to retrieve task list: %s", err)
 	} else {
+		// swarm replaces failed containers with new tasks and keeps the old ones for a while (task history limit),
+		// tasks shut down by updates are not restarts
+		failedTasksPerService := make(map[string]int)
+		for _, task := range tasks {
+			if task.Status.State == swarm.TaskStateFailed || task.Status.State == swarm.TaskStateRejected {
+				failedTasksPerService[task.ServiceID]++
+			}
+		}
+
 		for _, task := range tasks {
 			name, _ := d.getServiceName(ctx, task.ServiceID) // FIXME handle error for non existing service
 			logs, _ := d.getLogs(ctx, task.ServiceID, since) // FIXME handle more errors
@@ -24,6 +34,15 @@ func (d *dockerSwarmOrchestrator) GetStatus(ctx context.Context, since time.Dura
 				NodeID:    task.NodeID,
 				CreatedAt: task.CreatedAt,
 				Logs:      logs,
+
+				TaskState:      string(task.Status.State),
+				StateChangedAt: task.Status.Timestamp,
+				RestartCount:   failedTasksPerService[task.ServiceID],
+			}
+
+			// swarm pins the digest of the image when the service is created
+			if task.Spec.ContainerSpec != nil {
+				status.ImageDigest = GetImageDigest(task.Spec.ContainerSpec.Image)
 			}
 
 			if task.Status.ContainerStatus != nil {
@@ -41,6 +60,48 @@ func (d *dockerSwarmOrchestrator) GetStatus(ctx context.Context, since time.Dura
 	return
 }
 
+func (d *dockerSwarmOrchestrator) GetStats(ctx context.Context) ([]*ContainerStats, error) {
+	tasks, err := d.client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{
+			Key:   "desired-state",
+			Value: "running",
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve task list: %s", err)
+	}
+
+	containerNames := make(map[string]string)
+	for _, task := range tasks {
+		if task.Status.ContainerStatus == nil || task.Status.ContainerStatus.ContainerID == "" {
+			continue
+		}
+
+		if name, err := d.getServiceName(ctx, task.ServiceID); err == nil {
+			containerNames[task.Status.ContainerStatus.ContainerID] = name
+		}
+	}
+
+	return getDockerContainerStats(ctx, d.client, d.localContainers(ctx, containerNames))
+}
+
+// Stats are only available for containers on the same machine
+func (d *dockerSwarmOrchestrator) localContainers(ctx context.Context, containerNames map[string]string) map[string]string {
+	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		return nil
+	}
+
+	local := make(map[string]string)
+	for _, c := range containers {
+		if name, found := containerNames[c.ID]; found {
+			local[c.ID] = name
+		}
+	}
+
+	return local
+}
+
 func (d *dockerSwarmOrchestrator) getServiceName(ctx context.Context, serviceID string) (string, error) {
 	if specs, err := d.client.ServiceList(ctx, types.ServiceListOptions{
 		Filters: filters.NewArgs(filters.KeyValuePair{
@@ -12,7 +12,12 @@ var VIRTUAL_CHAIN_RESTART_DELAY = 20 * time.Minute
 var VIRTUAL_CHAIN_RESTART_SUCCESS_WINDOW = 2 * time.Minute
 
 func (d *dockerSwarmOrchestrator) RunVirtualChain(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
-	if err := d.RemoveService(ctx, serviceConfig.ContainerName); err != nil {
+	service, err := d.getService(ctx, serviceConfig.ContainerName)
+	if err != nil {
+		return err
+	}
+
+	if err := d.pruneSwarmSecrets(ctx, serviceConfig.ContainerName, service); err != nil {
 		return err
 	}
 
@@ -50,9 +55,9 @@ func (d *dockerSwarmOrchestrator) RunVirtualChain(ctx context.Context, serviceCo
 	}
 
 	secrets := []*swarm.SecretReference{
-		getSecretReference(serviceConfig.ContainerName, config.configSecretId, "config", "config.json"),
-		getSecretReference(serviceConfig.ContainerName, config.keysSecretId, "keyPair", "keys.json"),
-		getSecretReference(serviceConfig.ContainerName, config.networkSecretId, "network", "network.json"),
+		getSecretReference(config.configSecret, "config.json"),
+		getSecretReference(config.keysSecret, "keys.json"),
+		getSecretReference(config.networkSecret, "network.json"),
 	}
 
 	mounts, err := d.provisionServiceVolumes(ctx, serviceConfig.ContainerName, nil)
@@ -68,14 +73,17 @@ func (d *dockerSwarmOrchestrator) RunVirtualChain(ctx context.Context, serviceCo
 	}
 
 	spec := getVirtualChainServiceSpec(serviceConfig, secrets, mounts, networks)
+	spec.Labels = getServiceLabels(serviceConfig.ConfigHash)
+	// blocks volume can not be shared between the old and the new task
+	spec.UpdateConfig, spec.RollbackConfig = getUpdateConfig(swarm.UpdateOrderStopFirst, d.options.UpdateMonitorWindow())
 
-	return d.create(ctx, spec, serviceConfig.ImageName)
+	return d.createOrUpdate(ctx, service, spec, serviceConfig.ImageName)
 }
 
-func getSecretReference(containerName string, secretId string, secretName string, filename string) *swarm.SecretReference {
+func getSecretReference(secret *dockerSwarmSecret, filename string) *swarm.SecretReference {
 	return &swarm.SecretReference{
-		SecretName: getSwarmSecretName(containerName, secretName),
-		SecretID:   secretId,
+		SecretName: secret.name,
+		SecretID:   secret.id,
 		File: &swarm.SecretReferenceFileTarget{
 			Name: filename,
 			UID:  "0",
@@ -133,9 +141,10 @@ func getVirtualChainServiceSpec(serviceConfig *ServiceConfig, secrets []*swarm.S
 			},
 			Resources: getResourceRequirements(serviceConfig.LimitedMemory, serviceConfig.LimitedCPU,
 				serviceConfig.ReservedMemory, serviceConfig.ReservedCPU),
+			// service update only supports network changes in the task template
+			Networks: networks,
 		},
-		Networks: networks,
-		Mode:     getServiceMode(replicas),
+		Mode: getServiceMode(replicas),
 		EndpointSpec: &swarm.EndpointSpec{
 			Ports: []swarm.PortConfig{
 				{
@@ -27,13 +27,20 @@ type ReverseProxyConfig struct {
 
 	SSLCertificate []byte
 	SSLPrivateKey  []byte
+
+	ConfigHash string
 }
 
 const DEFAULT_HTTP_PORT = uint32(80)
 const DEFAULT_SSL_PORT = uint32(443)
 
 func (d *dockerSwarmOrchestrator) RunReverseProxy(ctx context.Context, config *ReverseProxyConfig) error {
-	if err := d.RemoveService(ctx, config.ContainerName); err != nil {
+	service, err := d.getService(ctx, config.ContainerName)
+	if err != nil {
+		return err
+	}
+
+	if err := d.pruneSwarmSecrets(ctx, config.ContainerName, service); err != nil {
 		return err
 	}
 
@@ -75,7 +82,11 @@ func (d *dockerSwarmOrchestrator) RunReverseProxy(ctx context.Context, config *R
 	}
 
 	spec := getNginxServiceSpec(config.ContainerName, httpPort, sslPort, storedSecrets, networks, mounts)
-	return d.create(ctx, spec, "")
+	spec.Labels = getServiceLabels(config.ConfigHash)
+	// new proxy starts serving before the old one is stopped, so there is no downtime
+	spec.UpdateConfig, spec.RollbackConfig = getUpdateConfig(swarm.UpdateOrderStartFirst, d.options.UpdateMonitorWindow())
+
+	return d.createOrUpdate(ctx, service, spec, "")
 }
 
 func getNginxServiceSpec(namespace string, httpPort uint32, sslPort uint32, storedSecrets *dockerSwarmNginxSecretsConfig, networks []swarm.NetworkAttachmentConfig, mounts []mount.Mount) swarm.ServiceSpec {
@@ -83,16 +94,16 @@ func getNginxServiceSpec(namespace string, httpPort uint32, sslPort uint32, stor
 	replicas := uint64(1)
 
 	secrets := []*swarm.SecretReference{
-		getSecretReference(namespace, storedSecrets.nginxConfId, NGINX_CONF, "nginx.conf"),
-		getSecretReference(namespace, storedSecrets.vchainConfId, VCHAINS_CONF, "vchains.conf"),
+		getSecretReference(storedSecrets.nginxConf, "nginx.conf"),
+		getSecretReference(storedSecrets.vchainConf, "vchains.conf"),
 	}
 
-	if storedSecrets.sslCertificateId != "" {
-		secrets = append(secrets, getSecretReference(namespace, storedSecrets.sslCertificateId, SSL_CERT, "ssl-cert"))
+	if storedSecrets.sslCertificate != nil {
+		secrets = append(secrets, getSecretReference(storedSecrets.sslCertificate, "ssl-cert"))
 	}
 
-	if storedSecrets.sslPrivateKeyId != "" {
-		secrets = append(secrets, getSecretReference(namespace, storedSecrets.sslPrivateKeyId, SSL_KEY, "ssl-key"))
+	if storedSecrets.sslPrivateKey != nil {
+		secrets = append(secrets, getSecretReference(storedSecrets.sslPrivateKey, "ssl-key"))
 	}
 
 	ports := []swarm.PortConfig{
@@ -104,7 +115,7 @@ func getNginxServiceSpec(namespace string, httpPort uint32, sslPort uint32, stor
 		},
 	}
 
-	if storedSecrets.sslCertificateId != "" && storedSecrets.sslPrivateKeyId != "" {
+	if storedSecrets.sslCertificate != nil && storedSecrets.sslPrivateKey != nil {
 		ports = append(ports, swarm.PortConfig{
 			Protocol:      "tcp",
 			PublishMode:   swarm.PortConfigPublishModeIngress,
@@ -128,12 +139,12 @@ func getNginxServiceSpec(namespace string, httpPort uint32, sslPort uint32, stor
 			RestartPolicy: &swarm.RestartPolicy{
 				Delay: &restartDelay,
 			},
+			Networks: networks,
 		},
 		Mode: getServiceMode(replicas),
 		EndpointSpec: &swarm.EndpointSpec{
 			Ports: ports,
 		},
-		Networks: networks,
 	}
 	spec.Name = namespace
 
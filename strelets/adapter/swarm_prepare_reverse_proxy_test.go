@@ -16,8 +16,8 @@ func Test_getNginxServiceSpec(t *testing.T) {
 	sslPort := uint32(443)
 
 	secrets := &dockerSwarmNginxSecretsConfig{
-		vchainConfId: "vchain-config-id",
-		nginxConfId:  "nginx-config-id",
+		vchainConf: &dockerSwarmSecret{id: "vchain-config-id", name: "node123-proxy-vchains.conf-a1b2c3d4e5f6"},
+		nginxConf:  &dockerSwarmSecret{id: "nginx-config-id", name: "node123-proxy-nginx.conf-a1b2c3d4e5f6"},
 	}
 	spec := getNginxServiceSpec(namespace, httpPort, sslPort, secrets, nil, nil)
 
@@ -32,7 +32,7 @@ func Test_getNginxServiceSpec(t *testing.T) {
 			},
 			Secrets: []*swarm.SecretReference{
 				{
-					SecretName: "node123-proxy-nginx.conf",
+					SecretName: "node123-proxy-nginx.conf-a1b2c3d4e5f6",
 					SecretID:   "nginx-config-id",
 					File: &swarm.SecretReferenceFileTarget{
 						Name: "nginx.conf",
@@ -41,7 +41,7 @@ func Test_getNginxServiceSpec(t *testing.T) {
 					},
 				},
 				{
-					SecretName: "node123-proxy-vchains.conf",
+					SecretName: "node123-proxy-vchains.conf-a1b2c3d4e5f6",
 					SecretID:   "vchain-config-id",
 					File: &swarm.SecretReferenceFileTarget{
 						Name: "vchains.conf",
@@ -9,7 +9,12 @@ import (
 )
 
 func (d *dockerSwarmOrchestrator) RunService(ctx context.Context, serviceConfig *ServiceConfig, appConfig *AppConfig) error {
-	if err := d.RemoveService(ctx, serviceConfig.ContainerName); err != nil {
+	service, err := d.getService(ctx, serviceConfig.ContainerName)
+	if err != nil {
+		return err
+	}
+
+	if err := d.pruneSwarmSecrets(ctx, serviceConfig.ContainerName, service); err != nil {
 		return err
 	}
 
@@ -38,10 +43,10 @@ func (d *dockerSwarmOrchestrator) RunService(ctx context.Context, serviceConfig
 	}
 
 	secrets := []*swarm.SecretReference{
-		getSecretReference(serviceConfig.ContainerName, config.configSecretId, "config", "config.json"),
+		getSecretReference(config.configSecret, "config.json"),
 	}
-	if config.keysSecretId != "" {
-		secrets = append(secrets, getSecretReference(serviceConfig.ContainerName, config.keysSecretId, "keyPair", "keys.json"))
+	if config.keysSecret != nil {
+		secrets = append(secrets, getSecretReference(config.keysSecret, "keys.json"))
 	}
 
 	mounts, err := d.provisionServiceVolumes(ctx, serviceConfig.ContainerName, serviceConfig.LogsMountPointNames)
@@ -50,57 +55,27 @@ func (d *dockerSwarmOrchestrator) RunService(ctx context.Context, serviceConfig
 	}
 
 	spec := getServiceSpec(serviceConfig, secrets, networks, mounts)
+	spec.Labels = getServiceLabels(serviceConfig.ConfigHash)
+	// services keep their state in the cache volume, same as vchains
+	spec.UpdateConfig, spec.RollbackConfig = getUpdateConfig(swarm.UpdateOrderStopFirst, d.options.UpdateMonitorWindow())
 
-	return d.create(ctx, spec, serviceConfig.ImageName)
-}
-
-func (d *dockerSwarmOrchestrator) provisionServiceVolumes(ctx context.Context, containerName string, logsMountPointNames map[string]string) (mounts []mount.Mount, err error) {
-	if statusMount, err := d.provisionStatusVolume(ctx, containerName, ORBS_STATUS_TARGET); err != nil {
-		return nil, err
-	} else {
-		mounts = append(mounts, statusMount)
-	}
-
-	if cacheMount, err := d.provisionCacheVolume(ctx, containerName); err != nil {
-		return nil, err
-	} else {
-		mounts = append(mounts, cacheMount)
-	}
-
-	if len(logsMountPointNames) == 0 {
-		if logsMount, err := d.provisionLogsVolume(ctx, containerName, ORBS_LOGS_TARGET); err != nil {
-			return nil, fmt.Errorf("failed to provision volumes: %s", err)
-		} else {
-			mounts = append(mounts, logsMount)
-		}
-	} else {
-		// special case for multiple logs
-		for simpleName, namespacedName := range logsMountPointNames {
-			if logsMount, err := d.provisionLogsVolume(ctx, namespacedName, GetNestedLogsMountPath(simpleName)); err != nil {
-				return nil, fmt.Errorf("failed to provision volumes: %s", err)
-			} else {
-				mounts = append(mounts, logsMount)
-			}
-		}
-	}
-
-	return mounts, nil
+	return d.createOrUpdate(ctx, service, spec, serviceConfig.ImageName)
 }
 
 func (d *dockerSwarmOrchestrator) storeServiceConfiguration(ctx context.Context, containerName string, config *AppConfig) (*dockerSwarmSecretsConfig, error) {
 	secrets := &dockerSwarmSecretsConfig{}
 
-	if configSecretId, err := d.saveSwarmSecret(ctx, containerName, "config", config.Config); err != nil {
+	if configSecret, err := d.saveSwarmSecret(ctx, containerName, "config", config.Config); err != nil {
 		return nil, fmt.Errorf("could not store config secret: %s", err)
 	} else {
-		secrets.configSecretId = configSecretId
+		secrets.configSecret = configSecret
 	}
 
 	if config.KeyPair != nil {
-		if keyPairSecretId, err := d.saveSwarmSecret(ctx, containerName, "keyPair", config.KeyPair); err != nil {
+		if keyPairSecret, err := d.saveSwarmSecret(ctx, containerName, "keyPair", config.KeyPair); err != nil {
 			return nil, fmt.Errorf("could not store key pair secret: %s", err)
 		} else {
-			secrets.keysSecretId = keyPairSecretId
+			secrets.keysSecret = keyPairSecret
 		}
 	}
 
@@ -119,9 +94,9 @@ func getServiceSpec(serviceConfig *ServiceConfig, secrets []*swarm.SecretReferen
 			},
 			Resources: getResourceRequirements(serviceConfig.LimitedMemory, serviceConfig.LimitedCPU,
 				serviceConfig.ReservedMemory, serviceConfig.ReservedCPU),
+			Networks: networks,
 		},
-		Networks: networks,
-		Mode:     getServiceMode(replicas),
+		Mode: getServiceMode(replicas),
 	}
 
 	if serviceConfig.ExternalPort != 0 {
@@ -145,6 +120,21 @@ func getServiceSpec(serviceConfig *ServiceConfig, secrets []*swarm.SecretReferen
 const SERVICE_EXECUTABLE_PATH = "/opt/orbs/service"
 
 func getServiceContainerSpec(imageName string, executable string, secrets []*swarm.SecretReference, mounts []mount.Mount) *swarm.ContainerSpec {
+	var secretFiles []string
+	for _, secret := range secrets {
+		secretFiles = append(secretFiles, secret.File.Name)
+	}
+
+	return &swarm.ContainerSpec{
+		Image:   imageName,
+		Command: getServiceCommand(executable, secretFiles),
+		Secrets: secrets,
+		Sysctls: GetSysctls(),
+		Mounts:  mounts,
+	}
+}
+
+func getServiceCommand(executable string, secretFiles []string) []string {
 	if executable == "" {
 		executable = SERVICE_EXECUTABLE_PATH
 	}
@@ -153,15 +143,9 @@ func getServiceContainerSpec(imageName string, executable string, secrets []*swa
 		executable,
 	}
 
-	for _, secret := range secrets {
-		command = append(command, "--config", "/run/secrets/"+secret.File.Name)
+	for _, secretFile := range secretFiles {
+		command = append(command, "--config", "/run/secrets/"+secretFile)
 	}
 
-	return &swarm.ContainerSpec{
-		Image:   imageName,
-		Command: command,
-		Secrets: secrets,
-		Sysctls: GetSysctls(),
-		Mounts:  mounts,
-	}
+	return command
 }
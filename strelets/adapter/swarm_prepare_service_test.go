@@ -11,7 +11,7 @@ import (
 func Test_getServiceSpec(t *testing.T) {
 	containerName := "signer"
 	secrets := []*swarm.SecretReference{
-		getSecretReference(containerName, "some-secret-id", "some-secret-name", "some-secret.json"),
+		getSecretReference(&dockerSwarmSecret{id: "some-secret-id", name: "some-secret-name"}, "some-secret.json"),
 	}
 
 	restartDelay := time.Duration(10 * time.Second)
@@ -56,6 +56,7 @@ func Test_getServiceSpec(t *testing.T) {
 			},
 			Reservations: &swarm.Resources{},
 		},
+		Networks: networkConfig,
 	}, spec.TaskTemplate)
 
 	require.Nil(t, spec.EndpointSpec)
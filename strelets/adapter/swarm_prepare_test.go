@@ -11,7 +11,7 @@ import (
 func Test_getVirtualChainServiceSpec(t *testing.T) {
 	containerName := "node1-vchain-42"
 	secrets := []*swarm.SecretReference{
-		getSecretReference(containerName, "some-secret-id", "some-secret-name", "some-secret.json"),
+		getSecretReference(&dockerSwarmSecret{id: "some-secret-id", name: "some-secret-name"}, "some-secret.json"),
 	}
 	mounts := []mount.Mount{
 		{Source: "vol1"},
@@ -60,6 +60,7 @@ func Test_getVirtualChainServiceSpec(t *testing.T) {
 			},
 			Reservations: &swarm.Resources{},
 		},
+		Networks: networkConfig,
 	})
 
 	require.EqualValues(t, spec.EndpointSpec, &swarm.EndpointSpec{
@@ -6,6 +6,8 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/orbs-network/boyarin/crypto"
+	"regexp"
 	"strings"
 )
 
@@ -17,41 +19,43 @@ const SSL_KEY = "ssl-key"
 func (d *dockerSwarmOrchestrator) storeVirtualChainConfiguration(ctx context.Context, containerName string, config *AppConfig) (*dockerSwarmSecretsConfig, error) {
 	secrets := &dockerSwarmSecretsConfig{}
 
-	if configSecretId, err := d.saveSwarmSecret(ctx, containerName, "config", config.Config); err != nil {
+	if configSecret, err := d.saveSwarmSecret(ctx, containerName, "config", config.Config); err != nil {
 		return nil, fmt.Errorf("could not store config secret: %s", err)
 	} else {
-		secrets.configSecretId = configSecretId
+		secrets.configSecret = configSecret
 	}
 
-	if keyPairSecretId, err := d.saveSwarmSecret(ctx, containerName, "keyPair", config.KeyPair); err != nil {
+	if keyPairSecret, err := d.saveSwarmSecret(ctx, containerName, "keyPair", config.KeyPair); err != nil {
 		return nil, fmt.Errorf("could not store key pair secret: %s", err)
 	} else {
-		secrets.keysSecretId = keyPairSecretId
+		secrets.keysSecret = keyPairSecret
 	}
 
-	if networkSecretId, err := d.saveSwarmSecret(ctx, containerName, "network", config.Network); err != nil {
+	if networkSecret, err := d.saveSwarmSecret(ctx, containerName, "network", config.Network); err != nil {
 		return nil, fmt.Errorf("could not store network config secret: %s", err)
 	} else {
-		secrets.networkSecretId = networkSecretId
+		secrets.networkSecret = networkSecret
 	}
 
 	return secrets, nil
 }
 
-func (d *dockerSwarmOrchestrator) saveSwarmSecret(ctx context.Context, containerName string, secretName string, content []byte) (string, error) {
-	secretId := getSwarmSecretName(containerName, secretName)
+// Secrets are immutable and can not be removed while in use, so every version of the content gets its own secret.
+// That way the updated service and the spec it can roll back to have their own copies.
+func (d *dockerSwarmOrchestrator) saveSwarmSecret(ctx context.Context, containerName string, secretName string, content []byte) (*dockerSwarmSecret, error) {
+	name := getSwarmSecretVersionName(containerName, secretName, content)
 
 	if secrets, err := d.client.SecretList(ctx, types.SecretListOptions{
 		Filters: filters.NewArgs(filters.KeyValuePair{
 			Key:   "name",
-			Value: secretId,
+			Value: name,
 		}),
 	}); err != nil {
-		return "", fmt.Errorf("could not list swarm secrets: %s", err)
+		return nil, fmt.Errorf("could not list swarm secrets: %s", err)
 	} else {
 		for _, secret := range secrets {
-			if err := d.client.SecretRemove(ctx, secret.ID); err != nil {
-				return "", fmt.Errorf("failed to remove a secret %s: :%s", secretName, err)
+			if secret.Spec.Name == name {
+				return &dockerSwarmSecret{id: secret.ID, name: name}, nil
 			}
 		}
 	}
@@ -59,45 +63,101 @@ func (d *dockerSwarmOrchestrator) saveSwarmSecret(ctx context.Context, container
 	secretSpec := swarm.SecretSpec{
 		Data: content,
 	}
-	secretSpec.Name = secretId
+	secretSpec.Name = name
 
 	response, err := d.client.SecretCreate(ctx, secretSpec)
-	return response.ID, err
+	if err != nil {
+		return nil, err
+	}
+
+	return &dockerSwarmSecret{id: response.ID, name: name}, nil
+}
+
+// Removes secrets of the container that are referenced neither by the current nor by the previous service spec
+func (d *dockerSwarmOrchestrator) pruneSwarmSecrets(ctx context.Context, containerName string, service *swarm.Service) error {
+	inUse := make(map[string]bool)
+	if service != nil {
+		for _, spec := range []*swarm.ServiceSpec{&service.Spec, service.PreviousSpec} {
+			if spec == nil || spec.TaskTemplate.ContainerSpec == nil {
+				continue
+			}
+
+			for _, secret := range spec.TaskTemplate.ContainerSpec.Secrets {
+				inUse[secret.SecretID] = true
+			}
+		}
+	}
+
+	secrets, err := d.client.SecretList(ctx, types.SecretListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{
+			Key:   "name",
+			Value: containerName,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("could not list swarm secrets: %s", err)
+	}
+
+	for _, secret := range secrets {
+		if inUse[secret.ID] || !isSwarmSecretOf(containerName, secret.Spec.Name) {
+			continue
+		}
+
+		if err := d.client.SecretRemove(ctx, secret.ID); err != nil {
+			d.logger.Info(fmt.Sprintf("could not remove unused secret %s: %s", secret.Spec.Name, err))
+		}
+	}
+
+	return nil
 }
 
 func getSwarmSecretName(containerName string, secretName string) string {
 	return strings.Join([]string{containerName, secretName}, "-")
 }
 
+const SWARM_SECRET_HASH_LENGTH = 12
+
+func getSwarmSecretVersionName(containerName string, secretName string, content []byte) string {
+	return getSwarmSecretName(containerName, secretName) + "-" + crypto.CalculateHash(content)[:SWARM_SECRET_HASH_LENGTH]
+}
+
+var swarmSecretNames = regexp.MustCompile(fmt.Sprintf(`^(config|keyPair|network|nginx\.conf|vchains\.conf|ssl-cert|ssl-key)(-[0-9a-f]{%d})?$`, SWARM_SECRET_HASH_LENGTH))
+
+// Matches both versioned secrets and the ones created before secrets were versioned
+func isSwarmSecretOf(containerName string, name string) bool {
+	prefix := containerName + "-"
+	return strings.HasPrefix(name, prefix) && swarmSecretNames.MatchString(strings.TrimPrefix(name, prefix))
+}
+
 func (d *dockerSwarmOrchestrator) storeNginxConfiguration(ctx context.Context, config *ReverseProxyConfig) (*dockerSwarmNginxSecretsConfig, error) {
 	secrets := &dockerSwarmNginxSecretsConfig{}
 
-	if nginxConfId, err := d.saveSwarmSecret(ctx, config.ContainerName, NGINX_CONF, []byte(DEFAULT_NGINX_CONFIG)); err != nil {
+	if nginxConf, err := d.saveSwarmSecret(ctx, config.ContainerName, NGINX_CONF, []byte(DEFAULT_NGINX_CONFIG)); err != nil {
 		return nil, fmt.Errorf("could not store nginx default config secret: %s", err)
 	} else {
-		secrets.nginxConfId = nginxConfId
+		secrets.nginxConf = nginxConf
 	}
 
-	if vchainConfId, err := d.saveSwarmSecret(ctx, config.ContainerName, VCHAINS_CONF, []byte(config.NginxConfig)); err != nil {
+	if vchainConf, err := d.saveSwarmSecret(ctx, config.ContainerName, VCHAINS_CONF, []byte(config.NginxConfig)); err != nil {
 		return nil, fmt.Errorf("could not store nginx vchains config secret: %s", err)
 	} else {
-		secrets.vchainConfId = vchainConfId
+		secrets.vchainConf = vchainConf
 	}
 
 	if config.SSLCertificate != nil {
-		if sslCertificateId, err := d.saveSwarmSecret(ctx, config.ContainerName, SSL_CERT, config.SSLCertificate); err != nil {
+		if sslCertificate, err := d.saveSwarmSecret(ctx, config.ContainerName, SSL_CERT, config.SSLCertificate); err != nil {
 			return nil, fmt.Errorf("could not store nginx ssl certificate secret: %s", err)
 		} else {
-			secrets.sslCertificateId = sslCertificateId
+			secrets.sslCertificate = sslCertificate
 		}
 
 	}
 
 	if config.SSLPrivateKey != nil {
-		if sslPrivateKeyId, err := d.saveSwarmSecret(ctx, config.ContainerName, SSL_KEY, config.SSLPrivateKey); err != nil {
+		if sslPrivateKey, err := d.saveSwarmSecret(ctx, config.ContainerName, SSL_KEY, config.SSLPrivateKey); err != nil {
 			return nil, fmt.Errorf("could not store nginx ssl private key secret: %s", err)
 		} else {
-			secrets.sslPrivateKeyId = sslPrivateKeyId
+			secrets.sslPrivateKey = sslPrivateKey
 		}
 	}
 
@@ -0,0 +1,23 @@
+package adapter
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_getSwarmSecretVersionName(t *testing.T) {
+	first := getSwarmSecretVersionName("node1-chain-42", "config", []byte("{}"))
+	require.EqualValues(t, "node1-chain-42-config-44136fa355b3", first)
+	require.EqualValues(t, first, getSwarmSecretVersionName("node1-chain-42", "config", []byte("{}")))
+	require.NotEqual(t, first, getSwarmSecretVersionName("node1-chain-42", "config", []byte(`{"a":1}`)))
+}
+
+func Test_isSwarmSecretOf(t *testing.T) {
+	require.True(t, isSwarmSecretOf("chain-42", "chain-42-config-44136fa355b3"))
+	require.True(t, isSwarmSecretOf("chain-42", "chain-42-keyPair"))
+	require.True(t, isSwarmSecretOf("http-api-reverse-proxy", "http-api-reverse-proxy-ssl-cert-44136fa355b3"))
+
+	require.False(t, isSwarmSecretOf("chain-4", "chain-42-config-44136fa355b3"))
+	require.False(t, isSwarmSecretOf("signer", "signer-v2-config-44136fa355b3"))
+	require.False(t, isSwarmSecretOf("chain-42", "chain-42-something-else"))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
 	"github.com/pkg/errors"
 	"os"
 	"strings"
@@ -28,23 +29,83 @@ func getServiceVolumeName(serviceName string, postfix string) string {
 	return fmt.Sprintf("%s-%s", serviceName, postfix)
 }
 
+// shared between orchestrators that talk to the Docker Engine API directly
+type dockerVolumes struct {
+	client  *client.Client
+	options *OrchestratorOptions
+}
+
+func (d *dockerSwarmOrchestrator) volumes() *dockerVolumes {
+	return &dockerVolumes{client: d.client, options: d.options}
+}
+
 func (d *dockerSwarmOrchestrator) provisionVchainVolume(ctx context.Context, nodeAddress string, vcId uint32) (mount.Mount, error) {
-	return d.provisionVolume(ctx, getVchainVolumeName(nodeAddress, vcId, "blocks"), ORBS_BLOCKS_TARGET, d.options)
+	return d.volumes().provisionVchainVolume(ctx, nodeAddress, vcId)
 }
 
 func (d *dockerSwarmOrchestrator) provisionLogsVolume(ctx context.Context, serviceName string, mountTarget string) (mount.Mount, error) {
-	return d.provisionVolume(ctx, getServiceVolumeName(serviceName, "logs"), mountTarget, d.options)
+	return d.volumes().provisionLogsVolume(ctx, serviceName, mountTarget)
 }
 
 func (d *dockerSwarmOrchestrator) provisionStatusVolume(ctx context.Context, serviceName string, mountTarget string) (mount.Mount, error) {
-	return d.provisionVolume(ctx, getServiceVolumeName(serviceName, "status"), mountTarget, d.options)
+	return d.volumes().provisionStatusVolume(ctx, serviceName, mountTarget)
+}
+
+func (d *dockerSwarmOrchestrator) provisionServiceVolumes(ctx context.Context, containerName string, logsMountPointNames map[string]string) ([]mount.Mount, error) {
+	return d.volumes().provisionServiceVolumes(ctx, containerName, logsMountPointNames)
+}
+
+func (v *dockerVolumes) provisionVchainVolume(ctx context.Context, nodeAddress string, vcId uint32) (mount.Mount, error) {
+	return v.provisionVolume(ctx, getVchainVolumeName(nodeAddress, vcId, "blocks"), ORBS_BLOCKS_TARGET)
+}
+
+func (v *dockerVolumes) provisionLogsVolume(ctx context.Context, serviceName string, mountTarget string) (mount.Mount, error) {
+	return v.provisionVolume(ctx, getServiceVolumeName(serviceName, "logs"), mountTarget)
+}
+
+func (v *dockerVolumes) provisionStatusVolume(ctx context.Context, serviceName string, mountTarget string) (mount.Mount, error) {
+	return v.provisionVolume(ctx, getServiceVolumeName(serviceName, "status"), mountTarget)
 }
 
-func (d *dockerSwarmOrchestrator) provisionCacheVolume(ctx context.Context, serviceName string) (mount.Mount, error) {
-	return d.provisionVolume(ctx, getServiceVolumeName(serviceName, "cache"), ORBS_CACHE_TARGET, d.options)
+func (v *dockerVolumes) provisionCacheVolume(ctx context.Context, serviceName string) (mount.Mount, error) {
+	return v.provisionVolume(ctx, getServiceVolumeName(serviceName, "cache"), ORBS_CACHE_TARGET)
+}
+
+func (v *dockerVolumes) provisionServiceVolumes(ctx context.Context, containerName string, logsMountPointNames map[string]string) (mounts []mount.Mount, err error) {
+	if statusMount, err := v.provisionStatusVolume(ctx, containerName, ORBS_STATUS_TARGET); err != nil {
+		return nil, err
+	} else {
+		mounts = append(mounts, statusMount)
+	}
+
+	if cacheMount, err := v.provisionCacheVolume(ctx, containerName); err != nil {
+		return nil, err
+	} else {
+		mounts = append(mounts, cacheMount)
+	}
+
+	if len(logsMountPointNames) == 0 {
+		if logsMount, err := v.provisionLogsVolume(ctx, containerName, ORBS_LOGS_TARGET); err != nil {
+			return nil, fmt.Errorf("failed to provision volumes: %s", err)
+		} else {
+			mounts = append(mounts, logsMount)
+		}
+	} else {
+		// special case for multiple logs
+		for simpleName, namespacedName := range logsMountPointNames {
+			if logsMount, err := v.provisionLogsVolume(ctx, namespacedName, GetNestedLogsMountPath(simpleName)); err != nil {
+				return nil, fmt.Errorf("failed to provision volumes: %s", err)
+			} else {
+				mounts = append(mounts, logsMount)
+			}
+		}
+	}
+
+	return mounts, nil
 }
 
-func (d *dockerSwarmOrchestrator) provisionVolume(ctx context.Context, volumeName string, target string, orchestratorOptions *OrchestratorOptions) (mount.Mount, error) {
+func (v *dockerVolumes) provisionVolume(ctx context.Context, volumeName string, target string) (mount.Mount, error) {
+	orchestratorOptions := v.options
 	if orchestratorOptions.StorageDriver == REXRAY_EBS_DRIVER {
 		return mount.Mount{}, errors.Errorf("%s storage driver is no longer supported, please consult how to enable EFS instead", REXRAY_EBS_DRIVER)
 	}
@@ -52,7 +113,7 @@ func (d *dockerSwarmOrchestrator) provisionVolume(ctx context.Context, volumeNam
 	driverName := LOCAL_DRIVER
 	source, driverOptions := getVolumeDriverOptions(volumeName, orchestratorOptions)
 
-	_, err := d.client.VolumeCreate(ctx, volume.VolumeCreateBody{
+	_, err := v.client.VolumeCreate(ctx, volume.VolumeCreateBody{
 		Name:       volumeName,
 		Driver:     driverName,
 		DriverOpts: driverOptions,
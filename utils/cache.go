@@ -39,6 +39,15 @@ func (cm *CacheMap) CheckNewJsonValue(key string, value interface{}) bool {
 	return cm.getFilter(key).CheckNewJsonValue(value)
 }
 
+// Marks the value as already applied without checking it
+func (cm *CacheMap) SetValue(key string, value Hasher) {
+	cm.getFilter(key).SetValue(value)
+}
+
+func (cm *CacheMap) SetJsonValue(key string, value interface{}) {
+	cm.getFilter(key).SetJsonValue(value)
+}
+
 func (cm *CacheMap) Clear(key string) {
 	cm.mux.Lock()
 	delete(cm.values, key)
@@ -86,6 +95,14 @@ func (cf *CacheFilter) CheckNewJsonValue(value interface{}) bool {
 	return cf.checkHash(hash)
 }
 
+func (cf *CacheFilter) SetValue(value Hasher) {
+	cf.CheckNewValue(value)
+}
+
+func (cf *CacheFilter) SetJsonValue(value interface{}) {
+	cf.CheckNewJsonValue(value)
+}
+
 func (cf *CacheFilter) Clear() {
 	cf.lastVal = "cleared"
 }
@@ -63,3 +63,14 @@ func TestClearCacheMap(t *testing.T) {
 	cache.Clear("1")
 	assert.True(t, cache.CheckNewValue("1", &HashedValue{Value: "foo"}))
 }
+
+func TestSetCacheMapValue(t *testing.T) {
+	var cache = NewCacheMap()
+
+	cache.SetValue("1", &HashedValue{Value: "foo"})
+	assert.False(t, cache.CheckNewValue("1", &HashedValue{Value: "foo"}))
+
+	cache.SetJsonValue("2", &Value1{Value: "foo"})
+	assert.False(t, cache.CheckNewJsonValue("2", &Value1{Value: "foo"}))
+	assert.True(t, cache.CheckNewJsonValue("2", &Value1{Value: "bar"}))
+}
@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLines(t *testing.T) {
+	require.Equal(t, "", DiffLines("a\nb\n", "a\nb\n"))
+
+	require.Equal(t, "  a\n- b\n+ B\n  c\n+ d\n", DiffLines("a\nb\nc\n", "a\nB\nc\nd\n"))
+
+	require.Equal(t, "+ a\n+ b\n", DiffLines("", "a\nb\n"))
+}
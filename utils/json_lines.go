@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const ROTATED_SUFFIX = ".1"
+const MAX_JSON_LINE_SIZE = 10 * 1024 * 1024
+
+// Append only log of json lines, rotated to a single backup file once it reaches max size
+type JsonLinesLog struct {
+	path    string
+	maxSize int64
+
+	mutex sync.Mutex
+}
+
+func NewJsonLinesLog(path string, maxSize int64) *JsonLinesLog {
+	return &JsonLinesLog{
+		path:    path,
+		maxSize: maxSize,
+	}
+}
+
+func (l *JsonLinesLog) Append(value interface{}) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(l.path); err == nil && info.Size()+int64(len(line)) > l.maxSize {
+		if err := os.Rename(l.path, l.path+ROTATED_SUFFIX); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(line)
+	return err
+}
+
+// Oldest lines first, including the rotated file. Missing files are empty,
+// partially written lines are up to the caller to skip when they do not unmarshal.
+func (l *JsonLinesLog) Scan(read func(line []byte)) error {
+	for _, path := range []string{l.path + ROTATED_SUFFIX, l.path} {
+		if err := scanLines(path, read); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanLines(path string, read func(line []byte)) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), MAX_JSON_LINE_SIZE)
+	for scanner.Scan() {
+		read(scanner.Bytes())
+	}
+
+	return scanner.Err()
+}
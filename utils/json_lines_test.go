@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonLinesLog_ScansRotatedFileFirst(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boyar-json-lines")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log := NewJsonLinesLog(filepath.Join(dir, "nested", "events.log"), 20)
+
+	var lines []string
+	require.NoError(t, log.Scan(func(line []byte) {
+		lines = append(lines, string(line))
+	}), "missing files should be empty")
+	require.Empty(t, lines)
+
+	for _, value := range []string{"first", "second", "third"} {
+		require.NoError(t, log.Append(map[string]string{"v": value}))
+	}
+
+	require.NoError(t, log.Scan(func(line []byte) {
+		lines = append(lines, string(line))
+	}))
+	require.Equal(t, []string{`{"v":"second"}`, `{"v":"third"}`}, lines)
+}